@@ -1,7 +1,6 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"net/http"
 
@@ -13,8 +12,12 @@ import (
 
 var errUserIsNotOwner = errors.New("account does not belong to authenticated user")
 
+var errSystemProduct = errors.New("system accounts are only opened by the bank")
+
 type createAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"`
+	// omitted opens a checking account
+	ProductCode string `json:"product_code" binding:"max=32"`
 }
 
 func (server *Server) createAccount(ctx *gin.Context) {
@@ -26,10 +29,26 @@ func (server *Server) createAccount(ctx *gin.Context) {
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
+	if len(req.ProductCode) == 0 {
+		req.ProductCode = db.DefaultProductCode
+	}
+	product, valid := server.loadProduct(ctx, req.ProductCode)
+	if !valid {
+		return
+	}
+	if product.AccountType == db.AccountTypeSystem {
+		ctx.JSON(http.StatusForbidden, errorResponse(errSystemProduct))
+		return
+	}
+	if !productCurrency(ctx, product, req.Currency) {
+		return
+	}
+
 	arg := db.CreateAccountParams{
-		Owner:    authPayload.Username,
-		Balance:  0,
-		Currency: req.Currency,
+		Owner:       authPayload.Username,
+		Balance:     0,
+		Currency:    req.Currency,
+		ProductCode: product.Code,
 	}
 
 	account, err := server.store.CreateAccount(ctx, arg)
@@ -58,23 +77,27 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
-	account, err := server.store.GetAccount(ctx, req.Id)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	account, valid := server.ownedAccount(ctx, req.Id)
+	if !valid {
 		return
 	}
 
+	ctx.JSON(http.StatusOK, account)
+}
+
+// ownedAccount loads the account and checks it belongs to the authenticated user, writing the error response if not
+func (server *Server) ownedAccount(ctx *gin.Context, accountId int64) (db.Account, bool) {
+	account, valid := server.loadAccount(ctx, accountId)
+	if !valid {
+		return account, false
+	}
+
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	if authPayload.Username != account.Owner {
 		ctx.JSON(http.StatusUnauthorized, errorResponse(errUserIsNotOwner))
-		return
+		return account, false
 	}
-
-	ctx.JSON(http.StatusOK, account)
+	return account, true
 }
 
 type listAccountsRequest struct {
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.transitionAccountStatus(ctx, db.AccountStatusActive, db.AccountStatusFrozen)
+}
+
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.transitionAccountStatus(ctx, db.AccountStatusFrozen, db.AccountStatusActive)
+}
+
+// transitionAccountStatus moves the account from one status to another, answering 409 when it does not have the
+// status the change starts from
+func (server *Server) transitionAccountStatus(ctx *gin.Context, from, to db.AccountStatus) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	account, valid := server.loadAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+	account, err := server.store.TransitionAccountStatus(ctx, db.TransitionAccountStatusParams{
+		ID:         account.ID,
+		FromStatus: from,
+		ToStatus:   to,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusConflict, errorResponse(fmt.Errorf("%w: account must be %s", db.ErrAccountStatusConflict, from)))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, account)
+}
+
+type closeAccountRequest struct {
+	// may be omitted when the account is empty
+	SweepToAccountId int64 `json:"sweep_to_account_id" binding:"omitempty,min=1"`
+}
+
+func (server *Server) closeAccount(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	// an empty body closes an account with nothing to sweep
+	var req closeAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.SweepToAccountId == uri.Id {
+		ctx.JSON(http.StatusBadRequest, errorResponse(db.ErrInvalidSweepAccount))
+		return
+	}
+	account, valid := server.ownedAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+
+	arg := db.CloseAccountTxParams{ID: account.ID}
+	if req.SweepToAccountId > 0 {
+		// the balance may only be swept to another account of the same owner
+		sweepAccount, valid := server.ownedAccount(ctx, req.SweepToAccountId)
+		if !valid {
+			return
+		}
+		arg.SweepToAccountID = sweepAccount.ID
+		if sweepAccount.Currency != account.Currency {
+			rate, err := server.rates.GetRate(ctx, account.Currency, sweepAccount.Currency, time.Now())
+			if err != nil {
+				rateErrorResponse(ctx, err)
+				return
+			}
+			arg.ExchangeRate = rate.Rate
+		}
+	}
+
+	result, err := server.store.CloseAccountTx(ctx, arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrAccountNotActive):
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		case errors.Is(err, db.ErrAccountHasHolds), errors.Is(err, db.ErrAccountOverdrawn),
+			errors.Is(err, db.ErrSweepAccountRequired), errors.Is(err, db.ErrInvalidExchangeRate):
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
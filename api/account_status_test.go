@@ -0,0 +1,310 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreezeAccountApi(t *testing.T) {
+	user, _ := createUser(t)
+	account := randomAccount(user.Username)
+	account.Status = db.AccountStatusActive
+
+	testCases := []struct {
+		name          string
+		action        string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Freeze",
+			action: "freeze",
+			role:   utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				frozen := account
+				frozen.Status = db.AccountStatusFrozen
+				arg := db.TransitionAccountStatusParams{ID: account.ID, FromStatus: db.AccountStatusActive, ToStatus: db.AccountStatusFrozen}
+				store.EXPECT().TransitionAccountStatus(gomock.Any(), gomock.Eq(arg)).Times(1).Return(frozen, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.Account
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, db.AccountStatusFrozen, got.Status)
+			},
+		},
+		{
+			name:   "Unfreeze",
+			action: "unfreeze",
+			role:   utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				frozen := account
+				frozen.Status = db.AccountStatusFrozen
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(frozen, nil)
+				arg := db.TransitionAccountStatusParams{ID: account.ID, FromStatus: db.AccountStatusFrozen, ToStatus: db.AccountStatusActive}
+				store.EXPECT().TransitionAccountStatus(gomock.Any(), gomock.Eq(arg)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.Account
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, db.AccountStatusActive, got.Status)
+			},
+		},
+		{
+			name:   "NotAdmin",
+			action: "freeze",
+			role:   utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransitionAccountStatus(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "AccountNotFound",
+			action: "freeze",
+			role:   utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().TransitionAccountStatus(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "WrongStatus",
+			action: "unfreeze",
+			role:   utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().TransitionAccountStatus(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:   "InternalError",
+			action: "freeze",
+			role:   utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().TransitionAccountStatus(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/accounts/%d/%s", account.ID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, "admin", tc.role)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCloseAccountApi(t *testing.T) {
+	user, _ := createUser(t)
+	other, _ := createUser(t)
+	account := randomAccount(user.Username)
+	account.Currency = utils.USD
+	sweepAccount := randomAccount(user.Username)
+	sweepAccount.ID = account.ID + 1
+	sweepAccount.Currency = utils.USD
+	eurAccount := randomAccount(user.Username)
+	eurAccount.ID = account.ID + 2
+	eurAccount.Currency = utils.EUR
+	otherAccount := randomAccount(other.Username)
+	otherAccount.ID = account.ID + 3
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"sweep_to_account_id": sweepAccount.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(sweepAccount.ID)).Times(1).Return(sweepAccount, nil)
+				closed := account
+				closed.Balance = 0
+				closed.Status = db.AccountStatusClosed
+				arg := db.CloseAccountTxParams{ID: account.ID, SweepToAccountID: sweepAccount.ID}
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.CloseAccountTxResult{Account: closed}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.CloseAccountTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, db.AccountStatusClosed, got.Account.Status)
+			},
+		},
+		{
+			name: "EmptyAccount",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.CloseAccountTxParams{ID: account.ID}
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.CloseAccountTxResult{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NoBody",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.CloseAccountTxParams{ID: account.ID}
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.CloseAccountTxResult{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "CrossCurrencySweep",
+			body: gin.H{"sweep_to_account_id": eurAccount.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(eurAccount.ID)).Times(1).Return(eurAccount, nil)
+				rate := db.Rate{BaseCurrency: utils.USD, QuoteCurrency: utils.EUR, Rate: 92_000_000}
+				store.EXPECT().GetEffectiveRate(gomock.Any(), gomock.Any()).Times(1).Return(rate, nil)
+				arg := db.CloseAccountTxParams{ID: account.ID, SweepToAccountID: eurAccount.ID, ExchangeRate: rate.Rate}
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.CloseAccountTxResult{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "SweepToSameAccount",
+			body: gin.H{"sweep_to_account_id": account.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "SweepToOtherOwner",
+			body: gin.H{"sweep_to_account_id": otherAccount.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(otherAccount.ID)).Times(1).Return(otherAccount, nil)
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotActive",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.CloseAccountTxResult{}, db.ErrAccountNotActive)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "SweepAccountRequired",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.CloseAccountTxResult{}, db.ErrSweepAccountRequired)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "HasHolds",
+			body: gin.H{"sweep_to_account_id": sweepAccount.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(sweepAccount.ID)).Times(1).Return(sweepAccount, nil)
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.CloseAccountTxResult{}, db.ErrAccountHasHolds)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CloseAccountTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.CloseAccountTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			if tc.body != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(tc.body))
+			}
+			url := fmt.Sprintf("/accounts/%d/close", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, &body)
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, user.Username)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
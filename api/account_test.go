@@ -279,6 +279,8 @@ func TestCreateAccount(t *testing.T) {
 	account := randomAccount(user.Username)
 
 	account.Balance = 0
+	account.ProductCode = db.DefaultProductCode
+	checking := db.Product{Code: db.DefaultProductCode, Name: "Checking", AccountType: db.AccountTypeChecking, CanBeSource: true}
 	testCases := []struct {
 		name          string
 		body          gin.H
@@ -294,10 +296,12 @@ func TestCreateAccount(t *testing.T) {
 			},
 			authUsername: user.Username,
 			buildStub: func(store *mockdb.MockStore) {
+				store.EXPECT().GetProduct(gomock.Any(), gomock.Eq(db.DefaultProductCode)).Times(1).Return(checking, nil)
 				arg := db.CreateAccountParams{
-					Owner:    user.Username,
-					Balance:  0,
-					Currency: account.Currency,
+					Owner:       user.Username,
+					Balance:     0,
+					Currency:    account.Currency,
+					ProductCode: db.DefaultProductCode,
 				}
 				store.EXPECT().
 					CreateAccount(gomock.Any(), gomock.Eq(arg)).
@@ -318,9 +322,10 @@ func TestCreateAccount(t *testing.T) {
 			authUsername: "",
 			buildStub: func(store *mockdb.MockStore) {
 				arg := db.CreateAccountParams{
-					Owner:    account.Owner,
-					Balance:  0,
-					Currency: account.Currency,
+					Owner:       account.Owner,
+					Balance:     0,
+					Currency:    account.Currency,
+					ProductCode: db.DefaultProductCode,
 				}
 				store.EXPECT().
 					CreateAccount(gomock.Any(), gomock.Eq(arg)).
@@ -354,10 +359,12 @@ func TestCreateAccount(t *testing.T) {
 			},
 			authUsername: account.Owner,
 			buildStub: func(store *mockdb.MockStore) {
+				store.EXPECT().GetProduct(gomock.Any(), gomock.Any()).Times(1).Return(checking, nil)
 				arg := db.CreateAccountParams{
-					Owner:    account.Owner,
-					Balance:  0,
-					Currency: account.Currency,
+					Owner:       account.Owner,
+					Balance:     0,
+					Currency:    account.Currency,
+					ProductCode: db.DefaultProductCode,
 				}
 				store.EXPECT().
 					CreateAccount(gomock.Any(), gomock.Eq(arg)).
@@ -368,6 +375,74 @@ func TestCreateAccount(t *testing.T) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name: "SavingsProduct",
+			body: gin.H{
+				"currency":     utils.EUR,
+				"product_code": "savings",
+			},
+			authUsername: user.Username,
+			buildStub: func(store *mockdb.MockStore) {
+				savings := db.Product{Code: "savings", Name: "Savings", AccountType: db.AccountTypeSavings, AllowedCurrencies: []string{utils.USD, utils.EUR}}
+				store.EXPECT().GetProduct(gomock.Any(), gomock.Eq("savings")).Times(1).Return(savings, nil)
+				arg := db.CreateAccountParams{
+					Owner:       user.Username,
+					Currency:    utils.EUR,
+					ProductCode: "savings",
+				}
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.Account{ProductCode: "savings"}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyNotAvailable",
+			body: gin.H{
+				"currency":     utils.CAD,
+				"product_code": "savings",
+			},
+			authUsername: user.Username,
+			buildStub: func(store *mockdb.MockStore) {
+				savings := db.Product{Code: "savings", Name: "Savings", AccountType: db.AccountTypeSavings, AllowedCurrencies: []string{utils.USD, utils.EUR}}
+				store.EXPECT().GetProduct(gomock.Any(), gomock.Eq("savings")).Times(1).Return(savings, nil)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "SystemProduct",
+			body: gin.H{
+				"currency":     utils.USD,
+				"product_code": "system",
+			},
+			authUsername: user.Username,
+			buildStub: func(store *mockdb.MockStore) {
+				system := db.Product{Code: "system", Name: "System", AccountType: db.AccountTypeSystem, CanBeSource: true}
+				store.EXPECT().GetProduct(gomock.Any(), gomock.Eq("system")).Times(1).Return(system, nil)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "ProductNotFound",
+			body: gin.H{
+				"currency":     utils.USD,
+				"product_code": "gold",
+			},
+			authUsername: user.Username,
+			buildStub: func(store *mockdb.MockStore) {
+				store.EXPECT().GetProduct(gomock.Any(), gomock.Eq("gold")).Times(1).Return(db.Product{}, sql.ErrNoRows)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/reconcile"
+	"github.com/gin-gonic/gin"
+)
+
+// getTxStats reports how many transactions were retried after serialization failures and deadlocks
+func (server *Server) getTxStats(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.store.TxStats())
+}
+
+type reconcileRequest struct {
+	ChunkSize int32 `form:"chunk_size" binding:"omitempty,min=1,max=10000"`
+}
+
+// reconcileLedger checks the whole ledger and returns the report, discrepancies included, with status 200
+func (server *Server) reconcileLedger(ctx *gin.Context) {
+	var req reconcileRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	chunkSize := int32(reconcile.DefaultChunkSize)
+	if req.ChunkSize > 0 {
+		chunkSize = req.ChunkSize
+	}
+	report, err := reconcile.NewReconciler(server.store, chunkSize).Run(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+// chainHeadResponse is the latest entry in the hash chain of an account, seq:hash is the checkpoint verify-chain takes.
+// An account without entries has seq 0 and the genesis hash
+type chainHeadResponse struct {
+	AccountID int64      `json:"account_id"`
+	Seq       int64      `json:"seq"`
+	Hash      string     `json:"hash"`
+	EntryID   int64      `json:"entry_id,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// getChainHead returns the head of the entry hash chain of an account so an auditor can checkpoint it
+func (server *Server) getChainHead(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if _, valid := server.loadAccount(ctx, uri.Id); !valid {
+		return
+	}
+
+	response := chainHeadResponse{
+		AccountID: uri.Id,
+		Hash:      hex.EncodeToString(db.GenesisHash()),
+	}
+	head, err := server.store.GetEntryChainHead(ctx, uri.Id)
+	switch {
+	case err == nil:
+		response.Seq = head.Seq
+		response.Hash = hex.EncodeToString(head.Hash)
+		response.EntryID = head.ID
+		response.CreatedAt = &head.CreatedAt
+	case err != sql.ErrNoRows:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/reconcile"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTxStatsApi(t *testing.T) {
+	stats := db.TxStats{
+		Retries:               7,
+		SerializationFailures: 6,
+		Deadlocks:             2,
+		Exhausted:             1,
+	}
+
+	testCases := []struct {
+		name          string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TxStats().Times(1).Return(stats)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.TxStats
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, stats, got)
+			},
+		},
+		{
+			name: "NotAdmin",
+			role: utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TxStats().Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/admin/stats/transactions", nil)
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, "admin", tc.role)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestReconcileLedgerApi(t *testing.T) {
+	testCases := []struct {
+		name          string
+		query         string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "?chunk_size=50",
+			role:  utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ReconcileAccounts(gomock.Any(), gomock.Eq(db.ReconcileAccountsParams{ChunkSize: 50})).
+					Times(1).
+					Return([]db.ReconcileAccountsRow{{ID: 1, Balance: 10, EntriesTotal: 0}}, nil)
+				store.EXPECT().
+					ReconcileTransfers(gomock.Any(), gomock.Eq(db.ReconcileTransfersParams{ChunkSize: 50})).
+					Times(1).
+					Return([]db.ReconcileTransfersRow{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got reconcile.Report
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, int64(1), got.AccountsChecked)
+				require.Equal(t, []reconcile.Discrepancy{
+					{Kind: reconcile.BalanceMismatch, AccountID: 1, Expected: 0, Actual: 10},
+				}, got.Discrepancies)
+			},
+		},
+		{
+			name: "NotAdmin",
+			role: utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReconcileAccounts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidChunkSize",
+			query: "?chunk_size=20000",
+			role:  utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReconcileAccounts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReconcileAccounts(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/admin/reconciliation"+tc.query, nil)
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, "admin", tc.role)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetChainHeadApi(t *testing.T) {
+	user, _ := createUser(t)
+	account := randomAccount(user.Username)
+	head := db.Entry{
+		ID:        77,
+		AccountID: account.ID,
+		Amount:    -10,
+		CreatedAt: time.Now().Truncate(time.Microsecond),
+		Seq:       12,
+		PrevHash:  db.GenesisHash(),
+	}
+	head.Hash = db.EntryHash(head)
+
+	testCases := []struct {
+		name          string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetEntryChainHead(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(head, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got chainHeadResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, int64(12), got.Seq)
+				require.Equal(t, hex.EncodeToString(head.Hash), got.Hash)
+				require.Equal(t, head.ID, got.EntryID)
+			},
+		},
+		{
+			name: "NoEntries",
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetEntryChainHead(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Entry{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got chainHeadResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Zero(t, got.Seq)
+				require.Equal(t, hex.EncodeToString(db.GenesisHash()), got.Hash)
+				require.Nil(t, got.CreatedAt)
+			},
+		},
+		{
+			name: "NotAdmin",
+			role: utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetEntryChainHead(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetEntryChainHead(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetEntryChainHead(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Entry{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/accounts/%d/chain_head", account.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, "admin", tc.role)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
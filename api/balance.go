@@ -0,0 +1,56 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+type getBalanceRequest struct {
+	AsOf time.Time `form:"as_of" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type balanceResponse struct {
+	AccountID int64     `json:"account_id"`
+	Currency  string    `json:"currency"`
+	AsOf      time.Time `json:"as_of"`
+	Balance   int64     `json:"balance"`
+}
+
+// getAccountBalance returns the balance of the account at a past instant, derived from its entries
+func (server *Server) getAccountBalance(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req getBalanceRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.AsOf.After(time.Now()) {
+		err := errors.New("as_of must not be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	account, valid := server.ownedAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+
+	balance, err := db.BalanceAt(ctx, server.store, account.ID, req.AsOf)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, balanceResponse{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		AsOf:      req.AsOf,
+		Balance:   balance,
+	})
+}
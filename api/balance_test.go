@@ -0,0 +1,164 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountBalanceApi(t *testing.T) {
+	user1, _ := createUser(t)
+	user2, _ := createUser(t)
+	account := randomAccount(user1.Username)
+
+	asOf := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)
+	snapshot := db.BalanceSnapshot{
+		AccountID:  account.ID,
+		SnapshotAt: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+		Balance:    1000,
+	}
+
+	testCases := []struct {
+		name          string
+		asOf          string
+		authUsername  string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "OK",
+			asOf:         asOf.Format(time.RFC3339),
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					GetLatestBalanceSnapshot(gomock.Any(), gomock.Eq(db.GetLatestBalanceSnapshotParams{AccountID: account.ID, SnapshotAt: asOf})).
+					Times(1).
+					Return(snapshot, nil)
+				// only the entries since the snapshot are replayed
+				arg := db.SumEntriesBetweenParams{
+					AccountID: account.ID,
+					StartTime: snapshot.SnapshotAt,
+					EndTime:   asOf,
+				}
+				store.EXPECT().SumEntriesBetween(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(-250), nil)
+				store.EXPECT().SumEntriesBefore(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var response balanceResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, account.ID, response.AccountID)
+				require.Equal(t, account.Currency, response.Currency)
+				require.True(t, asOf.Equal(response.AsOf))
+				require.Equal(t, int64(750), response.Balance)
+			},
+		},
+		{
+			name:         "NoSnapshot",
+			asOf:         asOf.Format(time.RFC3339),
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					GetLatestBalanceSnapshot(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BalanceSnapshot{}, sql.ErrNoRows)
+				arg := db.SumEntriesBeforeParams{
+					AccountID: account.ID,
+					CreatedAt: asOf,
+				}
+				store.EXPECT().SumEntriesBefore(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(300), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var response balanceResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, int64(300), response.Balance)
+			},
+		},
+		{
+			name:         "UnauthorizedUser",
+			asOf:         asOf.Format(time.RFC3339),
+			authUsername: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetLatestBalanceSnapshot(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:         "MissingAsOf",
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:         "FutureAsOf",
+			asOf:         time.Now().Add(time.Hour).Format(time.RFC3339),
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:         "InternalError",
+			asOf:         asOf.Format(time.RFC3339),
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					GetLatestBalanceSnapshot(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BalanceSnapshot{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+
+			query := url.Values{}
+			if tc.asOf != "" {
+				query.Set("as_of", tc.asOf)
+			}
+			url := fmt.Sprintf("/accounts/%d/balance?%s", account.ID, query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, tc.authUsername)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+type batchTransferLeg struct {
+	FromAccountId int64           `json:"from_account_id" binding:"required,min=1"`
+	ToAccountId   int64           `json:"to_account_id" binding:"required,min=1,nefield=FromAccountId"`
+	Amount        int64           `json:"amount" binding:"required,gt=0"`
+	Description   string          `json:"description" binding:"max=140"`
+	Reference     string          `json:"reference" binding:"max=35"`
+	Metadata      json.RawMessage `json:"metadata" binding:"omitempty,metadata"`
+}
+
+type batchTransferRequest struct {
+	// every account of the batch must be in Currency, a batch does not convert
+	Currency string             `json:"currency" binding:"required,currency"`
+	Legs     []batchTransferLeg `json:"legs" binding:"required,min=1,max=500,dive"`
+}
+
+func (server *Server) createBatchTransfer(ctx *gin.Context) {
+	var request batchTransferRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// each account is checked once however many legs it appears in
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	accounts := make(map[int64]db.Account)
+	checkAccount := func(accountId int64) (db.Account, bool) {
+		if account, ok := accounts[accountId]; ok {
+			return account, true
+		}
+		account, valid := server.validAccount(ctx, accountId, request.Currency)
+		if valid {
+			accounts[accountId] = account
+		}
+		return account, valid
+	}
+	arg := db.BatchTransferTxParams{Legs: make([]db.TransferTxParams, 0, len(request.Legs))}
+	for _, leg := range request.Legs {
+		fromAccount, valid := checkAccount(leg.FromAccountId)
+		if !valid {
+			return
+		}
+		if authPayload.Username != fromAccount.Owner {
+			err := errors.New("from acccount does not belong to the authenticated user")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		if _, valid := checkAccount(leg.ToAccountId); !valid {
+			return
+		}
+		arg.Legs = append(arg.Legs, db.TransferTxParams{
+			FromAccountId: leg.FromAccountId,
+			ToAccountId:   leg.ToAccountId,
+			Amount:        leg.Amount,
+			Description:   leg.Description,
+			Reference:     leg.Reference,
+			Metadata:      leg.Metadata,
+		})
+	}
+
+	result, err := server.store.BatchTransferTx(ctx, arg)
+	if err != nil {
+		batchTransferErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// batchTransferErrorResponse adds the index of the failing leg to the error response when there is one
+func batchTransferErrorResponse(ctx *gin.Context, err error) {
+	var legErr *db.BatchLegError
+	if !errors.As(err, &legErr) {
+		transferErrorResponse(ctx, err)
+		return
+	}
+	status := http.StatusInternalServerError
+	switch {
+	case isInsufficientFunds(legErr.Err), errors.Is(legErr.Err, db.ErrTransferLimitExceeded),
+		errors.Is(legErr.Err, db.ErrProductRuleViolated), errors.Is(legErr.Err, db.ErrAccountNotActive),
+		errors.Is(legErr.Err, db.ErrApprovalRequired):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(legErr.Err, db.ErrInvalidBatchLeg):
+		status = http.StatusBadRequest
+	case errors.Is(legErr.Err, sql.ErrNoRows):
+		status = http.StatusNotFound
+	}
+	ctx.JSON(status, gin.H{"error": err.Error(), "leg": legErr.Index})
+}
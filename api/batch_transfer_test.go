@@ -0,0 +1,178 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTransferApi(t *testing.T) {
+	user1, _ := createUser(t)
+	user2, _ := createUser(t)
+	user3, _ := createUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account3 := randomAccount(user3.Username)
+	account1.Currency = utils.USD
+	account2.Currency = utils.USD
+	account3.Currency = utils.USD
+	account2.ID = account1.ID + 1
+	account3.ID = account1.ID + 2
+
+	legs := []gin.H{
+		{"from_account_id": account1.ID, "to_account_id": account2.ID, "amount": 10},
+		{"from_account_id": account1.ID, "to_account_id": account3.ID, "amount": 20},
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		authUsername  string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "OK",
+			body:         gin.H{"currency": utils.USD, "legs": legs},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				// the shared from account is only loaded once
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				arg := db.BatchTransferTxParams{Legs: []db.TransferTxParams{
+					{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 10},
+					{FromAccountId: account1.ID, ToAccountId: account3.ID, Amount: 20},
+				}}
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.BatchTransferTxResult{Legs: make([]db.TransferTxResult, 2)}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.BatchTransferTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got.Legs, 2)
+			},
+		},
+		{
+			name:         "NoLegs",
+			body:         gin.H{"currency": utils.USD, "legs": []gin.H{}},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidLeg",
+			body: gin.H{"currency": utils.USD, "legs": []gin.H{
+				legs[0],
+				{"from_account_id": account1.ID, "to_account_id": account1.ID, "amount": 20},
+			}},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{"currency": utils.USD, "legs": []gin.H{
+				legs[0],
+				{"from_account_id": account2.ID, "to_account_id": account3.ID, "amount": 20},
+			}},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:         "LegInsufficientFunds",
+			body:         gin.H{"currency": utils.USD, "legs": legs},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BatchTransferTxResult{}, &db.BatchLegError{Index: 1, Err: db.ErrInsufficientFunds})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				var got struct {
+					Leg int `json:"leg"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, 1, got.Leg)
+			},
+		},
+		{
+			name:         "LegApprovalRequired",
+			body:         gin.H{"currency": utils.USD, "legs": legs},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().
+					BatchTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BatchTransferTxResult{}, &db.BatchLegError{Index: 0, Err: db.ErrApprovalRequired})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				var got struct {
+					Error string `json:"error"`
+					Leg   int    `json:"leg"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, 0, got.Leg)
+				require.Contains(t, got.Error, db.ErrApprovalRequired.Error())
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/transfers/batch", bytes.NewReader(data))
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, tc.authUsername)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/fx"
+	"github.com/DingBao-sys/simple_bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+type feeScheduleResponse struct {
+	Currency   string    `json:"currency"`
+	FlatFee    int64     `json:"flat_fee"`
+	Percentage int64     `json:"percentage"`
+	MinFee     int64     `json:"min_fee"`
+	MaxFee     *int64    `json:"max_fee,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func newFeeScheduleResponse(schedule db.FeeSchedule) feeScheduleResponse {
+	return feeScheduleResponse{
+		Currency:   schedule.Currency,
+		FlatFee:    schedule.FlatFee,
+		Percentage: schedule.Percentage,
+		MinFee:     schedule.MinFee,
+		MaxFee:     nullInt64Ptr(schedule.MaxFee),
+		UpdatedAt:  schedule.UpdatedAt,
+	}
+}
+
+func (server *Server) listFeeSchedules(ctx *gin.Context) {
+	schedules, err := server.store.ListFeeSchedules(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	response := make([]feeScheduleResponse, 0, len(schedules))
+	for _, schedule := range schedules {
+		response = append(response, newFeeScheduleResponse(schedule))
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+type feeScheduleUri struct {
+	Currency string `uri:"currency" binding:"required,currency"`
+}
+
+type setFeeScheduleRequest struct {
+	FlatFee int64 `json:"flat_fee" binding:"min=0"`
+	// Percentage is scaled by 1e8 like exchange rates, 0.5% is 500000
+	Percentage int64  `json:"percentage" binding:"min=0,max=100000000"`
+	MinFee     int64  `json:"min_fee" binding:"min=0"`
+	MaxFee     *int64 `json:"max_fee" binding:"omitempty,min=0"`
+}
+
+// setFeeSchedule replaces the fee schedule of a currency, it applies to every transfer from then on
+func (server *Server) setFeeSchedule(ctx *gin.Context) {
+	var uri feeScheduleUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req setFeeScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.MaxFee != nil && *req.MaxFee < req.MinFee {
+		err := errors.New("max_fee must not be less than min_fee")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	schedule, err := server.store.UpsertFeeSchedule(ctx, db.UpsertFeeScheduleParams{
+		Currency:   uri.Currency,
+		FlatFee:    req.FlatFee,
+		Percentage: req.Percentage,
+		MinFee:     req.MinFee,
+		MaxFee:     int64PtrNull(req.MaxFee),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newFeeScheduleResponse(schedule))
+}
+
+// deleteFeeSchedule stops charging fees on transfers in the currency
+func (server *Server) deleteFeeSchedule(ctx *gin.Context) {
+	var uri feeScheduleUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	deleted, err := server.store.DeleteFeeSchedule(ctx, uri.Currency)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if deleted == 0 {
+		err := errors.New("currency has no fee schedule")
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+type previewTransferRequest struct {
+	FromAccountId int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountId   int64  `json:"to_account_id" binding:"required_without=Recipient,excluded_with=Recipient,omitempty,min=1"`
+	Recipient     string `json:"recipient" binding:"max=255"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+}
+
+// previewTransferResponse shows what a transfer would cost at the current fee schedule and exchange rate, nothing is
+// reserved so the transfer itself may still come out different
+type previewTransferResponse struct {
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Currency      string `json:"currency"`
+	Amount        int64  `json:"amount"`
+	Fee           int64  `json:"fee"`
+	// TotalDebit is the amount plus the fee, what leaves the source account
+	TotalDebit   int64  `json:"total_debit"`
+	ToCurrency   string `json:"to_currency"`
+	ToAmount     int64  `json:"to_amount"`
+	ExchangeRate string `json:"exchange_rate,omitempty"`
+	// SufficientFunds is whether the available balance and overdraft cover the total debit right now
+	SufficientFunds bool `json:"sufficient_funds"`
+}
+
+func (server *Server) previewTransfer(ctx *gin.Context) {
+	var request previewTransferRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	fromAccount, valid := server.validAccount(ctx, request.FromAccountId, request.Currency)
+	if !valid {
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Username != fromAccount.Owner {
+		err := errors.New("from acccount does not belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	toAccount, valid := server.destinationAccount(ctx, request.ToAccountId, request.Recipient, fromAccount)
+	if !valid {
+		return
+	}
+
+	response := previewTransferResponse{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Currency:      fromAccount.Currency,
+		Amount:        request.Amount,
+		ToCurrency:    toAccount.Currency,
+		ToAmount:      request.Amount,
+	}
+	if toAccount.Currency != fromAccount.Currency {
+		rate, err := server.rates.GetRate(ctx, fromAccount.Currency, toAccount.Currency, time.Now())
+		if err != nil {
+			rateErrorResponse(ctx, err)
+			return
+		}
+		response.ExchangeRate = fx.FormatRate(rate.Rate)
+		response.ToAmount, err = fx.Convert(request.Amount, rate.Rate)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+	fee, err := db.TransferFee(ctx, server.store, fromAccount.Currency, request.Amount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	response.Fee = fee
+	response.TotalDebit = request.Amount + fee
+	response.SufficientFunds = fromAccount.AvailableBalance-response.TotalDebit >= -fromAccount.OverdraftLimit
+	ctx.JSON(http.StatusOK, response)
+}
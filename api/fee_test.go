@@ -0,0 +1,393 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewTransferApi(t *testing.T) {
+	user1, _ := createUser(t)
+	user2, _ := createUser(t)
+	account1 := randomAccount(user1.Username)
+	account1.Currency = utils.USD
+	account1.Balance = 1000
+	account1.AvailableBalance = 1000
+	account2 := randomAccount(user2.Username)
+	account2.ID = account1.ID + 1
+	account2.Currency = utils.USD
+	account3 := randomAccount(user2.Username)
+	account3.ID = account1.ID + 2
+	account3.Currency = utils.EUR
+	// 25 flat plus 1%, at least 50 and at most 500
+	schedule := db.FeeSchedule{
+		Currency:   utils.USD,
+		FlatFee:    25,
+		Percentage: 1_000_000,
+		MinFee:     50,
+		MaxFee:     sql.NullInt64{Int64: 500, Valid: true},
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"currency":        utils.USD,
+				"amount":          900,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetFeeSchedule(gomock.Any(), gomock.Eq(utils.USD)).Times(1).Return(schedule, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got previewTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, int64(900), got.Amount)
+				require.Equal(t, int64(50), got.Fee)
+				require.Equal(t, int64(950), got.TotalDebit)
+				require.Equal(t, int64(900), got.ToAmount)
+				require.Empty(t, got.ExchangeRate)
+				require.True(t, got.SufficientFunds)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"currency":        utils.USD,
+				"amount":          990,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetFeeSchedule(gomock.Any(), gomock.Eq(utils.USD)).Times(1).Return(schedule, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// the amount alone fits the balance, the fee on top does not
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got previewTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, int64(1040), got.TotalDebit)
+				require.False(t, got.SufficientFunds)
+			},
+		},
+		{
+			name: "NoFeeSchedule",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"currency":        utils.USD,
+				"amount":          100,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetFeeSchedule(gomock.Any(), gomock.Eq(utils.USD)).Times(1).Return(db.FeeSchedule{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got previewTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Zero(t, got.Fee)
+				require.Equal(t, int64(100), got.TotalDebit)
+			},
+		},
+		{
+			name: "CrossCurrency",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account3.ID,
+				"currency":        utils.USD,
+				"amount":          100,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				rate := db.Rate{BaseCurrency: utils.USD, QuoteCurrency: utils.EUR, Rate: 92_000_000}
+				store.EXPECT().GetEffectiveRate(gomock.Any(), gomock.Any()).Times(1).Return(rate, nil)
+				store.EXPECT().GetFeeSchedule(gomock.Any(), gomock.Eq(utils.USD)).Times(1).Return(schedule, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got previewTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, int64(50), got.Fee)
+				require.Equal(t, utils.EUR, got.ToCurrency)
+				require.Equal(t, int64(92), got.ToAmount)
+				require.Equal(t, "0.92000000", got.ExchangeRate)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{
+				"from_account_id": account2.ID,
+				"to_account_id":   account1.ID,
+				"currency":        utils.USD,
+				"amount":          100,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetFeeSchedule(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"currency":        utils.EUR,
+				"amount":          100,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetFeeSchedule(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"currency":        utils.USD,
+				"amount":          100,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetFeeSchedule(gomock.Any(), gomock.Any()).Times(1).Return(db.FeeSchedule{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/transfers/preview", bytes.NewReader(data))
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, user1.Username)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListFeeSchedulesApi(t *testing.T) {
+	user, _ := createUser(t)
+	schedules := []db.FeeSchedule{
+		{Currency: utils.EUR, FlatFee: 30},
+		{Currency: utils.USD, Percentage: 500_000, MinFee: 10, MaxFee: sql.NullInt64{Int64: 1000, Valid: true}},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListFeeSchedules(gomock.Any()).Times(1).Return(schedules, nil)
+
+	server := NewTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/fee_schedules", nil)
+	require.NoError(t, err)
+	createAndSetAuthToken(t, request, server.maker, user.Username)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var got []feeScheduleResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Len(t, got, 2)
+	require.Equal(t, int64(30), got[0].FlatFee)
+	require.Nil(t, got[0].MaxFee)
+	require.Equal(t, int64(500_000), got[1].Percentage)
+	require.Equal(t, int64(1000), *got[1].MaxFee)
+}
+
+func TestSetFeeScheduleApi(t *testing.T) {
+	testCases := []struct {
+		name          string
+		currency      string
+		body          gin.H
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			currency: utils.USD,
+			body:     gin.H{"flat_fee": 25, "percentage": 1_000_000, "min_fee": 50, "max_fee": 500},
+			role:     utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpsertFeeScheduleParams{
+					Currency:   utils.USD,
+					FlatFee:    25,
+					Percentage: 1_000_000,
+					MinFee:     50,
+					MaxFee:     sql.NullInt64{Int64: 500, Valid: true},
+				}
+				store.EXPECT().
+					UpsertFeeSchedule(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.FeeSchedule{Currency: arg.Currency, FlatFee: 25, Percentage: 1_000_000, MinFee: 50, MaxFee: arg.MaxFee}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got feeScheduleResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, utils.USD, got.Currency)
+				require.Equal(t, int64(500), *got.MaxFee)
+			},
+		},
+		{
+			name:     "NotAdmin",
+			currency: utils.USD,
+			body:     gin.H{"flat_fee": 25},
+			role:     utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertFeeSchedule(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "UnsupportedCurrency",
+			currency: "XYZ",
+			body:     gin.H{"flat_fee": 25},
+			role:     utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertFeeSchedule(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "MaxBelowMin",
+			currency: utils.USD,
+			body:     gin.H{"min_fee": 50, "max_fee": 10},
+			role:     utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertFeeSchedule(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "PercentageAboveAll",
+			currency: utils.USD,
+			body:     gin.H{"percentage": 100_000_001},
+			role:     utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertFeeSchedule(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			url := fmt.Sprintf("/admin/fee_schedules/%s", tc.currency)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, "admin", tc.role)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDeleteFeeScheduleApi(t *testing.T) {
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteFeeSchedule(gomock.Any(), gomock.Eq(utils.USD)).Times(1).Return(int64(1), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteFeeSchedule(gomock.Any(), gomock.Eq(utils.USD)).Times(1).Return(int64(0), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodDelete, "/admin/fee_schedules/"+utils.USD, nil)
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, "admin", utils.AdminRole)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
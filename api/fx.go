@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/fx"
+	"github.com/DingBao-sys/simple_bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var errSameCurrencyQuote = errors.New("from_currency and to_currency must differ")
+
+type createQuoteRequest struct {
+	FromCurrency string `json:"from_currency" binding:"required,currency"`
+	ToCurrency   string `json:"to_currency" binding:"required,currency"`
+	Amount       int64  `json:"amount" binding:"required,gt=0"`
+}
+
+type quoteResponse struct {
+	QuoteID      uuid.UUID `json:"quote_id"`
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	Rate         string    `json:"rate"`
+	FromAmount   int64     `json:"from_amount"`
+	ToAmount     int64     `json:"to_amount"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func newQuoteResponse(quote db.FxQuote) quoteResponse {
+	return quoteResponse{
+		QuoteID:      quote.ID,
+		FromCurrency: quote.FromCurrency,
+		ToCurrency:   quote.ToCurrency,
+		Rate:         fx.FormatRate(quote.Rate),
+		FromAmount:   quote.FromAmount,
+		ToAmount:     quote.ToAmount,
+		ExpiresAt:    quote.ExpiresAt,
+	}
+}
+
+func (server *Server) createQuote(ctx *gin.Context) {
+	var req createQuoteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.FromCurrency == req.ToCurrency {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errSameCurrencyQuote))
+		return
+	}
+
+	now := time.Now()
+	rate, err := server.rates.GetRate(ctx, req.FromCurrency, req.ToCurrency, now)
+	if err != nil {
+		rateErrorResponse(ctx, err)
+		return
+	}
+	toAmount, err := fx.Convert(req.Amount, rate.Rate)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	quoteID, err := uuid.NewRandom()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	quote, err := server.store.CreateFxQuote(ctx, db.CreateFxQuoteParams{
+		ID:           quoteID,
+		Username:     authPayload.Username,
+		FromCurrency: req.FromCurrency,
+		ToCurrency:   req.ToCurrency,
+		Rate:         rate.Rate,
+		FromAmount:   req.Amount,
+		ToAmount:     toAmount,
+		ExpiresAt:    now.Add(server.config.FXQuoteDuration),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newQuoteResponse(quote))
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func randomQuote(username, fromCurrency, toCurrency string, amount int64) db.FxQuote {
+	return db.FxQuote{
+		ID:           uuid.New(),
+		Username:     username,
+		FromCurrency: fromCurrency,
+		ToCurrency:   toCurrency,
+		Rate:         92_000_000,
+		FromAmount:   amount,
+		ToAmount:     amount * 92 / 100,
+		ExpiresAt:    time.Now().Add(time.Minute),
+		CreatedAt:    time.Now(),
+	}
+}
+
+func TestCreateQuoteApi(t *testing.T) {
+	user, _ := createUser(t)
+	amount := int64(100)
+	quote := randomQuote(user.Username, utils.USD, utils.EUR, amount)
+	rate := db.Rate{BaseCurrency: utils.USD, QuoteCurrency: utils.EUR, Rate: quote.Rate}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_currency": utils.USD,
+				"to_currency":   utils.EUR,
+				"amount":        amount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetEffectiveRate(gomock.Any(), gomock.Any()).Times(1).Return(rate, nil)
+				store.EXPECT().
+					CreateFxQuote(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateFxQuoteParams) (db.FxQuote, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, quote.Rate, arg.Rate)
+						require.Equal(t, amount, arg.FromAmount)
+						require.Equal(t, quote.ToAmount, arg.ToAmount)
+						require.WithinDuration(t, time.Now().Add(30*time.Second), arg.ExpiresAt, time.Second)
+						return quote, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				data, err := io.ReadAll(recorder.Body)
+				require.NoError(t, err)
+				var got quoteResponse
+				require.NoError(t, json.Unmarshal(data, &got))
+				require.Equal(t, quote.ID, got.QuoteID)
+				require.Equal(t, "0.92000000", got.Rate)
+				require.Equal(t, quote.ToAmount, got.ToAmount)
+			},
+		},
+		{
+			name: "SameCurrency",
+			body: gin.H{
+				"from_currency": utils.USD,
+				"to_currency":   utils.USD,
+				"amount":        amount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetEffectiveRate(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateFxQuote(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAmount",
+			body: gin.H{
+				"from_currency": utils.USD,
+				"to_currency":   utils.EUR,
+				"amount":        -1,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateFxQuote(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoExchangeRate",
+			body: gin.H{
+				"from_currency": utils.USD,
+				"to_currency":   utils.EUR,
+				"amount":        amount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetEffectiveRate(gomock.Any(), gomock.Any()).Times(1).Return(db.Rate{}, sql.ErrNoRows)
+				store.EXPECT().CreateFxQuote(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "InternalServerError",
+			body: gin.H{
+				"from_currency": utils.USD,
+				"to_currency":   utils.EUR,
+				"amount":        amount,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetEffectiveRate(gomock.Any(), gomock.Any()).Times(1).Return(rate, nil)
+				store.EXPECT().CreateFxQuote(gomock.Any(), gomock.Any()).Times(1).Return(db.FxQuote{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			jsonBody, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/fx/quotes", bytes.NewReader(jsonBody))
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, user.Username)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/token"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type createHoldRequest struct {
+	FromAccountId int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountId   int64  `json:"to_account_id" binding:"required,min=1"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	// ExpiresAt defaults to, and may not be later than, the configured hold duration from now
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type holdResponse struct {
+	ID            int64         `json:"id"`
+	FromAccountID int64         `json:"from_account_id"`
+	ToAccountID   int64         `json:"to_account_id"`
+	Amount        int64         `json:"amount"`
+	Fee           int64         `json:"fee"`
+	Status        db.HoldStatus `json:"status"`
+	TransferID    *int64        `json:"transfer_id,omitempty"`
+	ExpiresAt     time.Time     `json:"expires_at"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+func newHoldResponse(hold db.Hold) holdResponse {
+	return holdResponse{
+		ID:            hold.ID,
+		FromAccountID: hold.FromAccountID,
+		ToAccountID:   hold.ToAccountID,
+		Amount:        hold.Amount,
+		Fee:           hold.Fee,
+		Status:        hold.Status,
+		TransferID:    nullInt64Ptr(hold.TransferID),
+		ExpiresAt:     hold.ExpiresAt,
+		CreatedAt:     hold.CreatedAt,
+	}
+}
+
+func (server *Server) createHold(ctx *gin.Context) {
+	var request createHoldRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	now := time.Now()
+	latest := now.Add(server.config.HoldDuration)
+	expiresAt := request.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = latest
+	}
+	if !expiresAt.After(now) || expiresAt.After(latest) {
+		err := errors.New("expires_at must be in the future and within the maximum hold duration")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, request.FromAccountId, request.Currency)
+	if !valid {
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Username != fromAccount.Owner {
+		err := errors.New("from acccount does not belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	// a hold is captured through a plain transfer, so both accounts must share the currency
+	if _, valid := server.validAccount(ctx, request.ToAccountId, request.Currency); !valid {
+		return
+	}
+
+	result, err := server.store.AuthorizeHoldTx(ctx, db.AuthorizeHoldTxParams{
+		FromAccountId: request.FromAccountId,
+		ToAccountId:   request.ToAccountId,
+		Amount:        request.Amount,
+		ExpiresAt:     expiresAt,
+	})
+	if err != nil {
+		transferErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newHoldResponse(result.Hold))
+}
+
+type holdRequest struct {
+	Id int64 `uri:"id" binding:"required,min=1"`
+}
+
+type captureHoldRequest struct {
+	// Amount is transferred to the receiving account, leave it out to capture the whole hold
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+type captureHoldResponse struct {
+	Hold holdResponse `json:"hold"`
+	db.TransferTxResult
+}
+
+func (server *Server) captureHold(ctx *gin.Context) {
+	var uri holdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	// an empty body captures the whole hold
+	var req captureHoldRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if _, valid := server.receivedHold(ctx, uri.Id); !valid {
+		return
+	}
+
+	result, err := server.store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{
+		HoldID: uri.Id,
+		Amount: req.Amount,
+	})
+	if err != nil {
+		holdErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, captureHoldResponse{
+		Hold:             newHoldResponse(result.Hold),
+		TransferTxResult: result.TransferTxResult,
+	})
+}
+
+func (server *Server) voidHold(ctx *gin.Context) {
+	var uri holdRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if _, valid := server.receivedHold(ctx, uri.Id); !valid {
+		return
+	}
+
+	result, err := server.store.VoidHoldTx(ctx, uri.Id)
+	if err != nil {
+		holdErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newHoldResponse(result.Hold))
+}
+
+// receivedHold loads the hold and checks the authenticated user owns the receiving account or is an admin, writing
+// the error response if not. Only the receiver settles a hold, the payer cannot withdraw an authorization
+func (server *Server) receivedHold(ctx *gin.Context, id int64) (db.Hold, bool) {
+	hold, err := server.store.GetHold(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return hold, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return hold, false
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role == utils.AdminRole {
+		return hold, true
+	}
+	toAccount, valid := server.loadAccount(ctx, hold.ToAccountID)
+	if !valid {
+		return hold, false
+	}
+	if authPayload.Username != toAccount.Owner {
+		err := errors.New("only the receiving account owner or an admin can settle a hold")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return hold, false
+	}
+	return hold, true
+}
+
+func holdErrorResponse(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, db.ErrHoldNotActive):
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	case errors.Is(err, db.ErrHoldExpired), errors.Is(err, db.ErrCaptureExceedsHold):
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+	default:
+		transferErrorResponse(ctx, err)
+	}
+}
@@ -0,0 +1,373 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomHold(fromAccount, toAccount db.Account) db.Hold {
+	return db.Hold{
+		ID:            utils.GenerateRandomInt(1, 1000),
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        utils.GenerateRandomInt(1, 1000),
+		Status:        db.HoldStatusActive,
+		ExpiresAt:     time.Now().UTC().Add(time.Hour).Truncate(time.Second),
+		CreatedAt:     time.Now().Truncate(time.Second),
+	}
+}
+
+func TestCreateHoldApi(t *testing.T) {
+	user1, _ := createUser(t)
+	user2, _ := createUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = utils.USD
+	account2.Currency = utils.USD
+
+	hold := randomHold(account1, account2)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		authUsername  string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          hold.Amount,
+				"currency":        utils.USD,
+				"expires_at":      hold.ExpiresAt,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				arg := db.AuthorizeHoldTxParams{
+					FromAccountId: account1.ID,
+					ToAccountId:   account2.ID,
+					Amount:        hold.Amount,
+					ExpiresAt:     hold.ExpiresAt,
+				}
+				store.EXPECT().
+					AuthorizeHoldTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.HoldTxResult{Hold: hold, Account: account1}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got holdResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, hold.ID, got.ID)
+				require.Equal(t, db.HoldStatusActive, got.Status)
+				require.Nil(t, got.TransferID)
+			},
+		},
+		{
+			name: "DefaultExpiry",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          hold.Amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					AuthorizeHoldTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.AuthorizeHoldTxParams) (db.HoldTxResult, error) {
+						require.WithinDuration(t, time.Now().Add(24*time.Hour), arg.ExpiresAt, time.Minute)
+						return db.HoldTxResult{Hold: hold}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiresAfterMaximum",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          hold.Amount,
+				"currency":        utils.USD,
+				"expires_at":      time.Now().Add(48 * time.Hour),
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().AuthorizeHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          hold.Amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().AuthorizeHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          hold.Amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					AuthorizeHoldTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.HoldTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "ApprovalRequired",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          hold.Amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					AuthorizeHoldTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.HoldTxResult{}, db.ErrApprovalRequired)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/holds", bytes.NewReader(data))
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, tc.authUsername)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSettleHoldApi(t *testing.T) {
+	user1, _ := createUser(t)
+	user2, _ := createUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account2.ID = account1.ID + 1
+	hold := randomHold(account1, account2)
+
+	testCases := []struct {
+		name          string
+		action        string
+		body          gin.H
+		authUsername  string
+		authRole      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "CaptureAll",
+			action:       "capture",
+			authUsername: user2.Username,
+			authRole:     utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				captured := hold
+				captured.Status = db.HoldStatusCaptured
+				captured.TransferID = sql.NullInt64{Int64: 7, Valid: true}
+				store.EXPECT().
+					CaptureHoldTx(gomock.Any(), gomock.Eq(db.CaptureHoldTxParams{HoldID: hold.ID})).
+					Times(1).
+					Return(db.CaptureHoldTxResult{Hold: captured}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got captureHoldResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, db.HoldStatusCaptured, got.Hold.Status)
+				require.Equal(t, int64(7), *got.Hold.TransferID)
+			},
+		},
+		{
+			name:         "CapturePartByAdmin",
+			action:       "capture",
+			body:         gin.H{"amount": 1},
+			authUsername: "admin",
+			authRole:     utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				arg := db.CaptureHoldTxParams{HoldID: hold.ID, Amount: 1}
+				store.EXPECT().CaptureHoldTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:         "CaptureByPayer",
+			action:       "capture",
+			authUsername: user1.Username,
+			authRole:     utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CaptureHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:         "CaptureExceedsHold",
+			action:       "capture",
+			body:         gin.H{"amount": hold.Amount + 1},
+			authUsername: user2.Username,
+			authRole:     utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					CaptureHoldTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CaptureHoldTxResult{}, db.ErrCaptureExceedsHold)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:         "CaptureNotFound",
+			action:       "capture",
+			authUsername: user2.Username,
+			authRole:     utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(db.Hold{}, sql.ErrNoRows)
+				store.EXPECT().CaptureHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:         "Void",
+			action:       "void",
+			authUsername: user2.Username,
+			authRole:     utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				voided := hold
+				voided.Status = db.HoldStatusVoided
+				store.EXPECT().
+					VoidHoldTx(gomock.Any(), gomock.Eq(hold.ID)).
+					Times(1).
+					Return(db.HoldTxResult{Hold: voided, Account: account1}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got holdResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, db.HoldStatusVoided, got.Status)
+			},
+		},
+		{
+			name:         "VoidNotActive",
+			action:       "void",
+			authUsername: user2.Username,
+			authRole:     utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					VoidHoldTx(gomock.Any(), gomock.Eq(hold.ID)).
+					Times(1).
+					Return(db.HoldTxResult{}, db.ErrHoldNotActive)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body bytes.Buffer
+			if tc.body != nil {
+				require.NoError(t, json.NewEncoder(&body).Encode(tc.body))
+			}
+			url := fmt.Sprintf("/holds/%d/%s", hold.ID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, &body)
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, tc.authUsername, tc.authRole)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
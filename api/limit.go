@@ -0,0 +1,135 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+// periodLimitResponse is the headroom left on a daily or monthly limit
+type periodLimitResponse struct {
+	Limit     int64 `json:"limit"`
+	Used      int64 `json:"used"`
+	Remaining int64 `json:"remaining"`
+}
+
+// limitResponse leaves out the limits that do not apply to the account
+type limitResponse struct {
+	AccountID   int64                `json:"account_id"`
+	Currency    string               `json:"currency"`
+	MaxTransfer *int64               `json:"max_transfer,omitempty"`
+	Daily       *periodLimitResponse `json:"daily,omitempty"`
+	Monthly     *periodLimitResponse `json:"monthly,omitempty"`
+}
+
+func (server *Server) listLimits(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	rows, err := server.store.ListOwnerAccountLimits(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	dayStart, monthStart := db.LimitPeriods(time.Now())
+	response := make([]limitResponse, 0, len(rows))
+	for _, row := range rows {
+		limit := limitResponse{
+			AccountID:   row.AccountID,
+			Currency:    row.Currency,
+			MaxTransfer: nullInt64Ptr(row.MaxTransfer),
+		}
+		limit.Daily, err = server.periodLimit(ctx, row, row.DailyLimit, dayStart)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		limit.Monthly, err = server.periodLimit(ctx, row, row.MonthlyLimit, monthStart)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		response = append(response, limit)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// periodLimit sums what the account has sent since the start of the period and what its active holds reserve, nil
+// when there is no limit
+func (server *Server) periodLimit(ctx *gin.Context, row db.ListOwnerAccountLimitsRow, limit sql.NullInt64, since time.Time) (*periodLimitResponse, error) {
+	if !limit.Valid {
+		return nil, nil
+	}
+	used, err := server.store.SumOutgoingEntriesSince(ctx, db.SumOutgoingEntriesSinceParams{
+		AccountID: row.AccountID,
+		CreatedAt: since,
+	})
+	if err != nil {
+		return nil, err
+	}
+	used += row.HeldAmount
+	return &periodLimitResponse{
+		Limit:     limit.Int64,
+		Used:      used,
+		Remaining: max(limit.Int64-used, 0),
+	}, nil
+}
+
+type setAccountLimitsRequest struct {
+	MaxTransfer  *int64 `json:"max_transfer" binding:"omitempty,min=0"`
+	DailyLimit   *int64 `json:"daily_limit" binding:"omitempty,min=0"`
+	MonthlyLimit *int64 `json:"monthly_limit" binding:"omitempty,min=0"`
+}
+
+// accountLimitsResponse shows the overrides of an account, a null limit falls back to the tier of the owner
+type accountLimitsResponse struct {
+	AccountID    int64     `json:"account_id"`
+	MaxTransfer  *int64    `json:"max_transfer"`
+	DailyLimit   *int64    `json:"daily_limit"`
+	MonthlyLimit *int64    `json:"monthly_limit"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (server *Server) setAccountLimits(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req setAccountLimitsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if _, valid := server.loadAccount(ctx, uri.Id); !valid {
+		return
+	}
+
+	limits, err := server.store.UpsertAccountLimits(ctx, db.UpsertAccountLimitsParams{
+		AccountID:    uri.Id,
+		MaxTransfer:  int64PtrNull(req.MaxTransfer),
+		DailyLimit:   int64PtrNull(req.DailyLimit),
+		MonthlyLimit: int64PtrNull(req.MonthlyLimit),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, accountLimitsResponse{
+		AccountID:    limits.AccountID,
+		MaxTransfer:  nullInt64Ptr(limits.MaxTransfer),
+		DailyLimit:   nullInt64Ptr(limits.DailyLimit),
+		MonthlyLimit: nullInt64Ptr(limits.MonthlyLimit),
+		UpdatedAt:    limits.UpdatedAt,
+	})
+}
+
+func int64PtrNull(value *int64) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *value, Valid: true}
+}
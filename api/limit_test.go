@@ -0,0 +1,206 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListLimitsApi(t *testing.T) {
+	user, _ := createUser(t)
+	account1 := randomAccount(user.Username)
+	account2 := randomAccount(user.Username)
+
+	limited := db.ListOwnerAccountLimitsRow{
+		AccountID:    account1.ID,
+		Currency:     account1.Currency,
+		HeldAmount:   100,
+		MaxTransfer:  sql.NullInt64{Int64: 500, Valid: true},
+		DailyLimit:   sql.NullInt64{Int64: 1000, Valid: true},
+		MonthlyLimit: sql.NullInt64{Int64: 5000, Valid: true},
+	}
+	unlimited := db.ListOwnerAccountLimitsRow{
+		AccountID: account2.ID,
+		Currency:  account2.Currency,
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListOwnerAccountLimits(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return([]db.ListOwnerAccountLimitsRow{limited, unlimited}, nil)
+				dayStart, monthStart := db.LimitPeriods(time.Now())
+				store.EXPECT().
+					SumOutgoingEntriesSince(gomock.Any(), gomock.Eq(db.SumOutgoingEntriesSinceParams{AccountID: account1.ID, CreatedAt: dayStart})).
+					Times(1).
+					Return(int64(1200), nil)
+				store.EXPECT().
+					SumOutgoingEntriesSince(gomock.Any(), gomock.Eq(db.SumOutgoingEntriesSinceParams{AccountID: account1.ID, CreatedAt: monthStart})).
+					Times(1).
+					Return(int64(3000), nil)
+				// an account without limits needs no usage
+				store.EXPECT().
+					SumOutgoingEntriesSince(gomock.Any(), gomock.Eq(db.SumOutgoingEntriesSinceParams{AccountID: account2.ID, CreatedAt: dayStart})).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got []limitResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got, 2)
+
+				require.Equal(t, int64(500), *got[0].MaxTransfer)
+				require.Equal(t, periodLimitResponse{Limit: 1000, Used: 1300, Remaining: 0}, *got[0].Daily)
+				// the held funds count as used
+				require.Equal(t, periodLimitResponse{Limit: 5000, Used: 3100, Remaining: 1900}, *got[0].Monthly)
+
+				require.Nil(t, got[1].MaxTransfer)
+				require.Nil(t, got[1].Daily)
+				require.Nil(t, got[1].Monthly)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListOwnerAccountLimits(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/limits", nil)
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, user.Username)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSetAccountLimitsApi(t *testing.T) {
+	user, _ := createUser(t)
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"daily_limit": 0, "monthly_limit": 100000},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.UpsertAccountLimitsParams{
+					AccountID:    account.ID,
+					DailyLimit:   sql.NullInt64{Int64: 0, Valid: true},
+					MonthlyLimit: sql.NullInt64{Int64: 100000, Valid: true},
+				}
+				store.EXPECT().
+					UpsertAccountLimits(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.AccountLimit{AccountID: account.ID, DailyLimit: arg.DailyLimit, MonthlyLimit: arg.MonthlyLimit}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got accountLimitsResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Nil(t, got.MaxTransfer)
+				require.Equal(t, int64(0), *got.DailyLimit)
+				require.Equal(t, int64(100000), *got.MonthlyLimit)
+			},
+		},
+		{
+			name: "NotAdmin",
+			body: gin.H{"daily_limit": 100},
+			role: utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertAccountLimits(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NegativeLimit",
+			body: gin.H{"daily_limit": -1},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertAccountLimits(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			body: gin.H{"daily_limit": 100},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().UpsertAccountLimits(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			url := fmt.Sprintf("/admin/accounts/%d/limits", account.ID)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, "admin", tc.role)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -16,8 +16,11 @@ import (
 
 func NewTestServer(t *testing.T, store db.Store) *Server {
 	config := utils.Config{
-		TokenSymetricKey:    utils.GenerateRandomString(32),
-		AccessTokenDuration: time.Minute,
+		TokenSymetricKey:        utils.GenerateRandomString(32),
+		AccessTokenDuration:     time.Minute,
+		FXQuoteDuration:         30 * time.Second,
+		HoldDuration:            24 * time.Hour,
+		IdempotencyKeyRetention: 24 * time.Hour,
 	}
 
 	server, err := NewServer(config, store)
@@ -33,10 +36,14 @@ func TestMain(m *testing.M) {
 }
 
 func createAndSetAuthToken(t *testing.T, request *http.Request, tokenMaker token.Maker, username string) {
+	createAndSetRoleAuthToken(t, request, tokenMaker, username, utils.DepositorRole)
+}
+
+func createAndSetRoleAuthToken(t *testing.T, request *http.Request, tokenMaker token.Maker, username string, role string) {
 	if len(username) == 0 {
 		return
 	}
-	token, err := tokenMaker.CreateToken(username, time.Minute)
+	token, err := tokenMaker.CreateToken(username, role, time.Minute)
 	require.NoError(t, err)
 	authorizationHeader := fmt.Sprintf("%s %s", authorizationTypeBearer, token)
 	request.Header.Set(authorizationHeaderKey, authorizationHeader)
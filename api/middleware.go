@@ -13,6 +13,7 @@ import (
 var (
 	errMissingHeader       = errors.New("authorization header not provided")
 	errInvalidHeaderFormat = errors.New("invalid authorization header format")
+	errForbiddenRole       = errors.New("the authenticated user is not allowed to access this resource")
 )
 
 const (
@@ -52,3 +53,17 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// roleMiddleware lets through only users with one of the roles, it must run after authMiddleware
+func roleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		for _, role := range roles {
+			if payload.Role == role {
+				ctx.Next()
+				return
+			}
+		}
+		ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errForbiddenRole))
+	}
+}
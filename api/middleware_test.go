@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/DingBao-sys/simple_bank/token"
+	"github.com/DingBao-sys/simple_bank/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 )
@@ -20,7 +21,7 @@ func addAuthorization(
 	username string,
 	duration time.Duration,
 ) {
-	token, err := tokenMaker.CreateToken(username, duration)
+	token, err := tokenMaker.CreateToken(username, utils.DepositorRole, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, token)
@@ -95,3 +96,32 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestRoleMiddleware(t *testing.T) {
+	testCases := []struct {
+		name         string
+		role         string
+		expectedCode int
+	}{
+		{name: "Admin", role: utils.AdminRole, expectedCode: http.StatusOK},
+		{name: "Depositor", role: utils.DepositorRole, expectedCode: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := NewTestServer(t, nil)
+			adminPath := "/admin-only"
+
+			server.router.GET(adminPath, authMiddleware(server.maker), roleMiddleware(utils.AdminRole), func(ctx *gin.Context) {
+				ctx.JSON(http.StatusOK, gin.H{})
+			})
+
+			request, err := http.NewRequest(http.MethodGet, adminPath, nil)
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, "user", tc.role)
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedCode, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/token"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type pendingTransferResponse struct {
+	ID                int64                    `json:"id"`
+	FromAccountID     int64                    `json:"from_account_id"`
+	ToAccountID       int64                    `json:"to_account_id"`
+	Amount            int64                    `json:"amount"`
+	Description       string                   `json:"description"`
+	Reference         string                   `json:"reference"`
+	Metadata          json.RawMessage          `json:"metadata"`
+	InitiatedBy       string                   `json:"initiated_by"`
+	RequiredApprovals int32                    `json:"required_approvals"`
+	ApprovalCount     int32                    `json:"approval_count"`
+	Status            db.PendingTransferStatus `json:"status"`
+	RejectedBy        *string                  `json:"rejected_by,omitempty"`
+	TransferID        *int64                   `json:"transfer_id,omitempty"`
+	FailureReason     *string                  `json:"failure_reason,omitempty"`
+	DecidedAt         *time.Time               `json:"decided_at,omitempty"`
+	CreatedAt         time.Time                `json:"created_at"`
+	RiskAssessmentID  *int64                   `json:"risk_assessment_id,omitempty"`
+	InReview          bool                     `json:"in_review"`
+	ReviewedBy        *string                  `json:"reviewed_by,omitempty"`
+}
+
+func newPendingTransferResponse(pending db.PendingTransfer) pendingTransferResponse {
+	response := pendingTransferResponse{
+		ID:                pending.ID,
+		FromAccountID:     pending.FromAccountID,
+		ToAccountID:       pending.ToAccountID,
+		Amount:            pending.Amount,
+		Description:       pending.Description,
+		Reference:         pending.Reference,
+		Metadata:          pending.Metadata,
+		InitiatedBy:       pending.InitiatedBy,
+		RequiredApprovals: pending.RequiredApprovals,
+		ApprovalCount:     pending.ApprovalCount,
+		Status:            pending.Status,
+		TransferID:        nullInt64Ptr(pending.TransferID),
+		CreatedAt:         pending.CreatedAt,
+		RiskAssessmentID:  nullInt64Ptr(pending.RiskAssessmentID),
+		InReview:          db.InReview(pending),
+	}
+	if pending.RejectedBy.Valid {
+		response.RejectedBy = &pending.RejectedBy.String
+	}
+	if pending.FailureReason.Valid {
+		response.FailureReason = &pending.FailureReason.String
+	}
+	if pending.DecidedAt.Valid {
+		response.DecidedAt = &pending.DecidedAt.Time
+	}
+	if pending.ReviewedBy.Valid {
+		response.ReviewedBy = &pending.ReviewedBy.String
+	}
+	return response
+}
+
+// pendingHold says what a held transfer waits for, approvals from the approvers of the source account, a review by
+// an admin when the risk engine flagged it, or both
+type pendingHold struct {
+	requiredApprovals int32
+	riskAssessmentID  sql.NullInt64
+}
+
+// createPendingTransfer holds a transfer until it has its approvals and review. It is executed without a quote, so it
+// has to stay within one currency. With an idempotency key, a retry gets the transfer held by the first request
+func (server *Server) createPendingTransfer(ctx *gin.Context, request transferRequest, hold pendingHold, username string, fromAccount db.Account, toAccount db.Account, idempotencyKey string, requestHash string) {
+	if toAccount.Currency != fromAccount.Currency {
+		err := fmt.Errorf("this transfer has to be approved before it runs and must be in %s", fromAccount.Currency)
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+	if len(request.QuoteId) > 0 {
+		err := errors.New("a transfer that needs approval cannot use a quote")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	metadata := request.Metadata
+	if len(metadata) == 0 {
+		metadata = json.RawMessage("{}")
+	}
+	arg := db.CreatePendingTransferParams{
+		FromAccountID:     fromAccount.ID,
+		ToAccountID:       toAccount.ID,
+		Amount:            request.Amount,
+		Description:       request.Description,
+		Reference:         request.Reference,
+		Metadata:          metadata,
+		InitiatedBy:       username,
+		RequiredApprovals: hold.requiredApprovals,
+		RiskAssessmentID:  hold.riskAssessmentID,
+	}
+	if len(idempotencyKey) == 0 {
+		pending, err := server.store.CreatePendingTransfer(ctx, arg)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusAccepted, newPendingTransferResponse(pending))
+		return
+	}
+
+	result, err := server.store.IdempotentPendingTransferTx(ctx, db.IdempotentPendingTransferTxParams{
+		CreatePendingTransferParams: arg,
+		Key:                         idempotencyKey,
+		RequestHash:                 requestHash,
+		ExpiredBefore:               time.Now().Add(-server.config.IdempotencyKeyRetention),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyReused) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if result.Replayed {
+		ctx.Header(idempotentReplayedHeader, "true")
+	}
+	ctx.JSON(http.StatusAccepted, newPendingTransferResponse(result.PendingTransfer))
+}
+
+type pendingTransferRequest struct {
+	Id int64 `uri:"id" binding:"required,min=1"`
+}
+
+// approvePendingTransfer records the approval of the authenticated user and executes the transfer once it has all
+// the approvals it needs. A transfer that fails then, for lack of funds for example, ends up failed with the reason.
+// An admin approving a transfer held by the risk engine reviews it instead
+func (server *Server) approvePendingTransfer(ctx *gin.Context) {
+	var req pendingTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	pending, valid := server.decidablePendingTransfer(ctx, req.Id, authPayload)
+	if !valid {
+		return
+	}
+
+	var err error
+	if db.InReview(pending) && authPayload.Role == utils.AdminRole {
+		pending, err = server.store.ReviewPendingTransferTx(ctx, db.ReviewPendingTransferTxParams{
+			ID:       req.Id,
+			Username: authPayload.Username,
+		})
+	} else {
+		pending, err = server.store.ApprovePendingTransferTx(ctx, db.ApprovePendingTransferTxParams{
+			ID:       req.Id,
+			Username: authPayload.Username,
+		})
+	}
+	if err != nil {
+		decisionErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newPendingTransferResponse(pending))
+}
+
+func (server *Server) rejectPendingTransfer(ctx *gin.Context) {
+	var req pendingTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if _, valid := server.decidablePendingTransfer(ctx, req.Id, authPayload); !valid {
+		return
+	}
+
+	pending, err := server.store.RejectPendingTransferTx(ctx, db.RejectPendingTransferTxParams{
+		ID:       req.Id,
+		Username: authPayload.Username,
+	})
+	if err != nil {
+		decisionErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newPendingTransferResponse(pending))
+}
+
+// decisionErrorResponse answers 403 when the store refuses the user a decision the handler let through, a change to
+// the approvers in between for example, and 409 when the transfer is no longer waiting for it
+func decisionErrorResponse(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, db.ErrInitiatorCannotDecide), errors.Is(err, db.ErrNotApprover), errors.Is(err, db.ErrNotReviewer):
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+	case errors.Is(err, db.ErrPendingTransferDecided), errors.Is(err, db.ErrAlreadyApproved), errors.Is(err, db.ErrNoReviewPending):
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
+
+// decidablePendingTransfer loads the pending transfer and checks the user is an approver of its source account other
+// than the one who started it, or an admin while the transfer is in review, writing the error response if not. The
+// store checks the same again inside the transaction deciding the transfer
+func (server *Server) decidablePendingTransfer(ctx *gin.Context, id int64, authPayload *token.Payload) (db.PendingTransfer, bool) {
+	username := authPayload.Username
+	pending, err := server.store.GetPendingTransfer(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return pending, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return pending, false
+	}
+	if pending.InitiatedBy == username {
+		ctx.JSON(http.StatusForbidden, errorResponse(db.ErrInitiatorCannotDecide))
+		return pending, false
+	}
+	if db.InReview(pending) && authPayload.Role == utils.AdminRole {
+		return pending, true
+	}
+	if pending.RequiredApprovals == 0 {
+		ctx.JSON(http.StatusForbidden, errorResponse(db.ErrNotReviewer))
+		return pending, false
+	}
+	approver, err := server.store.IsAccountApprover(ctx, db.IsAccountApproverParams{
+		AccountID: pending.FromAccountID,
+		Username:  username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return pending, false
+	}
+	if !approver {
+		ctx.JSON(http.StatusForbidden, errorResponse(db.ErrNotApprover))
+		return pending, false
+	}
+	return pending, true
+}
+
+type listPendingTransfersRequest struct {
+	PageId   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listPendingTransfers returns the transfers waiting for a decision that the user started or may approve
+func (server *Server) listPendingTransfers(ctx *gin.Context) {
+	var req listPendingTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	transfers, err := server.store.ListPendingTransfers(ctx, db.ListPendingTransfersParams{
+		Username: authPayload.Username,
+		Limit:    req.PageSize,
+		Offset:   (req.PageId - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	response := make([]pendingTransferResponse, 0, len(transfers))
+	for _, pending := range transfers {
+		response = append(response, newPendingTransferResponse(pending))
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+type setApprovalPolicyRequest struct {
+	Threshold         *int64   `json:"threshold" binding:"required,min=0"`
+	RequiredApprovals int32    `json:"required_approvals" binding:"required,min=1,max=10"`
+	Approvers         []string `json:"approvers" binding:"required,min=1,max=50,unique,dive,alphanum"`
+}
+
+type approvalPolicyResponse struct {
+	AccountID         int64     `json:"account_id"`
+	Threshold         int64     `json:"threshold"`
+	RequiredApprovals int32     `json:"required_approvals"`
+	Approvers         []string  `json:"approvers"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// setApprovalPolicy makes transfers above the threshold from an account wait for approval by the given users. The
+// owner starts the transfers, so they cannot be one of the approvers
+func (server *Server) setApprovalPolicy(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req setApprovalPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if len(req.Approvers) < int(req.RequiredApprovals) {
+		err := fmt.Errorf("%d approvals are required but only %d approvers were given", req.RequiredApprovals, len(req.Approvers))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	account, valid := server.loadAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+	for _, approver := range req.Approvers {
+		if approver == account.Owner {
+			err := errors.New("the account owner cannot be an approver")
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	result, err := server.store.SetApprovalPolicyTx(ctx, db.SetApprovalPolicyTxParams{
+		AccountID:         account.ID,
+		Threshold:         *req.Threshold,
+		RequiredApprovals: req.RequiredApprovals,
+		Approvers:         req.Approvers,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			err := errors.New("approvers must be existing users")
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	response := approvalPolicyResponse{
+		AccountID:         result.Policy.AccountID,
+		Threshold:         result.Policy.Threshold,
+		RequiredApprovals: result.Policy.RequiredApprovals,
+		Approvers:         make([]string, 0, len(result.Approvers)),
+		UpdatedAt:         result.Policy.UpdatedAt,
+	}
+	for _, approver := range result.Approvers {
+		response.Approvers = append(response.Approvers, approver.Username)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// deleteApprovalPolicy lets transfers from the account run straight away again. Transfers already pending still
+// need their approvals
+func (server *Server) deleteApprovalPolicy(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	deleted, err := server.store.DeleteApprovalPolicy(ctx, uri.Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if deleted == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
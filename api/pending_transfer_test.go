@@ -0,0 +1,668 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func randomPendingTransfer(fromAccount, toAccount db.Account, initiatedBy string) db.PendingTransfer {
+	return db.PendingTransfer{
+		ID:                utils.GenerateRandomInt(1, 1000),
+		FromAccountID:     fromAccount.ID,
+		ToAccountID:       toAccount.ID,
+		Amount:            utils.GenerateRandomInt(1000, 2000),
+		Metadata:          json.RawMessage("{}"),
+		InitiatedBy:       initiatedBy,
+		RequiredApprovals: 2,
+		Status:            db.PendingTransferStatusPending,
+		CreatedAt:         time.Now(),
+	}
+}
+
+func requireBodyMatchPendingTransfer(t *testing.T, body *bytes.Buffer, status db.PendingTransferStatus) pendingTransferResponse {
+	var got pendingTransferResponse
+	require.NoError(t, json.Unmarshal(body.Bytes(), &got))
+	require.Equal(t, status, got.Status)
+	return got
+}
+
+func TestTransferNeedsApprovalApi(t *testing.T) {
+	user1, _ := createUser(t)
+	user2, _ := createUser(t)
+	account1 := randomAccount(user1.Username)
+	account1.Currency = utils.USD
+	account2 := randomAccount(user2.Username)
+	account2.ID = account1.ID + 1
+	account2.Currency = utils.USD
+	account3 := randomAccount(user2.Username)
+	account3.ID = account1.ID + 2
+	account3.Currency = utils.EUR
+	policy := db.ApprovalPolicy{AccountID: account1.ID, Threshold: 1000, RequiredApprovals: 2}
+
+	testCases := []struct {
+		name           string
+		body           gin.H
+		idempotencyKey string
+		buildStubs     func(store *mockdb.MockStore)
+		checkResponse  func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "AboveThreshold",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1001,
+				"currency":        utils.USD,
+				"reference":       "INV-7",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(policy, nil)
+				arg := db.CreatePendingTransferParams{
+					FromAccountID:     account1.ID,
+					ToAccountID:       account2.ID,
+					Amount:            1001,
+					Reference:         "INV-7",
+					Metadata:          json.RawMessage("{}"),
+					InitiatedBy:       user1.Username,
+					RequiredApprovals: 2,
+				}
+				pending := db.PendingTransfer{
+					ID:                1,
+					FromAccountID:     account1.ID,
+					ToAccountID:       account2.ID,
+					Amount:            1001,
+					Reference:         "INV-7",
+					Metadata:          json.RawMessage("{}"),
+					InitiatedBy:       user1.Username,
+					RequiredApprovals: 2,
+					Status:            db.PendingTransferStatusPending,
+				}
+				store.EXPECT().CreatePendingTransfer(gomock.Any(), gomock.Eq(arg)).Times(1).Return(pending, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				got := requireBodyMatchPendingTransfer(t, recorder.Body, db.PendingTransferStatusPending)
+				require.Equal(t, int32(2), got.RequiredApprovals)
+				require.Zero(t, got.ApprovalCount)
+			},
+		},
+		{
+			name: "AboveThresholdWithIdempotencyKey",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1001,
+				"currency":        utils.USD,
+			},
+			idempotencyKey: "retry-key",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(policy, nil)
+				store.EXPECT().CreatePendingTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IdempotentPendingTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ any, arg db.IdempotentPendingTransferTxParams) (db.IdempotentPendingTransferTxResult, error) {
+						require.Equal(t, user1.Username, arg.InitiatedBy)
+						require.Equal(t, "retry-key", arg.Key)
+						require.NotEmpty(t, arg.RequestHash)
+						require.Equal(t, int64(1001), arg.Amount)
+						pending := db.PendingTransfer{ID: 1, Amount: arg.Amount, Status: db.PendingTransferStatusPending}
+						return db.IdempotentPendingTransferTxResult{PendingTransfer: pending}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Empty(t, recorder.Header().Get(idempotentReplayedHeader))
+				requireBodyMatchPendingTransfer(t, recorder.Body, db.PendingTransferStatusPending)
+			},
+		},
+		{
+			name: "PendingReplayed",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1001,
+				"currency":        utils.USD,
+			},
+			idempotencyKey: "retry-key",
+			buildStubs: func(store *mockdb.MockStore) {
+				requestHash, err := hashRequest(transferRequest{
+					FromAccountId: account1.ID,
+					ToAccountId:   account2.ID,
+					Amount:        1001,
+					Currency:      utils.USD,
+				})
+				require.NoError(t, err)
+				stored := db.IdempotencyKey{
+					Username:          user1.Username,
+					Key:               "retry-key",
+					RequestHash:       requestHash,
+					Response:          json.RawMessage("{}"),
+					CreatedAt:         time.Now(),
+					PendingTransferID: sql.NullInt64{Int64: 1, Valid: true},
+				}
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(stored, nil)
+				// the retry sees the pending transfer as it is now, already approved once
+				pending := db.PendingTransfer{ID: 1, Amount: 1001, ApprovalCount: 1, Status: db.PendingTransferStatusPending}
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(int64(1))).Times(1).Return(pending, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreatePendingTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IdempotentPendingTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+				got := requireBodyMatchPendingTransfer(t, recorder.Body, db.PendingTransferStatusPending)
+				require.Equal(t, int32(1), got.ApprovalCount)
+			},
+		},
+		{
+			name: "PendingReplayedConcurrently",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1001,
+				"currency":        utils.USD,
+			},
+			idempotencyKey: "retry-key",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(policy, nil)
+				result := db.IdempotentPendingTransferTxResult{
+					PendingTransfer: db.PendingTransfer{ID: 1, Status: db.PendingTransferStatusPending},
+					Replayed:        true,
+				}
+				store.EXPECT().IdempotentPendingTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			name: "AtThreshold",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1000,
+				"currency":        utils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(policy, nil)
+				store.EXPECT().CreatePendingTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "CrossCurrency",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account3.ID,
+				"amount":          1001,
+				"currency":        utils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(policy, nil)
+				store.EXPECT().CreatePendingTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().FxTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "PolicyError",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          1001,
+				"currency":        utils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(account1, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Any()).Times(1).Return(db.ApprovalPolicy{}, sql.ErrConnDone)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+			if len(tc.idempotencyKey) > 0 {
+				request.Header.Set(idempotencyKeyHeader, tc.idempotencyKey)
+			}
+			createAndSetAuthToken(t, request, server.maker, user1.Username)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestApprovePendingTransferApi(t *testing.T) {
+	initiator, _ := createUser(t)
+	approver, _ := createUser(t)
+	account1 := randomAccount(initiator.Username)
+	account2 := randomAccount(approver.Username)
+	pending := randomPendingTransfer(account1, account2, initiator.Username)
+	isApprover := db.IsAccountApproverParams{AccountID: account1.ID, Username: approver.Username}
+	approveArg := db.ApprovePendingTransferTxParams{ID: pending.ID, Username: approver.Username}
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "MoreApprovalsNeeded",
+			username: approver.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().IsAccountApprover(gomock.Any(), gomock.Eq(isApprover)).Times(1).Return(true, nil)
+				approved := pending
+				approved.ApprovalCount = 1
+				store.EXPECT().ApprovePendingTransferTx(gomock.Any(), gomock.Eq(approveArg)).Times(1).Return(approved, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				got := requireBodyMatchPendingTransfer(t, recorder.Body, db.PendingTransferStatusPending)
+				require.Equal(t, int32(1), got.ApprovalCount)
+			},
+		},
+		{
+			name:     "ApproverRemovedMeanwhile",
+			username: approver.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().IsAccountApprover(gomock.Any(), gomock.Eq(isApprover)).Times(1).Return(true, nil)
+				store.EXPECT().ApprovePendingTransferTx(gomock.Any(), gomock.Eq(approveArg)).Times(1).Return(db.PendingTransfer{}, db.ErrNotApprover)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "Executed",
+			username: approver.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().IsAccountApprover(gomock.Any(), gomock.Eq(isApprover)).Times(1).Return(true, nil)
+				executed := pending
+				executed.ApprovalCount = 2
+				executed.Status = db.PendingTransferStatusExecuted
+				executed.TransferID = sql.NullInt64{Int64: 42, Valid: true}
+				store.EXPECT().ApprovePendingTransferTx(gomock.Any(), gomock.Eq(approveArg)).Times(1).Return(executed, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				got := requireBodyMatchPendingTransfer(t, recorder.Body, db.PendingTransferStatusExecuted)
+				require.Equal(t, int64(42), *got.TransferID)
+			},
+		},
+		{
+			name:     "ExecutionFailed",
+			username: approver.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().IsAccountApprover(gomock.Any(), gomock.Eq(isApprover)).Times(1).Return(true, nil)
+				failed := pending
+				failed.Status = db.PendingTransferStatusFailed
+				failed.FailureReason = sql.NullString{String: db.ErrInsufficientFunds.Error(), Valid: true}
+				store.EXPECT().ApprovePendingTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(failed, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				got := requireBodyMatchPendingTransfer(t, recorder.Body, db.PendingTransferStatusFailed)
+				require.Equal(t, db.ErrInsufficientFunds.Error(), *got.FailureReason)
+			},
+		},
+		{
+			name:     "Initiator",
+			username: initiator.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().ApprovePendingTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotApprover",
+			username: "outsider",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().IsAccountApprover(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+				store.EXPECT().ApprovePendingTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "AlreadyApproved",
+			username: approver.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().IsAccountApprover(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+				store.EXPECT().ApprovePendingTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.PendingTransfer{}, db.ErrAlreadyApproved)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "AlreadyDecided",
+			username: approver.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().IsAccountApprover(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+				err := fmt.Errorf("approve: %w", db.ErrPendingTransferDecided)
+				store.EXPECT().ApprovePendingTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.PendingTransfer{}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: approver.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(db.PendingTransfer{}, sql.ErrNoRows)
+				store.EXPECT().ApprovePendingTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/approve", pending.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, tc.username)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRejectPendingTransferApi(t *testing.T) {
+	initiator, _ := createUser(t)
+	approver, _ := createUser(t)
+	account1 := randomAccount(initiator.Username)
+	account2 := randomAccount(approver.Username)
+	pending := randomPendingTransfer(account1, account2, initiator.Username)
+	rejectArg := db.RejectPendingTransferTxParams{
+		ID:       pending.ID,
+		Username: approver.Username,
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: approver.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().IsAccountApprover(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+				rejected := pending
+				rejected.Status = db.PendingTransferStatusRejected
+				rejected.RejectedBy = sql.NullString{String: approver.Username, Valid: true}
+				store.EXPECT().RejectPendingTransferTx(gomock.Any(), gomock.Eq(rejectArg)).Times(1).Return(rejected, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				got := requireBodyMatchPendingTransfer(t, recorder.Body, db.PendingTransferStatusRejected)
+				require.Equal(t, approver.Username, *got.RejectedBy)
+			},
+		},
+		{
+			name:     "Initiator",
+			username: initiator.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().RejectPendingTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "AlreadyDecided",
+			username: approver.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().IsAccountApprover(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+				store.EXPECT().RejectPendingTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.PendingTransfer{}, db.ErrPendingTransferDecided)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "ApproverRemovedMeanwhile",
+			username: approver.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().IsAccountApprover(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+				store.EXPECT().RejectPendingTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.PendingTransfer{}, db.ErrNotApprover)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/reject", pending.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, tc.username)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSetApprovalPolicyApi(t *testing.T) {
+	owner, _ := createUser(t)
+	approver1, _ := createUser(t)
+	approver2, _ := createUser(t)
+	account := randomAccount(owner.Username)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"threshold": 0, "required_approvals": 2, "approvers": []string{approver1.Username, approver2.Username}},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.SetApprovalPolicyTxParams{
+					AccountID:         account.ID,
+					Threshold:         0,
+					RequiredApprovals: 2,
+					Approvers:         []string{approver1.Username, approver2.Username},
+				}
+				result := db.SetApprovalPolicyTxResult{
+					Policy: db.ApprovalPolicy{AccountID: account.ID, Threshold: 0, RequiredApprovals: 2},
+					Approvers: []db.AccountApprover{
+						{AccountID: account.ID, Username: approver1.Username},
+						{AccountID: account.ID, Username: approver2.Username},
+					},
+				}
+				store.EXPECT().SetApprovalPolicyTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got approvalPolicyResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, []string{approver1.Username, approver2.Username}, got.Approvers)
+			},
+		},
+		{
+			name: "MissingThreshold",
+			body: gin.H{"required_approvals": 1, "approvers": []string{approver1.Username}},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetApprovalPolicyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "TooFewApprovers",
+			body: gin.H{"threshold": 1000, "required_approvals": 2, "approvers": []string{approver1.Username}},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetApprovalPolicyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "DuplicateApprovers",
+			body: gin.H{"threshold": 1000, "required_approvals": 2, "approvers": []string{approver1.Username, approver1.Username}},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetApprovalPolicyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "OwnerAsApprover",
+			body: gin.H{"threshold": 1000, "required_approvals": 1, "approvers": []string{owner.Username}},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().SetApprovalPolicyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownApprover",
+			body: gin.H{"threshold": 1000, "required_approvals": 1, "approvers": []string{"nobody"}},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				err := &pq.Error{Code: "23503"}
+				store.EXPECT().SetApprovalPolicyTx(gomock.Any(), gomock.Any()).Times(1).Return(db.SetApprovalPolicyTxResult{}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			body: gin.H{"threshold": 1000, "required_approvals": 1, "approvers": []string{approver1.Username}},
+			role: utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetApprovalPolicyTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			url := fmt.Sprintf("/admin/accounts/%d/approval_policy", account.ID)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, "admin", tc.role)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+func (server *Server) listProducts(ctx *gin.Context) {
+	products, err := server.store.ListProducts(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, products)
+}
+
+// loadProduct loads a product by code, writing a 404 if it does not exist
+func (server *Server) loadProduct(ctx *gin.Context, code string) (db.Product, bool) {
+	product, err := server.store.GetProduct(ctx, code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return product, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return product, false
+	}
+	return product, true
+}
+
+// productCurrency checks an account of the product can hold currency, writing the error response if not
+func productCurrency(ctx *gin.Context, product db.Product, currency string) bool {
+	if !db.ProductAllowsCurrency(product, currency) {
+		err := fmt.Errorf("%w: %s accounts can hold %v", db.ErrCurrencyNotAvailable, product.Name, product.AllowedCurrencies)
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+type setAccountProductRequest struct {
+	ProductCode string `json:"product_code" binding:"required"`
+}
+
+// setAccountProduct moves an account to another product. Interest is accrued at the rate of the new product from the
+// day of the move, days before it are never accrued at the new rate
+func (server *Server) setAccountProduct(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req setAccountProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	account, valid := server.loadAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+	product, valid := server.loadProduct(ctx, req.ProductCode)
+	if !valid {
+		return
+	}
+	if !productCurrency(ctx, product, account.Currency) {
+		return
+	}
+
+	account, err := server.store.SetAccountProduct(ctx, db.SetAccountProductParams{
+		ID:          uri.Id,
+		ProductCode: req.ProductCode,
+	})
+	if err != nil {
+		// the owner already has an open account of the product in this currency
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			ctx.JSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, account)
+}
+
+type upsertProductUri struct {
+	Code string `uri:"code" binding:"required,max=32"`
+}
+
+type upsertProductRequest struct {
+	Name               string   `json:"name" binding:"required,max=64"`
+	AccountType        string   `json:"account_type" binding:"required,oneof=checking savings business system"`
+	AnnualInterestRate int64    `json:"annual_interest_rate" binding:"min=0"`
+	Compounding        string   `json:"compounding" binding:"omitempty,oneof=daily monthly"`
+	AllowedCurrencies  []string `json:"allowed_currencies" binding:"unique,dive,currency"`
+	MinBalance         int64    `json:"min_balance" binding:"min=0"`
+	// omitted for no limit
+	MaxWithdrawalsPerMonth *int32 `json:"max_withdrawals_per_month" binding:"omitempty,min=0"`
+	// omitted lets accounts of the product send transfers
+	CanBeSource *bool `json:"can_be_source"`
+}
+
+// upsertProduct adds a product to the catalog or replaces one. The rules apply to every transfer made after the
+// change, existing accounts keep their currency even when the product no longer allows it
+func (server *Server) upsertProduct(ctx *gin.Context) {
+	var uri upsertProductUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req upsertProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	arg := db.UpsertProductParams{
+		Code:               uri.Code,
+		Name:               req.Name,
+		AccountType:        db.AccountType(req.AccountType),
+		AnnualInterestRate: req.AnnualInterestRate,
+		Compounding:        db.CompoundingMonthly,
+		AllowedCurrencies:  req.AllowedCurrencies,
+		MinBalance:         req.MinBalance,
+		CanBeSource:        true,
+	}
+	if len(req.Compounding) > 0 {
+		arg.Compounding = db.Compounding(req.Compounding)
+	}
+	if arg.AllowedCurrencies == nil {
+		arg.AllowedCurrencies = []string{}
+	}
+	if req.MaxWithdrawalsPerMonth != nil {
+		arg.MaxWithdrawalsPerMonth = sql.NullInt32{Int32: *req.MaxWithdrawalsPerMonth, Valid: true}
+	}
+	if req.CanBeSource != nil {
+		arg.CanBeSource = *req.CanBeSource
+	}
+	product, err := server.store.UpsertProduct(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, product)
+}
@@ -0,0 +1,334 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListProductsApi(t *testing.T) {
+	user, _ := createUser(t)
+	products := []db.Product{
+		{Code: "checking", Name: "Checking", Compounding: db.CompoundingMonthly},
+		{Code: "savings", Name: "Savings", AnnualInterestRate: 2_000_000, Compounding: db.CompoundingMonthly},
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListProducts(gomock.Any()).Times(1).Return(products, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got []db.Product
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, products, got)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListProducts(gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/products", nil)
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, user.Username)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSetAccountProductApi(t *testing.T) {
+	user, _ := createUser(t)
+	account := randomAccount(user.Username)
+	savings := db.Product{Code: "savings", Name: "Savings", AnnualInterestRate: 2_000_000, Compounding: db.CompoundingMonthly}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"product_code": savings.Code},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetProduct(gomock.Any(), gomock.Eq(savings.Code)).Times(1).Return(savings, nil)
+				updated := account
+				updated.ProductCode = savings.Code
+				arg := db.SetAccountProductParams{ID: account.ID, ProductCode: savings.Code}
+				store.EXPECT().SetAccountProduct(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updated, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.Account
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, savings.Code, got.ProductCode)
+			},
+		},
+		{
+			name: "ProductAlreadyHeld",
+			body: gin.H{"product_code": savings.Code},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetProduct(gomock.Any(), gomock.Eq(savings.Code)).Times(1).Return(savings, nil)
+				store.EXPECT().SetAccountProduct(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			body: gin.H{"product_code": savings.Code},
+			role: utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetAccountProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "MissingProduct",
+			body: gin.H{},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetAccountProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ProductNotFound",
+			body: gin.H{"product_code": "gold"},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetProduct(gomock.Any(), gomock.Eq("gold")).Times(1).Return(db.Product{}, sql.ErrNoRows)
+				store.EXPECT().SetAccountProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyNotAvailable",
+			body: gin.H{"product_code": "business"},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				account := account
+				account.Currency = utils.CAD
+				business := db.Product{Code: "business", Name: "Business", AccountType: db.AccountTypeBusiness, AllowedCurrencies: []string{utils.USD}}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetProduct(gomock.Any(), gomock.Eq("business")).Times(1).Return(business, nil)
+				store.EXPECT().SetAccountProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			body: gin.H{"product_code": savings.Code},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().SetAccountProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			url := fmt.Sprintf("/admin/accounts/%d/product", account.ID)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, "admin", tc.role)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpsertProductApi(t *testing.T) {
+	testCases := []struct {
+		name          string
+		code          string
+		body          gin.H
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			code: "escrow",
+			body: gin.H{
+				"name":                      "Escrow",
+				"account_type":              "business",
+				"allowed_currencies":        []string{utils.USD, utils.EUR},
+				"min_balance":               1000,
+				"max_withdrawals_per_month": 2,
+				"can_be_source":             false,
+			},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpsertProductParams{
+					Code:                   "escrow",
+					Name:                   "Escrow",
+					AccountType:            db.AccountTypeBusiness,
+					Compounding:            db.CompoundingMonthly,
+					AllowedCurrencies:      []string{utils.USD, utils.EUR},
+					MinBalance:             1000,
+					MaxWithdrawalsPerMonth: sql.NullInt32{Int32: 2, Valid: true},
+					CanBeSource:            false,
+				}
+				product := db.Product{
+					Code:                   arg.Code,
+					Name:                   arg.Name,
+					AccountType:            arg.AccountType,
+					Compounding:            arg.Compounding,
+					AllowedCurrencies:      arg.AllowedCurrencies,
+					MinBalance:             arg.MinBalance,
+					MaxWithdrawalsPerMonth: arg.MaxWithdrawalsPerMonth,
+				}
+				store.EXPECT().UpsertProduct(gomock.Any(), gomock.Eq(arg)).Times(1).Return(product, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.Product
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.False(t, got.CanBeSource)
+				require.Equal(t, []string{utils.USD, utils.EUR}, got.AllowedCurrencies)
+			},
+		},
+		{
+			name: "Defaults",
+			code: "basic",
+			body: gin.H{"name": "Basic", "account_type": "checking"},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpsertProductParams{
+					Code:              "basic",
+					Name:              "Basic",
+					AccountType:       db.AccountTypeChecking,
+					Compounding:       db.CompoundingMonthly,
+					AllowedCurrencies: []string{},
+					CanBeSource:       true,
+				}
+				store.EXPECT().UpsertProduct(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.Product{Code: "basic"}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidAccountType",
+			code: "basic",
+			body: gin.H{"name": "Basic", "account_type": "premium"},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCurrency",
+			code: "basic",
+			body: gin.H{"name": "Basic", "account_type": "checking", "allowed_currencies": []string{"RMB"}},
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			code: "basic",
+			body: gin.H{"name": "Basic", "account_type": "checking"},
+			role: utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertProduct(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPut, "/admin/products/"+tc.code, bytes.NewReader(data))
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, "admin", tc.role)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
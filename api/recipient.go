@@ -0,0 +1,230 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+// maxAliasesPerUser caps how many aliases one user can hold
+const maxAliasesPerUser = 5
+
+// aliasPattern is the same rule as the CHECK constraint on aliases, applied after lowercasing
+var aliasPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{2,29}$`)
+
+var (
+	errRecipientNotFound = errors.New("no user found for recipient")
+	errAliasTaken        = errors.New("alias is already taken")
+	errTooManyAliases    = fmt.Errorf("a user can have at most %d aliases", maxAliasesPerUser)
+)
+
+// recipientAccount resolves a username, verified email or alias to the account its user holds in currency, the first
+// one they opened if they hold several products in it, writing a not found response if there is no such user or account
+func (server *Server) recipientAccount(ctx *gin.Context, recipient string, currency string) (db.Account, bool) {
+	var account db.Account
+	username, err := server.store.ResolveRecipient(ctx, recipient)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(errRecipientNotFound))
+			return account, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+	account, err = server.store.GetOwnerAccount(ctx, db.GetOwnerAccountParams{
+		Owner:    username,
+		Currency: currency,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err := fmt.Errorf("recipient has no %s account", currency)
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+	return account, true
+}
+
+// maskName keeps the first word of a full name and shortens the others to their initial, "John Doe" becomes "John D."
+func maskName(fullName string) string {
+	words := strings.Fields(fullName)
+	for i := 1; i < len(words); i++ {
+		initial, _ := utf8.DecodeRuneInString(words[i])
+		words[i] = string(initial) + "."
+	}
+	return strings.Join(words, " ")
+}
+
+type lookupRecipientRequest struct {
+	Recipient string `form:"recipient" binding:"required,max=255"`
+	Currency  string `form:"currency" binding:"required,currency"`
+}
+
+// lookupRecipientResponse lets the sender confirm who they are paying. The account id is left out so a lookup cannot
+// be used to enumerate accounts
+type lookupRecipientResponse struct {
+	Recipient   string `json:"recipient"`
+	DisplayName string `json:"display_name"`
+	Currency    string `json:"currency"`
+}
+
+func (server *Server) lookupRecipient(ctx *gin.Context) {
+	var req lookupRecipientRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	account, valid := server.recipientAccount(ctx, req.Recipient, req.Currency)
+	if !valid {
+		return
+	}
+	user, err := server.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, lookupRecipientResponse{
+		Recipient:   req.Recipient,
+		DisplayName: maskName(user.FullName),
+		Currency:    account.Currency,
+	})
+}
+
+type createAliasRequest struct {
+	Alias string `json:"alias" binding:"required"`
+}
+
+type aliasResponse struct {
+	Alias     string    `json:"alias"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// createAlias adds an alias for the authenticated user. Aliases are lowercase and may not match anyone an existing
+// recipient resolves to, so an alias can never take over payments meant for a username or email
+func (server *Server) createAlias(ctx *gin.Context) {
+	var req createAliasRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	alias := strings.ToLower(req.Alias)
+	if !aliasPattern.MatchString(alias) {
+		err := errors.New("alias must be 3 to 30 letters, digits, '_', '.' or '-' and start with a letter or digit")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	_, err := server.store.ResolveRecipient(ctx, alias)
+	if err == nil {
+		ctx.JSON(http.StatusForbidden, errorResponse(errAliasTaken))
+		return
+	}
+	if err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	count, err := server.store.CountUserAliases(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if count >= maxAliasesPerUser {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errTooManyAliases))
+		return
+	}
+
+	created, err := server.store.CreateAlias(ctx, db.CreateAliasParams{
+		Alias:    alias,
+		Username: authPayload.Username,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			ctx.JSON(http.StatusForbidden, errorResponse(errAliasTaken))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, aliasResponse{Alias: created.Alias, CreatedAt: created.CreatedAt})
+}
+
+func (server *Server) listAliases(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	aliases, err := server.store.ListUserAliases(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	response := make([]aliasResponse, 0, len(aliases))
+	for _, alias := range aliases {
+		response = append(response, aliasResponse{Alias: alias.Alias, CreatedAt: alias.CreatedAt})
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+type aliasRequest struct {
+	Alias string `uri:"alias" binding:"required"`
+}
+
+func (server *Server) deleteAlias(ctx *gin.Context) {
+	var req aliasRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	deleted, err := server.store.DeleteAlias(ctx, db.DeleteAliasParams{
+		Alias:    strings.ToLower(req.Alias),
+		Username: authPayload.Username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if deleted == 0 {
+		ctx.JSON(http.StatusNotFound, errorResponse(sql.ErrNoRows))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+type verifyEmailRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// verifyUserEmail marks the email of a user as verified so others can pay them by it. Verifying twice keeps the
+// first timestamp, an address verified by another user in different case is a conflict
+func (server *Server) verifyUserEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	user, err := server.store.VerifyUserEmail(ctx, req.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
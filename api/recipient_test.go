@@ -0,0 +1,343 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaskName(t *testing.T) {
+	require.Equal(t, "John D.", maskName("John Doe"))
+	require.Equal(t, "Mary J. W.", maskName("  Mary  Jane Watson "))
+	require.Equal(t, "Cher", maskName("Cher"))
+	require.Equal(t, "Zoë Å.", maskName("Zoë Ångström"))
+	require.Equal(t, "", maskName(""))
+}
+
+func TestLookupRecipientApi(t *testing.T) {
+	user, _ := createUser(t)
+	user.FullName = "John Doe"
+	account := randomAccount(user.Username)
+	account.Currency = utils.USD
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: url.Values{"recipient": {"johnny"}, "currency": {utils.USD}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Eq("johnny")).Times(1).Return(user.Username, nil)
+				arg := db.GetOwnerAccountParams{Owner: user.Username, Currency: utils.USD}
+				store.EXPECT().GetOwnerAccount(gomock.Any(), gomock.Eq(arg)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got map[string]any
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, map[string]any{
+					"recipient":    "johnny",
+					"display_name": "John D.",
+					"currency":     utils.USD,
+				}, got)
+			},
+		},
+		{
+			name:  "RecipientNotFound",
+			query: url.Values{"recipient": {"nobody"}, "currency": {utils.USD}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Eq("nobody")).Times(1).Return("", sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:  "NoAccountInCurrency",
+			query: url.Values{"recipient": {"johnny"}, "currency": {utils.EUR}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Eq("johnny")).Times(1).Return(user.Username, nil)
+				store.EXPECT().GetOwnerAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:  "MissingCurrency",
+			query: url.Values{"recipient": {"johnny"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/recipients/lookup?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, "someone")
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCreateAliasApi(t *testing.T) {
+	user, _ := createUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"alias": "John.Doe"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Eq("john.doe")).Times(1).Return("", sql.ErrNoRows)
+				store.EXPECT().CountUserAliases(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(int64(1), nil)
+				arg := db.CreateAliasParams{Alias: "john.doe", Username: user.Username}
+				store.EXPECT().CreateAlias(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.Alias{Alias: "john.doe", Username: user.Username, CreatedAt: time.Now()}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got aliasResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, "john.doe", got.Alias)
+			},
+		},
+		{
+			name: "InvalidFormat",
+			body: gin.H{"alias": "_john"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAlias(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "TooShort",
+			body: gin.H{"alias": "jd"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAlias(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "MatchesExistingRecipient",
+			body: gin.H{"alias": "alice"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Eq("alice")).Times(1).Return("alice", nil)
+				store.EXPECT().CreateAlias(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "TooManyAliases",
+			body: gin.H{"alias": "john.doe"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Any()).Times(1).Return("", sql.ErrNoRows)
+				store.EXPECT().CountUserAliases(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(int64(maxAliasesPerUser), nil)
+				store.EXPECT().CreateAlias(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "CreatedConcurrently",
+			body: gin.H{"alias": "john.doe"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Any()).Times(1).Return("", sql.ErrNoRows)
+				store.EXPECT().CountUserAliases(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().CreateAlias(gomock.Any(), gomock.Any()).Times(1).Return(db.Alias{}, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/aliases", bytes.NewReader(data))
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, user.Username)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDeleteAliasApi(t *testing.T) {
+	user, _ := createUser(t)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.DeleteAliasParams{Alias: "john.doe", Username: user.Username}
+				store.EXPECT().DeleteAlias(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(1), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "NotOwned",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteAlias(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodDelete, "/aliases/John.Doe", nil)
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, user.Username)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestVerifyUserEmailApi(t *testing.T) {
+	user, _ := createUser(t)
+	verified := user
+	verified.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	testCases := []struct {
+		name          string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyUserEmail(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(verified, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got userResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.True(t, got.EmailVerified)
+			},
+		},
+		{
+			name: "UserNotFound",
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyUserEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "EmailVerifiedByAnotherUser",
+			role: utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				err := &pq.Error{Code: "23505", Constraint: "users_verified_email_key"}
+				store.EXPECT().VerifyUserEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "NotAdmin",
+			role: utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyUserEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/admin/users/"+user.Username+"/verify_email", nil)
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, "someone", tc.role)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
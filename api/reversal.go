@@ -0,0 +1,68 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/token"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type reverseTransferRequest struct {
+	// Amount is refunded in the currency the sender paid in, leave it out to refund whatever is left
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+func (server *Server) reverseTransfer(ctx *gin.Context) {
+	var uri getTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	// an empty body is a full refund
+	var req reverseTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, uri.Id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != utils.AdminRole {
+		toAccount, valid := server.loadAccount(ctx, transfer.ToAccountID)
+		if !valid {
+			return
+		}
+		if authPayload.Username != toAccount.Owner {
+			err := errors.New("only the receiving account owner or an admin can reverse a transfer")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+	}
+
+	result, err := server.store.ReverseTransferTx(ctx, db.ReverseTransferTxParams{
+		TransferID: transfer.ID,
+		Amount:     req.Amount,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrReversalExceedsTransfer) || errors.Is(err, db.ErrReversalOfReversal) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		transferErrorResponse(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
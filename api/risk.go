@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/risk"
+	"github.com/gin-gonic/gin"
+)
+
+// screenTransfer runs the risk engine over a transfer and stores the assessment. A denied transfer gets a 403 with
+// the reason code of the rule that denied it. The id of the stored assessment is only valid when rules are configured
+func (server *Server) screenTransfer(ctx *gin.Context, username string, fromAccount db.Account, toAccount db.Account, amount int64) (db.RiskOutcome, int64, bool) {
+	if !server.risk.Enabled() {
+		return db.RiskOutcomeAllow, 0, true
+	}
+	assessment, err := server.risk.Evaluate(ctx, risk.Transfer{
+		Username: username,
+		From:     fromAccount,
+		To:       toAccount,
+		Amount:   amount,
+		Now:      time.Now(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return "", 0, false
+	}
+	decisions, err := json.Marshal(assessment.Decisions)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return "", 0, false
+	}
+	stored, err := server.store.CreateRiskAssessment(ctx, db.CreateRiskAssessmentParams{
+		Username:      username,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+		Outcome:       assessment.Outcome,
+		Rule:          assessment.Decisive.Rule,
+		ReasonCode:    assessment.Decisive.Code,
+		Reason:        assessment.Decisive.Reason,
+		Decisions:     decisions,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return "", 0, false
+	}
+	if assessment.Outcome == db.RiskOutcomeDeny {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"error":       assessment.Decisive.Reason,
+			"reason_code": assessment.Decisive.Code,
+		})
+		return assessment.Outcome, stored.ID, false
+	}
+	return assessment.Outcome, stored.ID, true
+}
+
+type listRiskAssessmentsRequest struct {
+	Outcome  string `form:"outcome" binding:"omitempty,oneof=allow review deny"`
+	PageId   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listRiskAssessments returns the stored screenings newest first, optionally only those with one outcome
+func (server *Server) listRiskAssessments(ctx *gin.Context) {
+	var req listRiskAssessmentsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	arg := db.ListRiskAssessmentsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageId - 1) * req.PageSize,
+	}
+	if len(req.Outcome) > 0 {
+		arg.Outcome = db.NullRiskOutcome{RiskOutcome: db.RiskOutcome(req.Outcome), Valid: true}
+	}
+	assessments, err := server.store.ListRiskAssessments(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, assessments)
+}
+
+type listPendingReviewsRequest struct {
+	PageId   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// listPendingReviews returns the transfers held by the risk engine that wait for an admin, who clears one through
+// the approve route and stops it through the reject route
+func (server *Server) listPendingReviews(ctx *gin.Context) {
+	var req listPendingReviewsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	transfers, err := server.store.ListPendingTransfersInReview(ctx, db.ListPendingTransfersInReviewParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageId - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	response := make([]pendingTransferResponse, 0, len(transfers))
+	for _, pending := range transfers {
+		response = append(response, newPendingTransferResponse(pending))
+	}
+	ctx.JSON(http.StatusOK, response)
+}
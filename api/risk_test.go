@@ -0,0 +1,413 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/risk"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferRiskApi(t *testing.T) {
+	user1, _ := createUser(t)
+	user2, _ := createUser(t)
+	account1 := randomAccount(user1.Username)
+	account1.Currency = utils.USD
+	account2 := randomAccount(user2.Username)
+	account2.ID = account1.ID + 1
+	account2.Currency = utils.USD
+	body := gin.H{
+		"from_account_id": account1.ID,
+		"to_account_id":   account2.ID,
+		"amount":          500,
+		"currency":        utils.USD,
+	}
+	assessment := db.RiskAssessment{ID: 7}
+	requestHash, err := hashRequest(transferRequest{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        500,
+		Currency:      utils.USD,
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		idempotencyKey string
+		buildStubs     func(store *mockdb.MockStore)
+		checkResponse  func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Allowed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().HasPaidAccount(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+				store.EXPECT().CreateRiskAssessment(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ any, arg db.CreateRiskAssessmentParams) (db.RiskAssessment, error) {
+						require.Equal(t, db.RiskOutcomeAllow, arg.Outcome)
+						require.Empty(t, arg.Rule)
+						var decisions []risk.Decision
+						require.NoError(t, json.Unmarshal(arg.Decisions, &decisions))
+						require.Len(t, decisions, 2)
+						return assessment, nil
+					})
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Any()).Times(1).Return(db.ApprovalPolicy{}, sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Denied",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Any()).Times(1).Return(int64(3), nil)
+				store.EXPECT().HasPaidAccount(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+				store.EXPECT().CreateRiskAssessment(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ any, arg db.CreateRiskAssessmentParams) (db.RiskAssessment, error) {
+						require.Equal(t, db.RiskOutcomeDeny, arg.Outcome)
+						require.Equal(t, risk.CodeVelocity, arg.ReasonCode)
+						require.Equal(t, user1.Username, arg.Username)
+						return assessment, nil
+					})
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreatePendingTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				var got gin.H
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, risk.CodeVelocity, got["reason_code"])
+				require.NotEmpty(t, got["error"])
+			},
+		},
+		{
+			name: "Review",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().HasPaidAccount(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+				store.EXPECT().CreateRiskAssessment(gomock.Any(), gomock.Any()).Times(1).Return(assessment, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Any()).Times(1).Return(db.ApprovalPolicy{}, sql.ErrNoRows)
+				arg := db.CreatePendingTransferParams{
+					FromAccountID:    account1.ID,
+					ToAccountID:      account2.ID,
+					Amount:           500,
+					Metadata:         json.RawMessage("{}"),
+					InitiatedBy:      user1.Username,
+					RiskAssessmentID: sql.NullInt64{Int64: assessment.ID, Valid: true},
+				}
+				pending := db.PendingTransfer{
+					ID:               1,
+					FromAccountID:    account1.ID,
+					ToAccountID:      account2.ID,
+					Amount:           500,
+					Status:           db.PendingTransferStatusPending,
+					RiskAssessmentID: arg.RiskAssessmentID,
+				}
+				store.EXPECT().CreatePendingTransfer(gomock.Any(), gomock.Eq(arg)).Times(1).Return(pending, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				got := requireBodyMatchPendingTransfer(t, recorder.Body, db.PendingTransferStatusPending)
+				require.True(t, got.InReview)
+				require.Equal(t, assessment.ID, *got.RiskAssessmentID)
+			},
+		},
+		{
+			name: "ReviewAndApproval",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().HasPaidAccount(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+				store.EXPECT().CreateRiskAssessment(gomock.Any(), gomock.Any()).Times(1).Return(assessment, nil)
+				policy := db.ApprovalPolicy{AccountID: account1.ID, Threshold: 100, RequiredApprovals: 2}
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Any()).Times(1).Return(policy, nil)
+				store.EXPECT().CreatePendingTransfer(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ any, arg db.CreatePendingTransferParams) (db.PendingTransfer, error) {
+						require.Equal(t, int32(2), arg.RequiredApprovals)
+						require.Equal(t, assessment.ID, arg.RiskAssessmentID.Int64)
+						return db.PendingTransfer{Status: db.PendingTransferStatusPending}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+			},
+		},
+		{
+			name:           "NewIdempotencyKey",
+			idempotencyKey: "retry-key",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().HasPaidAccount(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+				store.EXPECT().CreateRiskAssessment(gomock.Any(), gomock.Any()).Times(1).Return(assessment, nil)
+				store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Any()).Times(1).Return(db.ApprovalPolicy{}, sql.ErrNoRows)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			// a retry was screened the first time round, screening it again would count it twice towards velocity
+			name:           "ReplayNotScreened",
+			idempotencyKey: "retry-key",
+			buildStubs: func(store *mockdb.MockStore) {
+				stored := db.IdempotencyKey{
+					Username:    user1.Username,
+					Key:         "retry-key",
+					RequestHash: requestHash,
+					Response:    json.RawMessage(`{}`),
+					CreatedAt:   time.Now(),
+				}
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(stored, nil)
+				store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateRiskAssessment(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			name: "RuleError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(account1, nil)
+				store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), sql.ErrConnDone)
+				store.EXPECT().CreateRiskAssessment(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			server.risk = risk.NewEngine(store, risk.VelocityRule{MaxTransfers: 3, Window: time.Minute}, risk.NewPayeeRule{Amount: 100})
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+			if len(tc.idempotencyKey) > 0 {
+				request.Header.Set(idempotencyKeyHeader, tc.idempotencyKey)
+			}
+			createAndSetAuthToken(t, request, server.maker, user1.Username)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestReviewPendingTransferApi(t *testing.T) {
+	initiator, _ := createUser(t)
+	account1 := randomAccount(initiator.Username)
+	account2 := randomAccount(utils.GenerateRandomOwner())
+	pending := randomPendingTransfer(account1, account2, initiator.Username)
+	pending.RequiredApprovals = 0
+	pending.RiskAssessmentID = sql.NullInt64{Int64: 7, Valid: true}
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Executed",
+			username: "admin",
+			role:     utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().IsAccountApprover(gomock.Any(), gomock.Any()).Times(0)
+				executed := pending
+				executed.ReviewedBy = sql.NullString{String: "admin", Valid: true}
+				executed.Status = db.PendingTransferStatusExecuted
+				executed.TransferID = sql.NullInt64{Int64: 42, Valid: true}
+				arg := db.ReviewPendingTransferTxParams{ID: pending.ID, Username: "admin"}
+				store.EXPECT().ReviewPendingTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(executed, nil)
+				store.EXPECT().ApprovePendingTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				got := requireBodyMatchPendingTransfer(t, recorder.Body, db.PendingTransferStatusExecuted)
+				require.False(t, got.InReview)
+				require.Equal(t, "admin", *got.ReviewedBy)
+			},
+		},
+		{
+			name:     "AlreadyReviewed",
+			username: "admin",
+			role:     utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().ReviewPendingTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.PendingTransfer{}, db.ErrNoReviewPending)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:     "NotAdmin",
+			username: "depositor",
+			role:     utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().ReviewPendingTransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ApprovePendingTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "AdminInitiator",
+			username: initiator.Username,
+			role:     utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPendingTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().ReviewPendingTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/approve", pending.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, tc.username, tc.role)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListRiskAssessmentsApi(t *testing.T) {
+	testCases := []struct {
+		name          string
+		query         string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "ByOutcome",
+			query: "?outcome=deny&page_id=2&page_size=5",
+			role:  utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListRiskAssessmentsParams{
+					Outcome: db.NullRiskOutcome{RiskOutcome: db.RiskOutcomeDeny, Valid: true},
+					Limit:   5,
+					Offset:  5,
+				}
+				assessments := []db.RiskAssessment{{ID: 3, Outcome: db.RiskOutcomeDeny, ReasonCode: risk.CodeVelocity}}
+				store.EXPECT().ListRiskAssessments(gomock.Any(), gomock.Eq(arg)).Times(1).Return(assessments, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got []db.RiskAssessment
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got, 1)
+				require.Equal(t, risk.CodeVelocity, got[0].ReasonCode)
+			},
+		},
+		{
+			name:  "AllOutcomes",
+			query: "?page_id=1&page_size=5",
+			role:  utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListRiskAssessmentsParams{Limit: 5}
+				store.EXPECT().ListRiskAssessments(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.RiskAssessment{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidOutcome",
+			query: "?outcome=maybe&page_id=1&page_size=5",
+			role:  utils.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListRiskAssessments(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "NotAdmin",
+			query: "?page_id=1&page_size=5",
+			role:  utils.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListRiskAssessments(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/admin/risk_assessments"+tc.query, nil)
+			require.NoError(t, err)
+			createAndSetRoleAuthToken(t, request, server.maker, "admin", tc.role)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+var errScheduledTransferNotPending = errors.New("scheduled transfer is no longer pending")
+
+type createScheduledTransferRequest struct {
+	FromAccountId int64     `json:"from_account_id" binding:"required,min=1"`
+	ToAccountId   int64     `json:"to_account_id" binding:"required,min=1"`
+	Currency      string    `json:"currency" binding:"required,currency"`
+	Amount        int64     `json:"amount" binding:"required,gt=0"`
+	ExecuteAt     time.Time `json:"execute_at" binding:"required"`
+}
+
+type scheduledTransferResponse struct {
+	ID            int64                      `json:"id"`
+	FromAccountID int64                      `json:"from_account_id"`
+	ToAccountID   int64                      `json:"to_account_id"`
+	Amount        int64                      `json:"amount"`
+	ExecuteAt     time.Time                  `json:"execute_at"`
+	Status        db.ScheduledTransferStatus `json:"status"`
+	TransferID    *int64                     `json:"transfer_id,omitempty"`
+	FailureReason *string                    `json:"failure_reason,omitempty"`
+	ExecutedAt    *time.Time                 `json:"executed_at,omitempty"`
+	CreatedAt     time.Time                  `json:"created_at"`
+}
+
+func newScheduledTransferResponse(scheduled db.ScheduledTransfer) scheduledTransferResponse {
+	response := scheduledTransferResponse{
+		ID:            scheduled.ID,
+		FromAccountID: scheduled.FromAccountID,
+		ToAccountID:   scheduled.ToAccountID,
+		Amount:        scheduled.Amount,
+		ExecuteAt:     scheduled.ExecuteAt,
+		Status:        scheduled.Status,
+		TransferID:    nullInt64Ptr(scheduled.TransferID),
+		CreatedAt:     scheduled.CreatedAt,
+	}
+	if scheduled.FailureReason.Valid {
+		response.FailureReason = &scheduled.FailureReason.String
+	}
+	if scheduled.ExecutedAt.Valid {
+		response.ExecutedAt = &scheduled.ExecutedAt.Time
+	}
+	return response
+}
+
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var request createScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !request.ExecuteAt.After(time.Now()) {
+		err := errors.New("execute_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	fromAccount, valid := server.validAccount(ctx, request.FromAccountId, request.Currency)
+	if !valid {
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Username != fromAccount.Owner {
+		err := errors.New("from acccount does not belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	// the worker executes through TransferTx, which has no exchange rate to apply
+	if _, valid := server.validAccount(ctx, request.ToAccountId, request.Currency); !valid {
+		return
+	}
+
+	scheduled, err := server.store.CreateScheduledTransfer(ctx, db.CreateScheduledTransferParams{
+		FromAccountID: request.FromAccountId,
+		ToAccountID:   request.ToAccountId,
+		Amount:        request.Amount,
+		ExecuteAt:     request.ExecuteAt,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled))
+}
+
+type listScheduledTransfersRequest struct {
+	PageId   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req listScheduledTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	account, valid := server.ownedAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+
+	scheduled, err := server.store.ListScheduledTransfers(ctx, db.ListScheduledTransfersParams{
+		FromAccountID: account.ID,
+		Limit:         req.PageSize,
+		Offset:        (req.PageId - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	response := make([]scheduledTransferResponse, 0, len(scheduled))
+	for _, transfer := range scheduled {
+		response = append(response, newScheduledTransferResponse(transfer))
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+type cancelScheduledTransferRequest struct {
+	Id int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	var req cancelScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	scheduled, err := server.store.GetScheduledTransfer(ctx, req.Id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if _, valid := server.ownedAccount(ctx, scheduled.FromAccountID); !valid {
+		return
+	}
+
+	// only a pending transfer can be canceled, the worker may claim it between the read above and this update
+	scheduled, err = server.store.CancelScheduledTransfer(ctx, req.Id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(errScheduledTransferNotPending))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled))
+}
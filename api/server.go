@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/fx"
+	"github.com/DingBao-sys/simple_bank/risk"
 	"github.com/DingBao-sys/simple_bank/token"
 	"github.com/DingBao-sys/simple_bank/utils"
 	"github.com/gin-gonic/gin"
@@ -16,6 +18,8 @@ type Server struct {
 	router *gin.Engine
 	maker  token.Maker
 	config utils.Config
+	rates  fx.RateProvider
+	risk   *risk.Engine
 }
 
 func NewServer(config utils.Config, store db.Store) (*Server, error) {
@@ -27,9 +31,18 @@ func NewServer(config utils.Config, store db.Store) (*Server, error) {
 		store:  store,
 		maker:  tokenMaker,
 		config: config,
+		rates:  fx.NewDBRateProvider(store),
+		risk:   risk.NewEngine(store, risk.RulesFromConfig(config)...),
+	}
+	if len(config.FXRatesFile) > 0 {
+		server.rates, err = fx.NewFileRateProvider(config.FXRatesFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load exchange rates: %w", err)
+		}
 	}
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("metadata", validMetadata)
 	}
 	server.setupRouter()
 	return server, nil
@@ -45,12 +58,70 @@ func (server *Server) setupRouter() {
 	// protected routes
 	authRoutes := router.Group("/").Use(authMiddleware(server.maker))
 
+	// user routes
+	authRoutes.PUT("/users/password", server.changePassword)
 	// accounts
 	authRoutes.POST("/accounts", server.createAccount)
 	authRoutes.GET("/accounts/:id", server.getAccount)
 	authRoutes.GET("/accounts", server.listAccounts)
+	authRoutes.GET("/accounts/:id/transfers", server.listAccountTransfers)
+	authRoutes.GET("/accounts/:id/balance", server.getAccountBalance)
+	authRoutes.GET("/accounts/:id/statement", server.getStatement)
+	authRoutes.GET("/accounts/:id/statement/export", server.exportStatement)
+	authRoutes.GET("/accounts/:id/scheduled_transfers", server.listScheduledTransfers)
+	authRoutes.GET("/accounts/:id/standing_orders", server.listStandingOrders)
+	authRoutes.POST("/accounts/:id/close", server.closeAccount)
 	// transfer routes
 	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.POST("/transfers/batch", server.createBatchTransfer)
+	authRoutes.POST("/transfers/preview", server.previewTransfer)
+	authRoutes.GET("/transfers/:id", server.getTransfer)
+	authRoutes.POST("/transfers/:id/reverse", server.reverseTransfer)
+	authRoutes.POST("/transfers/:id/approve", server.approvePendingTransfer)
+	authRoutes.POST("/transfers/:id/reject", server.rejectPendingTransfer)
+	authRoutes.GET("/pending_transfers", server.listPendingTransfers)
+	// scheduled transfer routes
+	authRoutes.POST("/scheduled_transfers", server.createScheduledTransfer)
+	authRoutes.POST("/scheduled_transfers/:id/cancel", server.cancelScheduledTransfer)
+	// standing order routes
+	authRoutes.POST("/standing_orders", server.createStandingOrder)
+	authRoutes.GET("/standing_orders/:id/executions", server.listStandingOrderExecutions)
+	authRoutes.POST("/standing_orders/:id/cancel", server.cancelStandingOrder)
+	// hold routes
+	authRoutes.POST("/holds", server.createHold)
+	authRoutes.POST("/holds/:id/capture", server.captureHold)
+	authRoutes.POST("/holds/:id/void", server.voidHold)
+	// fx routes
+	authRoutes.POST("/fx/quotes", server.createQuote)
+	// recipient routes
+	authRoutes.GET("/recipients/lookup", server.lookupRecipient)
+	authRoutes.POST("/aliases", server.createAlias)
+	authRoutes.GET("/aliases", server.listAliases)
+	authRoutes.DELETE("/aliases/:alias", server.deleteAlias)
+	// limit routes
+	authRoutes.GET("/limits", server.listLimits)
+	// product routes
+	authRoutes.GET("/products", server.listProducts)
+	// fee routes
+	authRoutes.GET("/fee_schedules", server.listFeeSchedules)
+
+	// admin routes
+	adminRoutes := router.Group("/admin").Use(authMiddleware(server.maker), roleMiddleware(utils.AdminRole))
+	adminRoutes.PUT("/accounts/:id/limits", server.setAccountLimits)
+	adminRoutes.PUT("/accounts/:id/product", server.setAccountProduct)
+	adminRoutes.POST("/accounts/:id/freeze", server.freezeAccount)
+	adminRoutes.POST("/accounts/:id/unfreeze", server.unfreezeAccount)
+	adminRoutes.PUT("/products/:code", server.upsertProduct)
+	adminRoutes.PUT("/accounts/:id/approval_policy", server.setApprovalPolicy)
+	adminRoutes.DELETE("/accounts/:id/approval_policy", server.deleteApprovalPolicy)
+	adminRoutes.GET("/accounts/:id/chain_head", server.getChainHead)
+	adminRoutes.POST("/users/:username/verify_email", server.verifyUserEmail)
+	adminRoutes.GET("/pending_transfers", server.listPendingReviews)
+	adminRoutes.GET("/risk_assessments", server.listRiskAssessments)
+	adminRoutes.GET("/stats/transactions", server.getTxStats)
+	adminRoutes.GET("/reconciliation", server.reconcileLedger)
+	adminRoutes.PUT("/fee_schedules/:currency", server.setFeeSchedule)
+	adminRoutes.DELETE("/fee_schedules/:currency", server.deleteFeeSchedule)
 
 	server.router = router
 }
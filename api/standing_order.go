@@ -0,0 +1,263 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+var errStandingOrderNotActive = errors.New("standing order is no longer active")
+
+type createStandingOrderRequest struct {
+	FromAccountId           int64     `json:"from_account_id" binding:"required,min=1"`
+	ToAccountId             int64     `json:"to_account_id" binding:"required,min=1"`
+	Currency                string    `json:"currency" binding:"required,currency"`
+	Amount                  int64     `json:"amount" binding:"required,gt=0"`
+	Frequency               string    `json:"frequency" binding:"required,oneof=weekly monthly last_business_day"`
+	DayOfMonth              int32     `json:"day_of_month" binding:"omitempty,min=1,max=31"`
+	StartAt                 time.Time `json:"start_at" binding:"required"`
+	EndAt                   time.Time `json:"end_at"`
+	InsufficientFundsPolicy string    `json:"insufficient_funds_policy" binding:"omitempty,oneof=skip retry"`
+	MaxRetries              int32     `json:"max_retries" binding:"omitempty,min=1,max=10"`
+}
+
+type standingOrderResponse struct {
+	ID                      int64                      `json:"id"`
+	FromAccountID           int64                      `json:"from_account_id"`
+	ToAccountID             int64                      `json:"to_account_id"`
+	Amount                  int64                      `json:"amount"`
+	Frequency               db.StandingOrderFrequency  `json:"frequency"`
+	DayOfMonth              int32                      `json:"day_of_month,omitempty"`
+	InsufficientFundsPolicy db.InsufficientFundsPolicy `json:"insufficient_funds_policy"`
+	MaxRetries              int32                      `json:"max_retries"`
+	NextRunAt               time.Time                  `json:"next_run_at"`
+	EndAt                   *time.Time                 `json:"end_at,omitempty"`
+	Status                  db.StandingOrderStatus     `json:"status"`
+	CreatedAt               time.Time                  `json:"created_at"`
+}
+
+func newStandingOrderResponse(order db.StandingOrder) standingOrderResponse {
+	response := standingOrderResponse{
+		ID:                      order.ID,
+		FromAccountID:           order.FromAccountID,
+		ToAccountID:             order.ToAccountID,
+		Amount:                  order.Amount,
+		Frequency:               order.Frequency,
+		DayOfMonth:              order.DayOfMonth,
+		InsufficientFundsPolicy: order.InsufficientFundsPolicy,
+		MaxRetries:              order.MaxRetries,
+		NextRunAt:               order.NextRunAt,
+		Status:                  order.Status,
+		CreatedAt:               order.CreatedAt,
+	}
+	if order.EndAt.Valid {
+		response.EndAt = &order.EndAt.Time
+	}
+	return response
+}
+
+func (server *Server) createStandingOrder(ctx *gin.Context) {
+	var request createStandingOrderRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	frequency := db.StandingOrderFrequency(request.Frequency)
+	if (frequency == db.StandingOrderFrequencyMonthly) != (request.DayOfMonth > 0) {
+		err := errors.New("day_of_month is required for a monthly order and only allowed for one")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	policy := db.InsufficientFundsPolicySkip
+	if len(request.InsufficientFundsPolicy) > 0 {
+		policy = db.InsufficientFundsPolicy(request.InsufficientFundsPolicy)
+	}
+	if (policy == db.InsufficientFundsPolicyRetry) != (request.MaxRetries > 0) {
+		err := errors.New("max_retries is required for the retry policy and only allowed for it")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !request.StartAt.After(time.Now()) {
+		err := errors.New("start_at must be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	firstRun := db.FirstStandingOrderRun(frequency, request.DayOfMonth, request.StartAt)
+	if !request.EndAt.IsZero() && request.EndAt.Before(firstRun) {
+		err := errors.New("end_at must not be before the first occurrence")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, request.FromAccountId, request.Currency)
+	if !valid {
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Username != fromAccount.Owner {
+		err := errors.New("from acccount does not belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if _, valid := server.validAccount(ctx, request.ToAccountId, request.Currency); !valid {
+		return
+	}
+
+	order, err := server.store.CreateStandingOrder(ctx, db.CreateStandingOrderParams{
+		FromAccountID:           request.FromAccountId,
+		ToAccountID:             request.ToAccountId,
+		Amount:                  request.Amount,
+		Frequency:               frequency,
+		DayOfMonth:              request.DayOfMonth,
+		InsufficientFundsPolicy: policy,
+		MaxRetries:              request.MaxRetries,
+		OccurrenceAt:            firstRun,
+		NextRunAt:               firstRun,
+		EndAt:                   sql.NullTime{Time: request.EndAt, Valid: !request.EndAt.IsZero()},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newStandingOrderResponse(order))
+}
+
+type listStandingOrdersRequest struct {
+	PageId   int32 `form:"page_id" binding:"required,min=1"`
+	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+func (server *Server) listStandingOrders(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req listStandingOrdersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	account, valid := server.ownedAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+
+	orders, err := server.store.ListStandingOrders(ctx, db.ListStandingOrdersParams{
+		FromAccountID: account.ID,
+		Limit:         req.PageSize,
+		Offset:        (req.PageId - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	response := make([]standingOrderResponse, 0, len(orders))
+	for _, order := range orders {
+		response = append(response, newStandingOrderResponse(order))
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+type standingOrderRequest struct {
+	Id int64 `uri:"id" binding:"required,min=1"`
+}
+
+type standingOrderExecutionResponse struct {
+	ID            int64                           `json:"id"`
+	OccurrenceAt  time.Time                       `json:"occurrence_at"`
+	Attempt       int32                           `json:"attempt"`
+	Status        db.StandingOrderExecutionStatus `json:"status"`
+	TransferID    *int64                          `json:"transfer_id,omitempty"`
+	FailureReason *string                         `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time                       `json:"created_at"`
+}
+
+func (server *Server) listStandingOrderExecutions(ctx *gin.Context) {
+	var uri standingOrderRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req listStandingOrdersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	order, valid := server.ownedStandingOrder(ctx, uri.Id)
+	if !valid {
+		return
+	}
+
+	executions, err := server.store.ListStandingOrderExecutions(ctx, db.ListStandingOrderExecutionsParams{
+		StandingOrderID: order.ID,
+		Limit:           req.PageSize,
+		Offset:          (req.PageId - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	response := make([]standingOrderExecutionResponse, 0, len(executions))
+	for _, execution := range executions {
+		executionResponse := standingOrderExecutionResponse{
+			ID:           execution.ID,
+			OccurrenceAt: execution.OccurrenceAt,
+			Attempt:      execution.Attempt,
+			Status:       execution.Status,
+			TransferID:   nullInt64Ptr(execution.TransferID),
+			CreatedAt:    execution.CreatedAt,
+		}
+		if execution.FailureReason.Valid {
+			executionResponse.FailureReason = &execution.FailureReason.String
+		}
+		response = append(response, executionResponse)
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (server *Server) cancelStandingOrder(ctx *gin.Context) {
+	var req standingOrderRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if _, valid := server.ownedStandingOrder(ctx, req.Id); !valid {
+		return
+	}
+
+	// blocks while a worker is executing an occurrence of the order
+	order, err := server.store.CancelStandingOrder(ctx, req.Id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusConflict, errorResponse(errStandingOrderNotActive))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newStandingOrderResponse(order))
+}
+
+// ownedStandingOrder loads the standing order and checks it is paid from an account of the authenticated user,
+// writing the error response if not
+func (server *Server) ownedStandingOrder(ctx *gin.Context, id int64) (db.StandingOrder, bool) {
+	order, err := server.store.GetStandingOrder(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return order, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return order, false
+	}
+	if _, valid := server.ownedAccount(ctx, order.FromAccountID); !valid {
+		return order, false
+	}
+	return order, true
+}
@@ -0,0 +1,400 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomStandingOrder(fromAccount, toAccount db.Account) db.StandingOrder {
+	nextRun := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	return db.StandingOrder{
+		ID:                      utils.GenerateRandomInt(1, 1000),
+		FromAccountID:           fromAccount.ID,
+		ToAccountID:             toAccount.ID,
+		Amount:                  utils.GenerateRandomInt(1, 1000),
+		Frequency:               db.StandingOrderFrequencyWeekly,
+		InsufficientFundsPolicy: db.InsufficientFundsPolicySkip,
+		OccurrenceAt:            nextRun,
+		NextRunAt:               nextRun,
+		Status:                  db.StandingOrderStatusActive,
+		CreatedAt:               time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func TestCreateStandingOrderApi(t *testing.T) {
+	user1, _ := createUser(t)
+	user2, _ := createUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = utils.USD
+	account2.Currency = utils.USD
+
+	order := randomStandingOrder(account1, account2)
+	// the 15th has already passed on the start date, so the first run is in the following month
+	startAt := time.Date(time.Now().Year()+1, time.January, 20, 9, 0, 0, 0, time.UTC)
+	firstMonthlyRun := time.Date(startAt.Year(), time.February, 15, 9, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		authUsername  string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Weekly",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          order.Amount,
+				"currency":        utils.USD,
+				"frequency":       "weekly",
+				"start_at":        order.NextRunAt,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				arg := db.CreateStandingOrderParams{
+					FromAccountID:           account1.ID,
+					ToAccountID:             account2.ID,
+					Amount:                  order.Amount,
+					Frequency:               db.StandingOrderFrequencyWeekly,
+					InsufficientFundsPolicy: db.InsufficientFundsPolicySkip,
+					OccurrenceAt:            order.NextRunAt,
+					NextRunAt:               order.NextRunAt,
+				}
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Eq(arg)).Times(1).Return(order, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got standingOrderResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, order.ID, got.ID)
+				require.Equal(t, db.StandingOrderStatusActive, got.Status)
+			},
+		},
+		{
+			name: "MonthlyWithRetry",
+			body: gin.H{
+				"from_account_id":           account1.ID,
+				"to_account_id":             account2.ID,
+				"amount":                    order.Amount,
+				"currency":                  utils.USD,
+				"frequency":                 "monthly",
+				"day_of_month":              15,
+				"start_at":                  startAt,
+				"insufficient_funds_policy": "retry",
+				"max_retries":               3,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				arg := db.CreateStandingOrderParams{
+					FromAccountID:           account1.ID,
+					ToAccountID:             account2.ID,
+					Amount:                  order.Amount,
+					Frequency:               db.StandingOrderFrequencyMonthly,
+					DayOfMonth:              15,
+					InsufficientFundsPolicy: db.InsufficientFundsPolicyRetry,
+					MaxRetries:              3,
+					OccurrenceAt:            firstMonthlyRun,
+					NextRunAt:               firstMonthlyRun,
+				}
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Eq(arg)).Times(1).Return(order, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "MonthlyWithoutDay",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          order.Amount,
+				"currency":        utils.USD,
+				"frequency":       "monthly",
+				"start_at":        order.NextRunAt,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "RetryWithoutMaxRetries",
+			body: gin.H{
+				"from_account_id":           account1.ID,
+				"to_account_id":             account2.ID,
+				"amount":                    order.Amount,
+				"currency":                  utils.USD,
+				"frequency":                 "weekly",
+				"start_at":                  order.NextRunAt,
+				"insufficient_funds_policy": "retry",
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidFrequency",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          order.Amount,
+				"currency":        utils.USD,
+				"frequency":       "daily",
+				"start_at":        order.NextRunAt,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "EndBeforeFirstRun",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          order.Amount,
+				"currency":        utils.USD,
+				"frequency":       "weekly",
+				"start_at":        order.NextRunAt,
+				"end_at":          order.NextRunAt.Add(-time.Hour),
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          order.Amount,
+				"currency":        utils.USD,
+				"frequency":       "weekly",
+				"start_at":        order.NextRunAt,
+			},
+			authUsername: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			jsonBody, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/standing_orders", bytes.NewReader(jsonBody))
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, tc.authUsername)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListStandingOrderExecutionsApi(t *testing.T) {
+	user1, _ := createUser(t)
+	user2, _ := createUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	order := randomStandingOrder(account1, account2)
+	executions := []db.StandingOrderExecution{
+		{
+			ID:              2,
+			StandingOrderID: order.ID,
+			OccurrenceAt:    order.OccurrenceAt,
+			Attempt:         1,
+			Status:          db.StandingOrderExecutionStatusSkipped,
+			FailureReason:   sql.NullString{String: db.ErrInsufficientFunds.Error(), Valid: true},
+		},
+		{
+			ID:              1,
+			StandingOrderID: order.ID,
+			OccurrenceAt:    order.OccurrenceAt.AddDate(0, 0, -7),
+			Attempt:         1,
+			Status:          db.StandingOrderExecutionStatusSucceeded,
+			TransferID:      sql.NullInt64{Int64: 42, Valid: true},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		authUsername  string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "OK",
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(order, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				arg := db.ListStandingOrderExecutionsParams{
+					StandingOrderID: order.ID,
+					Limit:           5,
+					Offset:          0,
+				}
+				store.EXPECT().ListStandingOrderExecutions(gomock.Any(), gomock.Eq(arg)).Times(1).Return(executions, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got []standingOrderExecutionResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got, 2)
+				require.Equal(t, db.ErrInsufficientFunds.Error(), *got[0].FailureReason)
+				require.Nil(t, got[0].TransferID)
+				require.Equal(t, int64(42), *got[1].TransferID)
+			},
+		},
+		{
+			name:         "NotFound",
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(db.StandingOrder{}, sql.ErrNoRows)
+				store.EXPECT().ListStandingOrderExecutions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:         "UnauthorizedUser",
+			authUsername: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(order, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().ListStandingOrderExecutions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/standing_orders/%d/executions?page_id=1&page_size=5", order.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, tc.authUsername)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCancelStandingOrderApi(t *testing.T) {
+	user, _ := createUser(t)
+	account1 := randomAccount(user.Username)
+	account2 := randomAccount(user.Username)
+	order := randomStandingOrder(account1, account2)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				canceled := order
+				canceled.Status = db.StandingOrderStatusCanceled
+				store.EXPECT().GetStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(order, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CancelStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(canceled, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got standingOrderResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, db.StandingOrderStatusCanceled, got.Status)
+			},
+		},
+		{
+			name: "NotActive",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(order, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CancelStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(db.StandingOrder{}, sql.ErrNoRows)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/standing_orders/%d/cancel", order.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, user.Username)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
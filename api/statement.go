@@ -0,0 +1,170 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/export"
+	"github.com/gin-gonic/gin"
+)
+
+const maxStatementPeriod = 366 * 24 * time.Hour
+
+type getStatementRequest struct {
+	From time.Time `form:"from" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   time.Time `form:"to" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type statementLineResponse struct {
+	EntryID        int64                 `json:"entry_id"`
+	CreatedAt      time.Time             `json:"created_at"`
+	Amount         int64                 `json:"amount"`
+	RunningBalance int64                 `json:"running_balance"`
+	TransferID     *int64                `json:"transfer_id,omitempty"`
+	Description    string                `json:"description,omitempty"`
+	Reference      string                `json:"reference,omitempty"`
+	Metadata       json.RawMessage       `json:"metadata,omitempty"`
+	Counterparty   *counterpartyResponse `json:"counterparty,omitempty"`
+}
+
+type statementResponse struct {
+	AccountID      int64                   `json:"account_id"`
+	Owner          string                  `json:"owner"`
+	Currency       string                  `json:"currency"`
+	From           time.Time               `json:"from"`
+	To             time.Time               `json:"to"`
+	OpeningBalance int64                   `json:"opening_balance"`
+	ClosingBalance int64                   `json:"closing_balance"`
+	Lines          []statementLineResponse `json:"lines"`
+}
+
+func newStatementResponse(statement db.StatementTxResult) statementResponse {
+	response := statementResponse{
+		AccountID:      statement.Account.ID,
+		Owner:          statement.Account.Owner,
+		Currency:       statement.Account.Currency,
+		From:           statement.StartTime,
+		To:             statement.EndTime,
+		OpeningBalance: statement.OpeningBalance,
+		ClosingBalance: statement.ClosingBalance,
+		Lines:          make([]statementLineResponse, 0, len(statement.Lines)),
+	}
+	for _, line := range statement.Lines {
+		lineResponse := statementLineResponse{
+			EntryID:        line.ID,
+			CreatedAt:      line.CreatedAt,
+			Amount:         line.Amount,
+			RunningBalance: line.RunningBalance,
+		}
+		lineResponse.TransferID = nullInt64Ptr(line.TransferID)
+		if line.TransferID.Valid {
+			lineResponse.Description = line.Description.String
+			lineResponse.Reference = line.Reference.String
+			lineResponse.Metadata = line.Metadata
+		}
+		if line.CounterpartyAccountID.Valid {
+			lineResponse.Counterparty = &counterpartyResponse{
+				AccountID: line.CounterpartyAccountID.Int64,
+				Owner:     line.CounterpartyOwner.String,
+				Currency:  line.CounterpartyCurrency.String,
+			}
+		}
+		response.Lines = append(response.Lines, lineResponse)
+	}
+	return response
+}
+
+func (server *Server) getStatement(ctx *gin.Context) {
+	statement, ok := server.loadStatement(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, newStatementResponse(statement))
+}
+
+type exportStatementRequest struct {
+	Format string `form:"format"`
+}
+
+func (server *Server) exportStatement(ctx *gin.Context) {
+	var req exportStatementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	// an explicit format wins over content negotiation
+	var renderer export.Renderer
+	var err error
+	if len(req.Format) > 0 {
+		renderer, err = export.NewRenderer(req.Format)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	} else {
+		renderer, err = export.NegotiateRenderer(ctx.GetHeader("Accept"))
+		if err != nil {
+			ctx.JSON(http.StatusNotAcceptable, errorResponse(err))
+			return
+		}
+	}
+
+	statement, ok := server.loadStatement(ctx)
+	if !ok {
+		return
+	}
+
+	var body bytes.Buffer
+	if err := renderer.Render(&body, statement); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	filename := fmt.Sprintf("statement-%d-%s.%s", statement.Account.ID, statement.EndTime.UTC().Format("20060102"), renderer.FileExtension())
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Data(http.StatusOK, renderer.ContentType(), body.Bytes())
+}
+
+// loadStatement binds the account id and period, checks ownership and reads the statement, writing the error
+// response if any step fails
+func (server *Server) loadStatement(ctx *gin.Context) (db.StatementTxResult, bool) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.StatementTxResult{}, false
+	}
+	var req getStatementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.StatementTxResult{}, false
+	}
+	if !req.To.After(req.From) {
+		err := errors.New("to must be after from")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.StatementTxResult{}, false
+	}
+	if req.To.Sub(req.From) > maxStatementPeriod {
+		err := errors.New("statement period must not exceed one year")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.StatementTxResult{}, false
+	}
+
+	if _, valid := server.ownedAccount(ctx, uri.Id); !valid {
+		return db.StatementTxResult{}, false
+	}
+
+	statement, err := server.store.StatementTx(ctx, db.StatementTxParams{
+		AccountID: uri.Id,
+		StartTime: req.From,
+		EndTime:   req.To,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return db.StatementTxResult{}, false
+	}
+	return statement, true
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+// fixedStatement has no random parts so the rendered exports can be compared byte for byte
+func fixedStatement() db.StatementTxResult {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lines := []struct {
+		amount       int64
+		transferID   int64
+		counterparty string
+		description  string
+		reference    string
+	}{
+		{-2550, 101, "alice", "Invoice 17, January", "INV-2024-0017"},
+		{10000, 102, "bob", "", ""},
+		{-199, 0, "", "", ""},
+	}
+
+	statement := db.StatementTxResult{
+		Account: db.Account{
+			ID:       42,
+			Owner:    "carol",
+			Currency: utils.USD,
+		},
+		StartTime:      from,
+		EndTime:        from.AddDate(0, 1, 0),
+		OpeningBalance: 5000,
+		GeneratedAt:    time.Date(2024, 2, 1, 8, 30, 0, 0, time.UTC),
+	}
+	balance := statement.OpeningBalance
+	for i, line := range lines {
+		balance += line.amount
+		row := db.ListStatementEntriesRow{
+			ID:        int64(1000 + i),
+			AccountID: statement.Account.ID,
+			Amount:    line.amount,
+			CreatedAt: from.AddDate(0, 0, i*7+3).Add(time.Duration(i+9) * time.Hour),
+		}
+		if line.transferID != 0 {
+			row.TransferID = sql.NullInt64{Int64: line.transferID, Valid: true}
+			row.CounterpartyAccountID = sql.NullInt64{Int64: line.transferID + 1000, Valid: true}
+			row.CounterpartyOwner = sql.NullString{String: line.counterparty, Valid: true}
+			row.CounterpartyCurrency = sql.NullString{String: utils.USD, Valid: true}
+			row.Description = sql.NullString{String: line.description, Valid: true}
+			row.Reference = sql.NullString{String: line.reference, Valid: true}
+		}
+		statement.Lines = append(statement.Lines, db.StatementLine{ListStatementEntriesRow: row, RunningBalance: balance})
+	}
+	statement.ClosingBalance = balance
+	return statement
+}
+
+func TestExportStatementApi(t *testing.T) {
+	statement := fixedStatement()
+	account := statement.Account
+
+	testCases := []struct {
+		name         string
+		format       string
+		accept       string
+		golden       string
+		contentType  string
+		expectedCode int
+		storeCalls   int
+	}{
+		{name: "CSVByFormat", format: "csv", golden: "statement.csv.golden", contentType: "text/csv; charset=utf-8", expectedCode: http.StatusOK, storeCalls: 1},
+		{name: "OFXByFormat", format: "ofx", golden: "statement.ofx.golden", contentType: "application/x-ofx", expectedCode: http.StatusOK, storeCalls: 1},
+		{name: "Camt053ByFormat", format: "camt053", golden: "statement.camt053.xml.golden", contentType: "application/xml; charset=utf-8", expectedCode: http.StatusOK, storeCalls: 1},
+		{name: "CSVByAccept", accept: "text/csv", golden: "statement.csv.golden", contentType: "text/csv; charset=utf-8", expectedCode: http.StatusOK, storeCalls: 1},
+		{name: "OFXByAccept", accept: "application/json, application/x-ofx", golden: "statement.ofx.golden", contentType: "application/x-ofx", expectedCode: http.StatusOK, storeCalls: 1},
+		{name: "Camt053ByAccept", accept: "application/xml", golden: "statement.camt053.xml.golden", contentType: "application/xml; charset=utf-8", expectedCode: http.StatusOK, storeCalls: 1},
+		{name: "DefaultsToCSV", golden: "statement.csv.golden", contentType: "text/csv; charset=utf-8", expectedCode: http.StatusOK, storeCalls: 1},
+		{name: "FormatWinsOverAccept", format: "ofx", accept: "text/csv", golden: "statement.ofx.golden", contentType: "application/x-ofx", expectedCode: http.StatusOK, storeCalls: 1},
+		{name: "UnsupportedFormat", format: "pdf", expectedCode: http.StatusBadRequest},
+		{name: "NotAcceptable", accept: "application/pdf", expectedCode: http.StatusNotAcceptable},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(tc.storeCalls).Return(account, nil)
+			arg := db.StatementTxParams{
+				AccountID: account.ID,
+				StartTime: statement.StartTime,
+				EndTime:   statement.EndTime,
+			}
+			store.EXPECT().StatementTx(gomock.Any(), gomock.Eq(arg)).Times(tc.storeCalls).Return(statement, nil)
+
+			server := NewTestServer(t, store)
+
+			url := fmt.Sprintf("/accounts/%d/statement/export?from=%s&to=%s&format=%s", account.ID,
+				statement.StartTime.Format(time.RFC3339), statement.EndTime.Format(time.RFC3339), tc.format)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			if len(tc.accept) > 0 {
+				request.Header.Set("Accept", tc.accept)
+			}
+			createAndSetAuthToken(t, request, server.maker, account.Owner)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.expectedCode, recorder.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			require.Equal(t, tc.contentType, recorder.Header().Get("Content-Type"))
+			requireGolden(t, tc.golden, recorder.Body.Bytes())
+		})
+	}
+}
+
+func requireGolden(t *testing.T, name string, actual []byte) {
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(path, actual, 0644))
+	}
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(actual))
+}
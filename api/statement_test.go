@@ -0,0 +1,183 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomStatement(account db.Account, counterparty db.Account, from, to time.Time) db.StatementTxResult {
+	statement := db.StatementTxResult{
+		Account:        account,
+		StartTime:      from,
+		EndTime:        to,
+		OpeningBalance: 100,
+	}
+	balance := statement.OpeningBalance
+	for i, amount := range []int64{-30, 50, -20} {
+		balance += amount
+		statement.Lines = append(statement.Lines, db.StatementLine{
+			ListStatementEntriesRow: db.ListStatementEntriesRow{
+				ID:                    int64(i + 1),
+				AccountID:             account.ID,
+				Amount:                amount,
+				CreatedAt:             from.Add(time.Duration(i+1) * time.Hour),
+				TransferID:            sql.NullInt64{Int64: int64(i + 10), Valid: true},
+				CounterpartyAccountID: sql.NullInt64{Int64: counterparty.ID, Valid: true},
+				CounterpartyOwner:     sql.NullString{String: counterparty.Owner, Valid: true},
+				CounterpartyCurrency:  sql.NullString{String: counterparty.Currency, Valid: true},
+			},
+			RunningBalance: balance,
+		})
+	}
+	statement.ClosingBalance = balance
+	return statement
+}
+
+func TestGetStatementApi(t *testing.T) {
+	user1, _ := createUser(t)
+	user2, _ := createUser(t)
+	account := randomAccount(user1.Username)
+	counterparty := randomAccount(user2.Username)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	statement := randomStatement(account, counterparty, from, to)
+
+	testCases := []struct {
+		name          string
+		from          string
+		to            string
+		authUsername  string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "OK",
+			from:         from.Format(time.RFC3339),
+			to:           to.Format(time.RFC3339),
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.StatementTxParams{
+					AccountID: account.ID,
+					StartTime: from,
+					EndTime:   to,
+				}
+				store.EXPECT().StatementTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(statement, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var response statementResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, statement.OpeningBalance, response.OpeningBalance)
+				require.Equal(t, statement.ClosingBalance, response.ClosingBalance)
+				require.Len(t, response.Lines, len(statement.Lines))
+				for i, line := range response.Lines {
+					require.Equal(t, statement.Lines[i].RunningBalance, line.RunningBalance)
+					require.Equal(t, counterparty.Owner, line.Counterparty.Owner)
+				}
+			},
+		},
+		{
+			name:         "UnauthorizedUser",
+			from:         from.Format(time.RFC3339),
+			to:           to.Format(time.RFC3339),
+			authUsername: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().StatementTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:         "MissingPeriod",
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().StatementTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:         "ToBeforeFrom",
+			from:         to.Format(time.RFC3339),
+			to:           from.Format(time.RFC3339),
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().StatementTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:         "PeriodTooLong",
+			from:         from.Format(time.RFC3339),
+			to:           from.AddDate(2, 0, 0).Format(time.RFC3339),
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().StatementTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:         "InternalError",
+			from:         from.Format(time.RFC3339),
+			to:           to.Format(time.RFC3339),
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().StatementTx(gomock.Any(), gomock.Any()).Times(1).Return(db.StatementTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+
+			query := url.Values{}
+			if tc.from != "" {
+				query.Set("from", tc.from)
+			}
+			if tc.to != "" {
+				query.Set("to", tc.to)
+			}
+			url := fmt.Sprintf("/accounts/%d/statement?%s", account.ID, query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			createAndSetAuthToken(t, request, server.maker, tc.authUsername)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -1,21 +1,39 @@
 package api
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/fx"
 	"github.com/DingBao-sys/simple_bank/token"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
 )
 
 type transferRequest struct {
-	FromAccountId int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountId   int64  `json:"to_account_id" binding:"required,min=1"`
-	Currency      string `json:"currency" binding:"required,currency"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	FromAccountId int64           `json:"from_account_id" binding:"required,min=1"`
+	ToAccountId   int64           `json:"to_account_id" binding:"required_without=Recipient,excluded_with=Recipient,omitempty,min=1"`
+	Recipient     string          `json:"recipient" binding:"max=255"`
+	Currency      string          `json:"currency" binding:"required,currency"`
+	Amount        int64           `json:"amount" binding:"required,gt=0"`
+	QuoteId       string          `json:"quote_id" binding:"omitempty,uuid"`
+	Description   string          `json:"description" binding:"max=140"`
+	Reference     string          `json:"reference" binding:"max=35"`
+	Metadata      json.RawMessage `json:"metadata" binding:"omitempty,metadata"`
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) {
@@ -24,47 +42,251 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	idempotencyKey := ctx.GetHeader(idempotencyKeyHeader)
+	var requestHash string
+	if len(idempotencyKey) > 0 {
+		var valid bool
+		requestHash, valid = server.replayTransfer(ctx, request, authPayload.Username, idempotencyKey)
+		if !valid {
+			return
+		}
+	}
+
 	fromAccount, valid := server.validAccount(ctx, request.FromAccountId, request.Currency)
 	if !valid {
 		return
 	}
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-
 	if authPayload.Username != fromAccount.Owner {
 		err := errors.New("from acccount does not belong to the authenticated user")
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
-	_, valid = server.validAccount(ctx, request.ToAccountId, request.Currency)
-
+	toAccount, valid := server.destinationAccount(ctx, request.ToAccountId, request.Recipient, fromAccount)
+	if !valid {
+		return
+	}
+	outcome, assessmentID, valid := server.screenTransfer(ctx, authPayload.Username, fromAccount, toAccount, request.Amount)
 	if !valid {
 		return
 	}
-	arg := db.TransferTxParams{
-		FromAccountId: request.FromAccountId,
-		ToAccountId:   request.ToAccountId,
-		Amount:        request.Amount,
+	policy, err := server.store.GetApprovalPolicy(ctx, fromAccount.ID)
+	if err != nil && err != sql.ErrNoRows {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	// a transfer above the approval threshold waits for its approvers and one the risk engine flagged for an admin
+	needsApproval := err == nil && request.Amount > policy.Threshold
+	if needsApproval || outcome == db.RiskOutcomeReview {
+		hold := pendingHold{}
+		if needsApproval {
+			hold.requiredApprovals = policy.RequiredApprovals
+		}
+		if outcome == db.RiskOutcomeReview {
+			hold.riskAssessmentID = sql.NullInt64{Int64: assessmentID, Valid: true}
+		}
+		server.createPendingTransfer(ctx, request, hold, authPayload.Username, fromAccount, toAccount, idempotencyKey, requestHash)
+		return
+	}
+	arg := db.FxTransferTxParams{
+		TransferTxParams: db.TransferTxParams{
+			FromAccountId: request.FromAccountId,
+			ToAccountId:   toAccount.ID,
+			Amount:        request.Amount,
+			Description:   request.Description,
+			Reference:     request.Reference,
+			Metadata:      request.Metadata,
+		},
+	}
+	// the amount is always in the source currency, a destination in another currency is credited at the locked
+	// quote rate if one was given and at the current rate otherwise
+	if len(request.QuoteId) > 0 {
+		if !server.applyQuote(ctx, &arg, request, authPayload.Username, fromAccount, toAccount) {
+			return
+		}
+	} else if toAccount.Currency != fromAccount.Currency {
+		rate, err := server.rates.GetRate(ctx, fromAccount.Currency, toAccount.Currency, time.Now())
+		if err != nil {
+			rateErrorResponse(ctx, err)
+			return
+		}
+		arg.ExchangeRate = rate.Rate
+		arg.ToAmount, err = fx.Convert(request.Amount, rate.Rate)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
 	}
 
-	result, err := server.store.TransferTx(ctx, arg)
+	if len(idempotencyKey) > 0 {
+		server.idempotentTransfer(ctx, arg, authPayload.Username, idempotencyKey, requestHash)
+		return
+	}
+
+	var result db.TransferTxResult
+	if arg.ExchangeRate > 0 {
+		result, err = server.store.FxTransferTx(ctx, arg)
+	} else {
+		result, err = server.store.TransferTx(ctx, arg.TransferTxParams)
+	}
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		transferErrorResponse(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, result)
 }
 
-// validate w.r.t to the accountId and the currency
-func (server *Server) validAccount(ctx *gin.Context, accountId int64, currency string) (db.Account, bool) {
-	account, err := server.store.GetAccount(ctx, accountId)
-
+// applyQuote checks the quote belongs to the user and matches the transfer, then sets its locked rate on arg
+func (server *Server) applyQuote(ctx *gin.Context, arg *db.FxTransferTxParams, request transferRequest, username string, fromAccount db.Account, toAccount db.Account) bool {
+	quoteID := uuid.MustParse(request.QuoteId)
+	quote, err := server.store.GetFxQuote(ctx, quoteID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return account, false
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	if quote.Username != username {
+		err := errors.New("quote does not belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return false
+	}
+	if quote.FromCurrency != fromAccount.Currency || quote.ToCurrency != toAccount.Currency || quote.FromAmount != request.Amount {
+		err := fmt.Errorf("quote is for %d %s to %s", quote.FromAmount, quote.FromCurrency, quote.ToCurrency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false
+	}
+	if quote.UsedAt.Valid {
+		ctx.JSON(http.StatusConflict, errorResponse(db.ErrQuoteUsed))
+		return false
+	}
+	if time.Now().After(quote.ExpiresAt) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(db.ErrQuoteExpired))
+		return false
+	}
+	arg.ExchangeRate = quote.Rate
+	arg.ToAmount = quote.ToAmount
+	arg.QuoteID = uuid.NullUUID{UUID: quote.ID, Valid: true}
+	return true
+}
+
+// replayTransfer looks the idempotency key up before anything else is done for the request, so a retry replays the
+// stored result instead of being screened by the risk engine a second time or turned down for a quote the first
+// attempt used up. It returns the request hash for a key that was not used yet, and false when it wrote the response,
+// the replay included
+func (server *Server) replayTransfer(ctx *gin.Context, request transferRequest, username string, key string) (string, bool) {
+	if len(key) > maxIdempotencyKeyLength {
+		err := fmt.Errorf("%s must be at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return "", false
+	}
+	requestHash, err := hashRequest(request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return "", false
+	}
+	stored, err := server.store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username: username,
+		Key:      key,
+	})
+	if err == sql.ErrNoRows {
+		return requestHash, true
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return "", false
+	}
+	// an expired key is used again, the transaction that stores the new one deletes it
+	if stored.CreatedAt.Before(time.Now().Add(-server.config.IdempotencyKeyRetention)) {
+		return requestHash, true
+	}
+	if stored.RequestHash != requestHash {
+		ctx.JSON(http.StatusConflict, errorResponse(db.ErrIdempotencyKeyReused))
+		return "", false
+	}
+	// the first request was held for approval, the retry gets the pending transfer as it stands now
+	if stored.PendingTransferID.Valid {
+		pending, err := server.store.GetPendingTransfer(ctx, stored.PendingTransferID.Int64)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return "", false
 		}
+		ctx.Header(idempotentReplayedHeader, "true")
+		ctx.JSON(http.StatusAccepted, newPendingTransferResponse(pending))
+		return "", false
+	}
+	var result db.TransferTxResult
+	if err := json.Unmarshal(stored.Response, &result); err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return "", false
+	}
+	ctx.Header(idempotentReplayedHeader, "true")
+	ctx.JSON(http.StatusOK, result)
+	return "", false
+}
+
+// idempotentTransfer runs a transfer whose key was not found by replayTransfer. A concurrent request with the same key
+// can still get there first, IdempotentTransferTx then replays its result
+func (server *Server) idempotentTransfer(ctx *gin.Context, arg db.FxTransferTxParams, username string, key string, requestHash string) {
+	result, err := server.store.IdempotentTransferTx(ctx, db.IdempotentTransferTxParams{
+		FxTransferTxParams: arg,
+		Username:           username,
+		Key:                key,
+		RequestHash:        requestHash,
+		ExpiredBefore:      time.Now().Add(-server.config.IdempotencyKeyRetention),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyReused) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		transferErrorResponse(ctx, err)
+		return
+	}
+	if result.Replayed {
+		ctx.Header(idempotentReplayedHeader, "true")
+	}
+	ctx.JSON(http.StatusOK, result.TransferTxResult)
+}
+
+func transferErrorResponse(ctx *gin.Context, err error) {
+	if isInsufficientFunds(err) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(db.ErrInsufficientFunds))
+		return
+	}
+	if errors.Is(err, db.ErrTransferLimitExceeded) || errors.Is(err, db.ErrProductRuleViolated) ||
+		errors.Is(err, db.ErrAccountNotActive) || errors.Is(err, db.ErrApprovalRequired) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+	if errors.Is(err, db.ErrInvalidExchangeRate) || errors.Is(err, db.ErrQuoteExpired) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+	if errors.Is(err, db.ErrQuoteUsed) {
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+}
+
+// hashRequest fingerprints the request body so a reused idempotency key can be told apart from a genuine retry
+func hashRequest(request any) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// validate w.r.t to the accountId and the currency
+func (server *Server) validAccount(ctx *gin.Context, accountId int64, currency string) (db.Account, bool) {
+	account, valid := server.loadAccount(ctx, accountId)
+	if !valid {
 		return account, false
 	}
 	if account.Currency != currency {
@@ -74,3 +296,244 @@ func (server *Server) validAccount(ctx *gin.Context, accountId int64, currency s
 	}
 	return account, true
 }
+
+// destinationAccount loads the account a transfer is paid into, either by id or as the account the recipient holds in
+// the currency of the source account, writing the error response if there is none
+func (server *Server) destinationAccount(ctx *gin.Context, toAccountId int64, recipient string, fromAccount db.Account) (db.Account, bool) {
+	if len(recipient) == 0 {
+		return server.loadAccount(ctx, toAccountId)
+	}
+	toAccount, valid := server.recipientAccount(ctx, recipient, fromAccount.Currency)
+	if !valid {
+		return toAccount, false
+	}
+	if toAccount.ID == fromAccount.ID {
+		err := errors.New("recipient resolves to the source account")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return toAccount, false
+	}
+	return toAccount, true
+}
+
+// loadAccount reads the account, writing a not found or internal error response if that fails
+func (server *Server) loadAccount(ctx *gin.Context, accountId int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return account, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return account, false
+	}
+	return account, true
+}
+
+func rateErrorResponse(ctx *gin.Context, err error) {
+	if errors.Is(err, fx.ErrRateNotFound) {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+}
+
+// the balance check in TransferTx is backed by a CHECK constraint on accounts, treat either as insufficient funds
+func isInsufficientFunds(err error) bool {
+	if errors.Is(err, db.ErrInsufficientFunds) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Name() == "check_violation" && pqErr.Constraint == "balance_within_overdraft"
+	}
+	return false
+}
+
+const (
+	directionIncoming = "incoming"
+	directionOutgoing = "outgoing"
+)
+
+type counterpartyResponse struct {
+	AccountID int64  `json:"account_id"`
+	Owner     string `json:"owner"`
+	Currency  string `json:"currency"`
+}
+
+type transferResponse struct {
+	ID            int64                `json:"id"`
+	FromAccountID int64                `json:"from_account_id"`
+	ToAccountID   int64                `json:"to_account_id"`
+	Amount        int64                `json:"amount"`
+	ToAmount      *int64               `json:"to_amount,omitempty"`
+	ExchangeRate  *int64               `json:"exchange_rate,omitempty"`
+	ReversalOf    *int64               `json:"reversal_of,omitempty"`
+	FeeOf         *int64               `json:"fee_of,omitempty"`
+	Description   string               `json:"description"`
+	Reference     string               `json:"reference"`
+	Metadata      json.RawMessage      `json:"metadata"`
+	CreatedAt     time.Time            `json:"created_at"`
+	Direction     string               `json:"direction"`
+	Counterparty  counterpartyResponse `json:"counterparty"`
+}
+
+type getTransferRequest struct {
+	Id int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	transfer, err := server.store.GetTransfer(ctx, req.Id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	fromAccount, err := server.store.GetAccount(ctx, transfer.FromAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	toAccount, err := server.store.GetAccount(ctx, transfer.ToAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	var response transferResponse
+	switch authPayload.Username {
+	case fromAccount.Owner:
+		response = newTransferResponse(transfer, directionOutgoing, toAccount)
+	case toAccount.Owner:
+		response = newTransferResponse(transfer, directionIncoming, fromAccount)
+	default:
+		err := errors.New("transfer does not involve an account of the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func newTransferResponse(transfer db.Transfer, direction string, counterparty db.Account) transferResponse {
+	return transferResponse{
+		ID:            transfer.ID,
+		FromAccountID: transfer.FromAccountID,
+		ToAccountID:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		ToAmount:      nullInt64Ptr(transfer.ToAmount),
+		ExchangeRate:  nullInt64Ptr(transfer.ExchangeRate),
+		ReversalOf:    nullInt64Ptr(transfer.ReversalOf),
+		FeeOf:         nullInt64Ptr(transfer.FeeOf),
+		Description:   transfer.Description,
+		Reference:     transfer.Reference,
+		Metadata:      transfer.Metadata,
+		CreatedAt:     transfer.CreatedAt,
+		Direction:     direction,
+		Counterparty: counterpartyResponse{
+			AccountID: counterparty.ID,
+			Owner:     counterparty.Owner,
+			Currency:  counterparty.Currency,
+		},
+	}
+}
+
+func nullInt64Ptr(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
+}
+
+type listAccountTransfersRequest struct {
+	PageId    int32     `form:"page_id" binding:"required,min=1"`
+	PageSize  int32     `form:"page_size" binding:"required,min=5,max=10"`
+	Direction string    `form:"direction" binding:"omitempty,oneof=incoming outgoing"`
+	StartTime time.Time `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime   time.Time `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	MinAmount int64     `form:"min_amount" binding:"omitempty,min=1"`
+	MaxAmount int64     `form:"max_amount" binding:"omitempty,min=1"`
+	Reference string    `form:"reference" binding:"max=35"`
+}
+
+func (server *Server) listAccountTransfers(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	var req listAccountTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !req.StartTime.IsZero() && !req.EndTime.IsZero() && !req.EndTime.After(req.StartTime) {
+		err := errors.New("end_time must be after start_time")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.MinAmount > 0 && req.MaxAmount > 0 && req.MaxAmount < req.MinAmount {
+		err := errors.New("max_amount must not be less than min_amount")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.ownedAccount(ctx, uri.Id)
+	if !valid {
+		return
+	}
+
+	arg := db.ListAccountTransfersParams{
+		AccountID:  account.ID,
+		Outgoing:   req.Direction != directionIncoming,
+		Incoming:   req.Direction != directionOutgoing,
+		StartTime:  sql.NullTime{Time: req.StartTime, Valid: !req.StartTime.IsZero()},
+		EndTime:    sql.NullTime{Time: req.EndTime, Valid: !req.EndTime.IsZero()},
+		MinAmount:  sql.NullInt64{Int64: req.MinAmount, Valid: req.MinAmount > 0},
+		MaxAmount:  sql.NullInt64{Int64: req.MaxAmount, Valid: req.MaxAmount > 0},
+		Reference:  sql.NullString{String: req.Reference, Valid: len(req.Reference) > 0},
+		PageLimit:  req.PageSize,
+		PageOffset: (req.PageId - 1) * req.PageSize,
+	}
+	rows, err := server.store.ListAccountTransfers(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	response := make([]transferResponse, 0, len(rows))
+	for _, row := range rows {
+		direction := directionIncoming
+		if row.FromAccountID == account.ID {
+			direction = directionOutgoing
+		}
+		response = append(response, transferResponse{
+			ID:            row.ID,
+			FromAccountID: row.FromAccountID,
+			ToAccountID:   row.ToAccountID,
+			Amount:        row.Amount,
+			ToAmount:      nullInt64Ptr(row.ToAmount),
+			ExchangeRate:  nullInt64Ptr(row.ExchangeRate),
+			ReversalOf:    nullInt64Ptr(row.ReversalOf),
+			FeeOf:         nullInt64Ptr(row.FeeOf),
+			Description:   row.Description,
+			Reference:     row.Reference,
+			Metadata:      row.Metadata,
+			CreatedAt:     row.CreatedAt,
+			Direction:     direction,
+			Counterparty: counterpartyResponse{
+				AccountID: row.CounterpartyAccountID,
+				Owner:     row.CounterpartyOwner,
+				Currency:  row.CounterpartyCurrency,
+			},
+		})
+	}
+	ctx.JSON(http.StatusOK, response)
+}
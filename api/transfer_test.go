@@ -4,15 +4,19 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
 	db "github.com/DingBao-sys/simple_bank/db/sqlc"
 	"github.com/DingBao-sys/simple_bank/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
@@ -31,6 +35,8 @@ func TestTransferApi(t *testing.T) {
 	account2.Currency = utils.USD
 	account3.Currency = utils.EUR
 
+	quote := randomQuote(user1.Username, utils.USD, utils.EUR, amount)
+
 	testCases := []struct {
 		name          string
 		body          gin.H
@@ -63,6 +69,208 @@ func TestTransferApi(t *testing.T) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			name: "WithDetails",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+				"description":     "March rent",
+				"reference":       "RENT-2024-03",
+				"metadata":        gin.H{"unit": "4B"},
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.TransferTxParams{
+					FromAccountId: account1.ID,
+					ToAccountId:   account2.ID,
+					Amount:        amount,
+					Description:   "March rent",
+					Reference:     "RENT-2024-03",
+					Metadata:      json.RawMessage(`{"unit":"4B"}`),
+				}
+
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "ToRecipient",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"recipient":       user2.Email,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Eq(user2.Email)).Times(1).Return(user2.Username, nil)
+				ownerArg := db.GetOwnerAccountParams{Owner: user2.Username, Currency: utils.USD}
+				store.EXPECT().GetOwnerAccount(gomock.Any(), gomock.Eq(ownerArg)).Times(1).Return(account2, nil)
+
+				arg := db.TransferTxParams{
+					FromAccountId: account1.ID,
+					ToAccountId:   account2.ID,
+					Amount:        amount,
+				}
+
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "RecipientNotFound",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"recipient":       "nobody",
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Eq("nobody")).Times(1).Return("", sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "RecipientWithoutAccountInCurrency",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"recipient":       user3.Username,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Eq(user3.Username)).Times(1).Return(user3.Username, nil)
+				store.EXPECT().GetOwnerAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "RecipientIsSender",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"recipient":       user1.Username,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().ResolveRecipient(gomock.Any(), gomock.Eq(user1.Username)).Times(1).Return(user1.Username, nil)
+				store.EXPECT().GetOwnerAccount(gomock.Any(), gomock.Any()).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "RecipientAndToAccount",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"recipient":       user2.Username,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NoDestination",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "MetadataNotObject",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+				"metadata":        []string{"unit", "4B"},
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "MetadataTooLarge",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+				"metadata":        gin.H{"notes": utils.GenerateRandomString(maxMetadataSize)},
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "ReferenceTooLong",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+				"reference":       utils.GenerateRandomString(36),
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "UnauthorizedUser",
 			body: gin.H{
@@ -153,7 +361,7 @@ func TestTransferApi(t *testing.T) {
 			},
 		},
 		{
-			name: "ToAccountCurrencyMismatch",
+			name: "CrossCurrency",
 			body: gin.H{
 				"from_account_id": account1.ID,
 				"to_account_id":   account3.ID,
@@ -164,107 +372,864 @@ func TestTransferApi(t *testing.T) {
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				rate := db.Rate{BaseCurrency: utils.USD, QuoteCurrency: utils.EUR, Rate: 92_000_000}
+				store.EXPECT().GetEffectiveRate(gomock.Any(), gomock.Any()).Times(1).Return(rate, nil)
+
+				arg := db.FxTransferTxParams{
+					TransferTxParams: db.TransferTxParams{
+						FromAccountId: account1.ID,
+						ToAccountId:   account3.ID,
+						Amount:        amount,
+					},
+					ToAmount:     9,
+					ExchangeRate: rate.Rate,
+				}
 				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().FxTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
 		{
-			name: "InvalidCurrency",
+			name: "NoExchangeRate",
 			body: gin.H{
 				"from_account_id": account1.ID,
-				"to_account_id":   account2.ID,
+				"to_account_id":   account3.ID,
 				"amount":          amount,
-				"currency":        "XYZ",
+				"currency":        utils.USD,
 			},
 			authUsername: user1.Username,
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(0)
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().GetEffectiveRate(gomock.Any(), gomock.Any()).Times(1).Return(db.Rate{}, sql.ErrNoRows)
+				store.EXPECT().FxTransferTx(gomock.Any(), gomock.Any()).Times(0)
 				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
 			},
 		},
 		{
-			name: "NegativeAmount",
+			name: "LockedQuote",
 			body: gin.H{
 				"from_account_id": account1.ID,
-				"to_account_id":   account2.ID,
-				"amount":          -amount,
+				"to_account_id":   account3.ID,
+				"amount":          amount,
 				"currency":        utils.USD,
+				"quote_id":        quote.ID.String(),
 			},
 			authUsername: user1.Username,
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(0)
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
-				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().GetFxQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(quote, nil)
+				store.EXPECT().GetEffectiveRate(gomock.Any(), gomock.Any()).Times(0)
+
+				arg := db.FxTransferTxParams{
+					TransferTxParams: db.TransferTxParams{
+						FromAccountId: account1.ID,
+						ToAccountId:   account3.ID,
+						Amount:        amount,
+					},
+					ToAmount:     quote.ToAmount,
+					ExchangeRate: quote.Rate,
+					QuoteID:      uuid.NullUUID{UUID: quote.ID, Valid: true},
+				}
+				store.EXPECT().FxTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
 		{
-			name: "GetAccountError",
+			name: "QuoteNotFound",
 			body: gin.H{
 				"from_account_id": account1.ID,
-				"to_account_id":   account2.ID,
+				"to_account_id":   account3.ID,
 				"amount":          amount,
 				"currency":        utils.USD,
+				"quote_id":        quote.ID.String(),
 			},
 			authUsername: user1.Username,
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, sql.ErrConnDone)
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
-				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().GetFxQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(db.FxQuote{}, sql.ErrNoRows)
+				store.EXPECT().FxTransferTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, http.StatusNotFound, recorder.Code)
 			},
 		},
 		{
-			name: "TransferTxError",
+			name: "QuoteOfOtherUser",
 			body: gin.H{
 				"from_account_id": account1.ID,
-				"to_account_id":   account2.ID,
+				"to_account_id":   account3.ID,
 				"amount":          amount,
 				"currency":        utils.USD,
+				"quote_id":        quote.ID.String(),
 			},
 			authUsername: user1.Username,
 			buildStubs: func(store *mockdb.MockStore) {
+				otherQuote := quote
+				otherQuote.Username = user2.Username
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
-				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
-
-				args := db.TransferTxParams{
-					FromAccountId: account1.ID,
-					ToAccountId:   account2.ID,
-					Amount:        amount,
-				}
-				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(args)).Times(1).Return(db.TransferTxResult{}, sql.ErrTxDone)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().GetFxQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(otherQuote, nil)
+				store.EXPECT().FxTransferTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			ctrl := gomock.NewController(t)
-			defer ctrl.Finish()
-
-			store := mockdb.NewMockStore(ctrl)
-			tc.buildStubs(store)
-
-			server := NewTestServer(t, store)
-
-			data, err := json.Marshal(tc.body)
-			require.NoError(t, err)
-
-			url := "/transfers"
-			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+		{
+			name: "QuoteAmountMismatch",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account3.ID,
+				"amount":          amount + 1,
+				"currency":        utils.USD,
+				"quote_id":        quote.ID.String(),
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().GetFxQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(quote, nil)
+				store.EXPECT().FxTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "QuoteExpired",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account3.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+				"quote_id":        quote.ID.String(),
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				expiredQuote := quote
+				expiredQuote.ExpiresAt = time.Now().Add(-time.Second)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().GetFxQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(expiredQuote, nil)
+				store.EXPECT().FxTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "QuoteUsed",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account3.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+				"quote_id":        quote.ID.String(),
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().GetFxQuote(gomock.Any(), gomock.Eq(quote.ID)).Times(1).Return(quote, nil)
+				store.EXPECT().FxTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrQuoteUsed)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCurrency",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        "XYZ",
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(0)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NegativeAmount",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          -amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(0)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "GetAccountError",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, sql.ErrConnDone)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "TransferTxError",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				args := db.TransferTxParams{
+					FromAccountId: account1.ID,
+					ToAccountId:   account2.ID,
+					Amount:        amount,
+				}
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(args)).Times(1).Return(db.TransferTxResult{}, sql.ErrTxDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "LimitExceeded",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				err := fmt.Errorf("%w: 5 left of the daily limit", db.ErrTransferLimitExceeded)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "ProductRuleViolated",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				err := fmt.Errorf("%w: Savings accounts allow 6 withdrawals a month", db.ErrProductRuleViolated)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "AccountNotActive",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				err := fmt.Errorf("%w: account %d is frozen", db.ErrAccountNotActive, account2.ID)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "OverdraftConstraintViolation",
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          amount,
+				"currency":        utils.USD,
+			},
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				err := &pq.Error{Code: "23514", Constraint: "balance_within_overdraft"}
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, err)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			// accounts have no approval policy unless a case says otherwise
+			store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Any()).AnyTimes().Return(db.ApprovalPolicy{}, sql.ErrNoRows)
+
+			server := NewTestServer(t, store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/transfers"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			createAndSetAuthToken(t, request, server.maker, tc.authUsername)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestIdempotentTransferApi(t *testing.T) {
+	amount := int64(10)
+
+	user1, _ := createUser(t)
+	user2, _ := createUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = utils.USD
+	account2.Currency = utils.USD
+
+	body := gin.H{
+		"from_account_id": account1.ID,
+		"to_account_id":   account2.ID,
+		"amount":          amount,
+		"currency":        utils.USD,
+	}
+	requestHash, err := hashRequest(transferRequest{
+		FromAccountId: account1.ID,
+		ToAccountId:   account2.ID,
+		Amount:        amount,
+		Currency:      utils.USD,
+	})
+	require.NoError(t, err)
+	stored := db.IdempotencyKey{
+		Username:    user1.Username,
+		Key:         "retry-key",
+		RequestHash: requestHash,
+		Response:    json.RawMessage(`{"transfer":{"id":42}}`),
+		CreatedAt:   time.Now(),
+	}
+	keyArg := db.GetIdempotencyKeyParams{Username: user1.Username, Key: "retry-key"}
+
+	// a quote can only be used once, so a retry has to be replayed before the quote is looked at
+	account3 := randomAccount(user2.Username)
+	account3.Currency = utils.EUR
+	quote := randomQuote(user1.Username, utils.USD, utils.EUR, amount)
+	quoteBody := gin.H{
+		"from_account_id": account1.ID,
+		"to_account_id":   account3.ID,
+		"amount":          amount,
+		"currency":        utils.USD,
+		"quote_id":        quote.ID.String(),
+	}
+	quoteHash, err := hashRequest(transferRequest{
+		FromAccountId: account1.ID,
+		ToAccountId:   account3.ID,
+		Amount:        amount,
+		Currency:      utils.USD,
+		QuoteId:       quote.ID.String(),
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		body           gin.H
+		idempotencyKey string
+		buildStubs     func(store *mockdb.MockStore)
+		checkResponse  func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:           "OK",
+			idempotencyKey: "retry-key",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(keyArg)).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ any, arg db.IdempotentTransferTxParams) (db.IdempotentTransferTxResult, error) {
+						require.Equal(t, user1.Username, arg.Username)
+						require.Equal(t, "retry-key", arg.Key)
+						require.Equal(t, requestHash, arg.RequestHash)
+						require.Equal(t, amount, arg.Amount)
+						return db.IdempotentTransferTxResult{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			name:           "Replayed",
+			idempotencyKey: "retry-key",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(keyArg)).Times(1).Return(stored, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+				var got db.TransferTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, int64(42), got.Transfer.ID)
+			},
+		},
+		{
+			name:           "ReplayedWithQuote",
+			body:           quoteBody,
+			idempotencyKey: "retry-key",
+			buildStubs: func(store *mockdb.MockStore) {
+				withQuote := stored
+				withQuote.RequestHash = quoteHash
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(keyArg)).Times(1).Return(withQuote, nil)
+				store.EXPECT().GetFxQuote(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			name:           "ReplayedConcurrently",
+			idempotencyKey: "retry-key",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(keyArg)).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.IdempotentTransferTxResult{Replayed: true}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			name:           "Expired",
+			idempotencyKey: "retry-key",
+			buildStubs: func(store *mockdb.MockStore) {
+				expired := stored
+				expired.CreatedAt = time.Now().Add(-48 * time.Hour)
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(keyArg)).Times(1).Return(expired, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.IdempotentTransferTxResult{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			name:           "KeyReused",
+			idempotencyKey: "retry-key",
+			buildStubs: func(store *mockdb.MockStore) {
+				reused := stored
+				reused.RequestHash = "another request"
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(keyArg)).Times(1).Return(reused, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:           "KeyReusedConcurrently",
+			idempotencyKey: "retry-key",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(keyArg)).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.IdempotentTransferTxResult{}, db.ErrIdempotencyKeyReused)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:           "KeyTooLong",
+			idempotencyKey: utils.GenerateRandomString(maxIdempotencyKeyLength + 1),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IdempotentTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			// accounts have no approval policy unless a case says otherwise
+			store.EXPECT().GetApprovalPolicy(gomock.Any(), gomock.Any()).AnyTimes().Return(db.ApprovalPolicy{}, sql.ErrNoRows)
+
+			server := NewTestServer(t, store)
+
+			requestBody := tc.body
+			if requestBody == nil {
+				requestBody = body
+			}
+			data, err := json.Marshal(requestBody)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set(idempotencyKeyHeader, tc.idempotencyKey)
+
+			createAndSetAuthToken(t, request, server.maker, user1.Username)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func randomTransfer(fromAccount, toAccount db.Account) db.Transfer {
+	return db.Transfer{
+		ID:            utils.GenerateRandomInt(1, 1000),
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        utils.GenerateRandomInt(1, 100),
+	}
+}
+
+func TestGetTransferApi(t *testing.T) {
+	user1, _ := createUser(t)
+	user2, _ := createUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account2.ID = account1.ID + 1
+	transfer := randomTransfer(account1, account2)
+
+	testCases := []struct {
+		name          string
+		transferID    int64
+		authUsername  string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "Sender",
+			transferID:   transfer.ID,
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var response transferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, transfer.ID, response.ID)
+				require.Equal(t, directionOutgoing, response.Direction)
+				require.Equal(t, account2.Owner, response.Counterparty.Owner)
+				require.Equal(t, account2.Currency, response.Counterparty.Currency)
+			},
+		},
+		{
+			name:         "Receiver",
+			transferID:   transfer.ID,
+			authUsername: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var response transferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, directionIncoming, response.Direction)
+				require.Equal(t, account1.Owner, response.Counterparty.Owner)
+			},
+		},
+		{
+			name:         "UnauthorizedUser",
+			transferID:   transfer.ID,
+			authUsername: "unauthorized_user",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(account1, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:         "NotFound",
+			transferID:   transfer.ID,
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(db.Transfer{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:         "InvalidID",
+			transferID:   0,
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+
+			url := fmt.Sprintf("/transfers/%d", tc.transferID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			createAndSetAuthToken(t, request, server.maker, tc.authUsername)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListAccountTransfersApi(t *testing.T) {
+	user1, _ := createUser(t)
+	user2, _ := createUser(t)
+	account := randomAccount(user1.Username)
+
+	row := db.ListAccountTransfersRow{
+		ID:                    1,
+		FromAccountID:         account.ID,
+		ToAccountID:           account.ID + 1,
+		Amount:                10,
+		CounterpartyAccountID: account.ID + 1,
+		CounterpartyOwner:     user2.Username,
+		CounterpartyCurrency:  account.Currency,
+		Reference:             "INV-7",
+		Metadata:              json.RawMessage(`{"order":7}`),
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		authUsername  string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "OK",
+			query:        "page_id=1&page_size=5",
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.ListAccountTransfersParams{
+					AccountID:  account.ID,
+					Outgoing:   true,
+					Incoming:   true,
+					PageLimit:  5,
+					PageOffset: 0,
+				}
+				store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.ListAccountTransfersRow{row}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var response []transferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Len(t, response, 1)
+				require.Equal(t, directionOutgoing, response[0].Direction)
+				require.Equal(t, user2.Username, response[0].Counterparty.Owner)
+				require.Equal(t, row.Reference, response[0].Reference)
+				require.JSONEq(t, string(row.Metadata), string(response[0].Metadata))
+			},
+		},
+		{
+			name:         "ByReference",
+			query:        "page_id=1&page_size=5&reference=INV-7",
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.ListAccountTransfersParams{
+					AccountID:  account.ID,
+					Outgoing:   true,
+					Incoming:   true,
+					Reference:  sql.NullString{String: "INV-7", Valid: true},
+					PageLimit:  5,
+					PageOffset: 0,
+				}
+				store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.ListAccountTransfersRow{row}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:         "Filters",
+			query:        "page_id=2&page_size=5&direction=incoming&start_time=2024-01-01T00:00:00Z&end_time=2024-02-01T00:00:00Z&min_amount=5&max_amount=50",
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ any, arg db.ListAccountTransfersParams) ([]db.ListAccountTransfersRow, error) {
+						require.False(t, arg.Outgoing)
+						require.True(t, arg.Incoming)
+						require.True(t, arg.StartTime.Valid)
+						require.True(t, arg.EndTime.Valid)
+						require.Equal(t, int64(5), arg.MinAmount.Int64)
+						require.Equal(t, int64(50), arg.MaxAmount.Int64)
+						require.Equal(t, int32(5), arg.PageOffset)
+						return []db.ListAccountTransfersRow{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:         "InvalidDirection",
+			query:        "page_id=1&page_size=5&direction=sideways",
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:         "InvalidAmountRange",
+			query:        "page_id=1&page_size=5&min_amount=50&max_amount=5",
+			authUsername: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:         "UnauthorizedUser",
+			query:        "page_id=1&page_size=5",
+			authUsername: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+
+			url := fmt.Sprintf("/accounts/%d/transfers?%s", account.ID, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
 
 			createAndSetAuthToken(t, request, server.maker, tc.authUsername)
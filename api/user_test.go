@@ -2,13 +2,16 @@ package api
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
 	db "github.com/DingBao-sys/simple_bank/db/sqlc"
@@ -66,6 +69,10 @@ func TestCreateUserAPI(t *testing.T) {
 					FullName: user.FullName,
 					Email:    user.Email,
 				}
+				store.EXPECT().
+					GetAlias(gomock.Any(), strings.ToLower(user.Username)).
+					Times(1).
+					Return(db.Alias{}, sql.ErrNoRows)
 				store.EXPECT().
 					CreateUser(gomock.Any(), eqCreateUserParams(arg, password)).
 					Times(1).
@@ -76,6 +83,27 @@ func TestCreateUserAPI(t *testing.T) {
 				requireBodyMatchUser(t, recorder.Body, user)
 			},
 		},
+		{
+			name: "UsernameTakenByAlias",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAlias(gomock.Any(), strings.ToLower(user.Username)).
+					Times(1).
+					Return(db.Alias{Alias: strings.ToLower(user.Username), Username: "someone"}, nil)
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -126,3 +154,104 @@ func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.User) {
 	require.Empty(t, testUser.HashedPassword)
 	require.Equal(t, testUser.Email, user.Email)
 }
+
+func TestChangePasswordAPI(t *testing.T) {
+	user, password := createUser(t)
+	newPassword := utils.GenerateRandomString(8)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"old_password": password,
+				"new_password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ any, arg db.UpdateUserPasswordParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NoError(t, utils.CheckPassword(newPassword, arg.HashedPassword))
+						changed := user
+						changed.HashedPassword = arg.HashedPassword
+						changed.PasswordChangedAt = time.Now()
+						return changed, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got userResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, user.Username, got.Username)
+				require.WithinDuration(t, time.Now(), got.PasswordChangedAt, time.Second)
+			},
+		},
+		{
+			name: "WrongPassword",
+			body: gin.H{
+				"old_password": password + "x",
+				"new_password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NewPasswordTooShort",
+			body: gin.H{
+				"old_password": password,
+				"new_password": "12345",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"old_password": password,
+				"new_password": newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UpdateUserPassword(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := NewTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPut, "/users/password", bytes.NewReader(data))
+			require.NoError(t, err)
+			createAndSetAuthToken(t, request, server.maker, user.Username)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
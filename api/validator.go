@@ -1,6 +1,9 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
+
 	"github.com/DingBao-sys/simple_bank/utils"
 	"github.com/go-playground/validator/v10"
 )
@@ -11,3 +14,16 @@ var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 	}
 	return false
 }
+
+// maxMetadataSize bounds the JSON a caller can attach to a transfer
+const maxMetadataSize = 4096
+
+var validMetadata validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	metadata, ok := fieldLevel.Field().Interface().(json.RawMessage)
+	if !ok || len(metadata) > maxMetadataSize {
+		return false
+	}
+	var object map[string]any
+	trimmed := bytes.TrimSpace(metadata)
+	return len(trimmed) > 0 && trimmed[0] == '{' && json.Unmarshal(trimmed, &object) == nil
+}
@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "balance_within_overdraft";
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "overdraft_limit_non_negative";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "overdraft_limit";
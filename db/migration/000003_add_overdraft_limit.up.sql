@@ -0,0 +1,9 @@
+ALTER TABLE "accounts" ADD COLUMN "overdraft_limit" BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "accounts"."overdraft_limit" IS 'how far below zero the balance may go';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "overdraft_limit_non_negative" CHECK ("overdraft_limit" >= 0);
+
+-- balances TransferTx already drove below zero are not given an overdraft, the constraint skips them until they are
+-- corrected by hand and it is validated with ALTER TABLE "accounts" VALIDATE CONSTRAINT "balance_within_overdraft"
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_within_overdraft" CHECK ("balance" >= -"overdraft_limit") NOT VALID;
@@ -0,0 +1,18 @@
+ALTER TABLE "entries" ADD COLUMN "transfer_id" BIGINT;
+
+-- TransferTx wrote a transfer and its two entries in one transaction, so existing entries are matched to their transfer
+-- by account, signed amount and the shared now() of that transaction
+UPDATE "entries" e SET "transfer_id" = t."id"
+FROM "transfers" t
+WHERE e."transfer_id" IS NULL
+AND e."created_at" = t."created_at"
+AND (
+  (e."account_id" = t."from_account_id" AND e."amount" = -t."amount") OR
+  (e."account_id" = t."to_account_id" AND e."amount" = t."amount")
+);
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+CREATE INDEX ON "entries" ("account_id", "created_at");
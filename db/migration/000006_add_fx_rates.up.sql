@@ -0,0 +1,24 @@
+CREATE TABLE "rates" (
+  "id" BIGSERIAL PRIMARY KEY,
+  "base_currency" varchar NOT NULL,
+  "quote_currency" varchar NOT NULL,
+  "rate" BIGINT NOT NULL,
+  "effective_at" TIMESTAMPTZ NOT NULL,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "rates"."rate" IS 'quote currency units per base currency unit, scaled by 1e8';
+
+ALTER TABLE "rates" ADD CONSTRAINT "rate_positive" CHECK ("rate" > 0);
+
+CREATE INDEX ON "rates" ("base_currency", "quote_currency", "effective_at");
+
+ALTER TABLE "transfers" ADD COLUMN "to_amount" BIGINT;
+
+ALTER TABLE "transfers" ADD COLUMN "exchange_rate" BIGINT;
+
+COMMENT ON COLUMN "transfers"."to_amount" IS 'amount credited in the destination currency, null when both accounts share a currency';
+
+COMMENT ON COLUMN "transfers"."exchange_rate" IS 'applied rate scaled by 1e8, null when both accounts share a currency';
+
+ALTER TABLE "transfers" ADD CONSTRAINT "fx_fields_together" CHECK (("to_amount" IS NULL) = ("exchange_rate" IS NULL));
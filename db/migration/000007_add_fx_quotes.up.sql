@@ -0,0 +1,18 @@
+CREATE TABLE "fx_quotes" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "from_currency" varchar NOT NULL,
+  "to_currency" varchar NOT NULL,
+  "rate" BIGINT NOT NULL,
+  "from_amount" BIGINT NOT NULL,
+  "to_amount" BIGINT NOT NULL,
+  "expires_at" TIMESTAMPTZ NOT NULL,
+  "used_at" TIMESTAMPTZ,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "fx_quotes"."rate" IS 'locked rate scaled by 1e8';
+
+ALTER TABLE "fx_quotes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "fx_quotes" ("username");
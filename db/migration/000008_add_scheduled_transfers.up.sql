@@ -0,0 +1,38 @@
+CREATE TYPE "scheduled_transfer_status" AS ENUM (
+  'pending',
+  'processing',
+  'succeeded',
+  'failed',
+  'canceled'
+);
+
+CREATE TABLE "scheduled_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "execute_at" TIMESTAMPTZ NOT NULL,
+  "status" scheduled_transfer_status NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "failure_reason" varchar,
+  "executed_at" TIMESTAMPTZ,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "scheduled_transfers"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "scheduled_transfers"."transfer_id" IS 'the executed transfer, set once the status is succeeded';
+
+COMMENT ON COLUMN "scheduled_transfers"."failure_reason" IS 'why the transfer could not be executed, set once the status is failed';
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_amount_positive" CHECK ("amount" > 0);
+
+CREATE INDEX ON "scheduled_transfers" ("from_account_id");
+
+CREATE INDEX ON "scheduled_transfers" ("status", "execute_at");
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS "standing_order_executions";
+
+DROP TABLE IF EXISTS "standing_orders";
+
+DROP TYPE IF EXISTS "standing_order_execution_status";
+
+DROP TYPE IF EXISTS "standing_order_status";
+
+DROP TYPE IF EXISTS "insufficient_funds_policy";
+
+DROP TYPE IF EXISTS "standing_order_frequency";
@@ -0,0 +1,78 @@
+CREATE TYPE "standing_order_frequency" AS ENUM (
+  'weekly',
+  'monthly',
+  'last_business_day'
+);
+
+CREATE TYPE "insufficient_funds_policy" AS ENUM (
+  'skip',
+  'retry'
+);
+
+CREATE TYPE "standing_order_status" AS ENUM (
+  'active',
+  'completed',
+  'canceled'
+);
+
+CREATE TYPE "standing_order_execution_status" AS ENUM (
+  'succeeded',
+  'retrying',
+  'skipped'
+);
+
+CREATE TABLE "standing_orders" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "frequency" standing_order_frequency NOT NULL,
+  "day_of_month" int NOT NULL DEFAULT 0,
+  "insufficient_funds_policy" insufficient_funds_policy NOT NULL DEFAULT 'skip',
+  "max_retries" int NOT NULL DEFAULT 0,
+  "retry_count" int NOT NULL DEFAULT 0,
+  "occurrence_at" TIMESTAMPTZ NOT NULL,
+  "next_run_at" TIMESTAMPTZ NOT NULL,
+  "end_at" TIMESTAMPTZ,
+  "status" standing_order_status NOT NULL DEFAULT 'active',
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "standing_order_executions" (
+  "id" bigserial PRIMARY KEY,
+  "standing_order_id" bigint NOT NULL,
+  "occurrence_at" TIMESTAMPTZ NOT NULL,
+  "attempt" int NOT NULL,
+  "status" standing_order_execution_status NOT NULL,
+  "transfer_id" bigint,
+  "failure_reason" varchar,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "standing_orders"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "standing_orders"."day_of_month" IS 'day a monthly order runs on, the last day of shorter months';
+
+COMMENT ON COLUMN "standing_orders"."occurrence_at" IS 'the occurrence currently due';
+
+COMMENT ON COLUMN "standing_orders"."next_run_at" IS 'when the occurrence is next attempted, later than occurrence_at while retrying';
+
+COMMENT ON COLUMN "standing_order_executions"."transfer_id" IS 'the executed transfer, set once the status is succeeded';
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "standing_order_executions" ADD FOREIGN KEY ("standing_order_id") REFERENCES "standing_orders" ("id");
+
+ALTER TABLE "standing_order_executions" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "standing_orders" ADD CONSTRAINT "standing_amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "standing_orders" ADD CONSTRAINT "monthly_day_of_month" CHECK ("frequency" <> 'monthly' OR "day_of_month" BETWEEN 1 AND 31);
+
+CREATE INDEX ON "standing_orders" ("from_account_id");
+
+CREATE INDEX ON "standing_orders" ("status", "next_run_at");
+
+CREATE INDEX ON "standing_order_executions" ("standing_order_id");
@@ -0,0 +1,7 @@
+ALTER TABLE "transfers" ADD COLUMN "reversal_of" bigint;
+
+COMMENT ON COLUMN "transfers"."reversal_of" IS 'the transfer this one refunds, null for a regular transfer';
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("reversal_of") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfers" ("reversal_of");
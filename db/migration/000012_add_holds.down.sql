@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS "holds";
+
+DROP TYPE IF EXISTS "hold_status";
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "balance_within_overdraft";
+
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "balance_within_overdraft" CHECK ("balance" >= -"overdraft_limit");
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "held_amount_non_negative";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "available_balance";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "held_amount";
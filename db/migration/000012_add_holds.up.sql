@@ -0,0 +1,47 @@
+ALTER TABLE "accounts" ADD COLUMN "held_amount" BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD COLUMN "available_balance" BIGINT GENERATED ALWAYS AS ("balance" - "held_amount") STORED;
+
+COMMENT ON COLUMN "accounts"."held_amount" IS 'total of the active holds on the account';
+
+COMMENT ON COLUMN "accounts"."available_balance" IS 'balance less held_amount, what transfers and new holds can spend';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "held_amount_non_negative" CHECK ("held_amount" >= 0);
+
+ALTER TABLE "accounts" DROP CONSTRAINT "balance_within_overdraft";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_within_overdraft" CHECK ("balance" - "held_amount" >= -"overdraft_limit");
+
+CREATE TYPE "hold_status" AS ENUM (
+  'active',
+  'captured',
+  'voided',
+  'expired'
+);
+
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "status" hold_status NOT NULL DEFAULT 'active',
+  "transfer_id" bigint,
+  "expires_at" TIMESTAMPTZ NOT NULL,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "holds"."amount" IS 'reserved on the from account while the hold is active';
+
+COMMENT ON COLUMN "holds"."transfer_id" IS 'the transfer the hold was captured into, set once the status is captured';
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "holds" ADD CONSTRAINT "hold_amount_positive" CHECK ("amount" > 0);
+
+CREATE INDEX ON "holds" ("from_account_id");
+
+CREATE INDEX ON "holds" ("status", "expires_at");
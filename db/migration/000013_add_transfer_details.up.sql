@@ -0,0 +1,15 @@
+ALTER TABLE "transfers" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD COLUMN "reference" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "transfers" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN "transfers"."description" IS 'free text shown to both parties';
+
+COMMENT ON COLUMN "transfers"."reference" IS 'end-to-end reference chosen by the sender, empty when none was given';
+
+COMMENT ON COLUMN "transfers"."metadata" IS 'arbitrary JSON object attached by the sender';
+
+ALTER TABLE "transfers" ADD CONSTRAINT "metadata_is_object" CHECK (jsonb_typeof("metadata") = 'object');
+
+CREATE INDEX ON "transfers" ("reference") WHERE "reference" <> '';
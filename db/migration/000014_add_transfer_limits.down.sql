@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "account_limits";
+
+DROP TABLE IF EXISTS "transfer_limits";
+
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "tier";
@@ -0,0 +1,35 @@
+ALTER TABLE "users" ADD COLUMN "tier" varchar NOT NULL DEFAULT 'standard';
+
+COMMENT ON COLUMN "users"."tier" IS 'selects the transfer limits that apply to the accounts of the user';
+
+CREATE TABLE "transfer_limits" (
+  "tier" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "max_transfer" bigint,
+  "daily_limit" bigint,
+  "monthly_limit" bigint,
+  PRIMARY KEY ("tier", "currency")
+);
+
+COMMENT ON COLUMN "transfer_limits"."max_transfer" IS 'largest single transfer, null for no limit';
+
+COMMENT ON COLUMN "transfer_limits"."daily_limit" IS 'total outgoing per UTC day, null for no limit';
+
+COMMENT ON COLUMN "transfer_limits"."monthly_limit" IS 'total outgoing per UTC month, null for no limit';
+
+CREATE TABLE "account_limits" (
+  "account_id" bigint PRIMARY KEY,
+  "max_transfer" bigint,
+  "daily_limit" bigint,
+  "monthly_limit" bigint,
+  "updated_at" TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+COMMENT ON TABLE "account_limits" IS 'per account overrides of transfer_limits, a null column keeps the tier limit';
+
+ALTER TABLE "account_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+INSERT INTO "transfer_limits" ("tier", "currency", "max_transfer", "daily_limit", "monthly_limit")
+SELECT 'standard', currency, 500000, 1000000, 5000000 FROM unnest(ARRAY['USD', 'EUR', 'CAD', 'SGD']) AS currency
+UNION ALL
+SELECT 'premium', currency, 2500000, 5000000, 25000000 FROM unnest(ARRAY['USD', 'EUR', 'CAD', 'SGD']) AS currency;
@@ -0,0 +1,15 @@
+CREATE TABLE "balance_snapshots" (
+  "account_id" bigint NOT NULL,
+  "snapshot_at" TIMESTAMPTZ NOT NULL,
+  "balance" bigint NOT NULL,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "snapshot_at")
+);
+
+COMMENT ON COLUMN "balance_snapshots"."snapshot_at" IS 'start of a UTC day';
+
+COMMENT ON COLUMN "balance_snapshots"."balance" IS 'sum of the entries of the account created before snapshot_at';
+
+ALTER TABLE "balance_snapshots" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "entries" ("account_id", "created_at");
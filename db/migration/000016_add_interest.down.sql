@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS "interest_accruals";
+
+DROP TABLE IF EXISTS "system_accounts";
+
+-- fails once interest has been posted, the ledger history is not thrown away
+DELETE FROM "accounts" WHERE "owner" = 'interest_expense';
+
+DELETE FROM "users" WHERE "username" = 'interest_expense';
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "product_code";
+
+DROP TABLE IF EXISTS "products";
+
+DROP TYPE IF EXISTS "compounding";
@@ -0,0 +1,82 @@
+CREATE TYPE "compounding" AS ENUM (
+  'daily',
+  'monthly'
+);
+
+CREATE TABLE "products" (
+  "code" varchar PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "annual_interest_rate" bigint NOT NULL DEFAULT 0,
+  "compounding" compounding NOT NULL DEFAULT 'monthly',
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "products"."annual_interest_rate" IS 'scaled by 1e8, 2% is 2000000';
+
+COMMENT ON COLUMN "products"."compounding" IS 'daily compounds interest accrued but not yet posted, monthly only the posted interest';
+
+ALTER TABLE "products" ADD CONSTRAINT "annual_interest_rate_non_negative" CHECK ("annual_interest_rate" >= 0);
+
+INSERT INTO "products" ("code", "name", "annual_interest_rate", "compounding") VALUES
+  ('checking', 'Checking', 0, 'monthly'),
+  ('savings', 'Savings', 2000000, 'monthly');
+
+ALTER TABLE "accounts" ADD COLUMN "product_code" varchar NOT NULL DEFAULT 'checking';
+
+COMMENT ON COLUMN "accounts"."product_code" IS 'the product of the account, which sets the interest it earns';
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("product_code") REFERENCES "products" ("code");
+
+CREATE TABLE "interest_accruals" (
+  "account_id" bigint NOT NULL,
+  "accrual_date" date NOT NULL,
+  "principal" bigint NOT NULL,
+  "annual_interest_rate" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "remainder" bigint NOT NULL,
+  "transfer_id" bigint,
+  "posted_at" TIMESTAMPTZ,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "accrual_date")
+);
+
+COMMENT ON COLUMN "interest_accruals"."principal" IS 'the balance interest was earned on at the end of the UTC day';
+
+COMMENT ON COLUMN "interest_accruals"."amount" IS 'whole minor units of interest earned on the day';
+
+COMMENT ON COLUMN "interest_accruals"."remainder" IS 'fraction of a minor unit carried to the next day, in 1/(365 * 1e8) minor units';
+
+COMMENT ON COLUMN "interest_accruals"."transfer_id" IS 'the transfer the interest was posted with, null if the posted amount was zero';
+
+COMMENT ON COLUMN "interest_accruals"."posted_at" IS 'null until the interest is posted';
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "interest_accruals" ("account_id") WHERE "posted_at" IS NULL;
+
+CREATE TABLE "system_accounts" (
+  "purpose" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "account_id" bigint UNIQUE NOT NULL,
+  PRIMARY KEY ("purpose", "currency")
+);
+
+COMMENT ON TABLE "system_accounts" IS 'accounts owned by the bank that it books its own income and expenses against';
+
+ALTER TABLE "system_accounts" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+-- the interest expense accounts pay out all interest, so they run an unbounded overdraft and the system tier has no
+-- transfer limits. The user has no password and cannot log in
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email", "tier") VALUES
+  ('interest_expense', '', 'Simple Bank interest expense', 'interest_expense@system.simplebank', 'system');
+
+WITH "created" AS (
+  INSERT INTO "accounts" ("owner", "balance", "currency", "overdraft_limit")
+  SELECT 'interest_expense', 0, "currency", 9223372036854775807
+  FROM (VALUES ('USD'), ('EUR'), ('CAD'), ('SGD')) AS "currencies" ("currency")
+  RETURNING "id", "currency"
+)
+INSERT INTO "system_accounts" ("purpose", "currency", "account_id")
+SELECT 'interest_expense', "currency", "id" FROM "created";
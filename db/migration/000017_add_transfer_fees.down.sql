@@ -0,0 +1,10 @@
+DELETE FROM "system_accounts" WHERE "purpose" = 'fee_revenue';
+
+-- fails once fees have been charged, the ledger history is not thrown away
+DELETE FROM "accounts" WHERE "owner" = 'fee_revenue';
+
+DELETE FROM "users" WHERE "username" = 'fee_revenue';
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "fee_of";
+
+DROP TABLE IF EXISTS "fee_schedules";
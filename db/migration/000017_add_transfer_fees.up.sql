@@ -0,0 +1,42 @@
+CREATE TABLE "fee_schedules" (
+  "currency" varchar PRIMARY KEY,
+  "flat_fee" bigint NOT NULL DEFAULT 0,
+  "percentage" bigint NOT NULL DEFAULT 0,
+  "min_fee" bigint NOT NULL DEFAULT 0,
+  "max_fee" bigint,
+  "updated_at" TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+COMMENT ON TABLE "fee_schedules" IS 'fee charged to the sender of a transfer by the currency debited, no row charges nothing';
+
+COMMENT ON COLUMN "fee_schedules"."percentage" IS 'share of the amount scaled by 1e8, 0.5% is 500000';
+
+COMMENT ON COLUMN "fee_schedules"."min_fee" IS 'smallest fee after adding the flat fee and the percentage';
+
+COMMENT ON COLUMN "fee_schedules"."max_fee" IS 'largest fee, null for no cap';
+
+ALTER TABLE "fee_schedules" ADD CONSTRAINT "fees_non_negative" CHECK (
+  "flat_fee" >= 0 AND "percentage" >= 0 AND "min_fee" >= 0 AND ("max_fee" IS NULL OR "max_fee" >= "min_fee")
+);
+
+ALTER TABLE "transfers" ADD COLUMN "fee_of" bigint;
+
+COMMENT ON COLUMN "transfers"."fee_of" IS 'the transfer this one charges the fee for, null for a regular transfer';
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("fee_of") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfers" ("fee_of");
+
+-- fees are credited to a revenue account per currency owned by a system user that cannot log in, like the interest
+-- expense accounts
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email", "tier") VALUES
+  ('fee_revenue', '', 'Simple Bank fee revenue', 'fee_revenue@system.simplebank', 'system');
+
+WITH "created" AS (
+  INSERT INTO "accounts" ("owner", "balance", "currency")
+  SELECT 'fee_revenue', 0, "currency"
+  FROM (VALUES ('USD'), ('EUR'), ('CAD'), ('SGD')) AS "currencies" ("currency")
+  RETURNING "id", "currency"
+)
+INSERT INTO "system_accounts" ("purpose", "currency", "account_id")
+SELECT 'fee_revenue', "currency", "id" FROM "created";
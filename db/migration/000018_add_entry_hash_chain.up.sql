@@ -0,0 +1,55 @@
+ALTER TABLE "entries" ADD COLUMN "seq" bigint;
+
+ALTER TABLE "entries" ADD COLUMN "prev_hash" bytea;
+
+ALTER TABLE "entries" ADD COLUMN "hash" bytea;
+
+COMMENT ON COLUMN "entries"."seq" IS 'position of the entry in the hash chain of its account, starting at 1';
+
+COMMENT ON COLUMN "entries"."prev_hash" IS 'hash of the previous entry of the account, 32 zero bytes for the first';
+
+COMMENT ON COLUMN "entries"."hash" IS 'SHA-256 of prev_hash followed by seq, account_id, amount, transfer_id (0 when null) and created_at in unix microseconds, each as a big-endian int64';
+
+UPDATE "entries" SET "seq" = "numbered"."seq"
+FROM (
+  SELECT "id", row_number() OVER (PARTITION BY "account_id" ORDER BY "id") AS "seq" FROM "entries"
+) AS "numbered"
+WHERE "entries"."id" = "numbered"."id";
+
+-- chain the existing entries the same way db.EntryHash does, int8send writes a big-endian int64
+DO $$
+DECLARE
+  "entry" record;
+  "previous" bytea;
+  "chained_account" bigint;
+BEGIN
+  FOR "entry" IN SELECT * FROM "entries" ORDER BY "account_id", "seq" LOOP
+    IF "chained_account" IS DISTINCT FROM "entry"."account_id" THEN
+      "previous" := decode(repeat('00', 32), 'hex');
+      "chained_account" := "entry"."account_id";
+    END IF;
+    UPDATE "entries" SET
+      "prev_hash" = "previous",
+      "hash" = sha256(
+        "previous" ||
+        int8send("entry"."seq") ||
+        int8send("entry"."account_id") ||
+        int8send("entry"."amount") ||
+        int8send(COALESCE("entry"."transfer_id", 0)) ||
+        int8send(
+          EXTRACT(EPOCH FROM date_trunc('second', "entry"."created_at"))::bigint * 1000000 +
+          EXTRACT(MICROSECONDS FROM "entry"."created_at")::bigint % 1000000
+        )
+      )
+    WHERE "id" = "entry"."id"
+    RETURNING "hash" INTO "previous";
+  END LOOP;
+END $$;
+
+ALTER TABLE "entries" ALTER COLUMN "seq" SET NOT NULL;
+
+ALTER TABLE "entries" ALTER COLUMN "prev_hash" SET NOT NULL;
+
+ALTER TABLE "entries" ALTER COLUMN "hash" SET NOT NULL;
+
+CREATE UNIQUE INDEX ON "entries" ("account_id", "seq");
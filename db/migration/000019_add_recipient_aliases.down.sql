@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "aliases";
+
+DROP INDEX IF EXISTS "users_verified_email_key";
+
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "email_verified_at";
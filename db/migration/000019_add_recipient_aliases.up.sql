@@ -0,0 +1,20 @@
+ALTER TABLE "users" ADD COLUMN "email_verified_at" TIMESTAMPTZ;
+
+COMMENT ON COLUMN "users"."email_verified_at" IS 'null until the email is verified, only a verified email can be paid to';
+
+-- a verified email resolves to exactly one user whatever its case
+CREATE UNIQUE INDEX "users_verified_email_key" ON "users" (lower("email")) WHERE "email_verified_at" IS NOT NULL;
+
+CREATE TABLE "aliases" (
+  "alias" varchar PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+COMMENT ON TABLE "aliases" IS 'names a user chooses to be paid by, lowercase and never the same as a username';
+
+ALTER TABLE "aliases" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+ALTER TABLE "aliases" ADD CONSTRAINT "alias_format" CHECK ("alias" ~ '^[a-z0-9][a-z0-9_.-]{2,29}$');
+
+CREATE INDEX ON "aliases" ("username");
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS "pending_transfer_approvals";
+
+DROP TABLE IF EXISTS "pending_transfers";
+
+DROP TABLE IF EXISTS "account_approvers";
+
+DROP TABLE IF EXISTS "approval_policies";
+
+DROP TYPE IF EXISTS "pending_transfer_status";
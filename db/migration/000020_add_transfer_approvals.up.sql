@@ -0,0 +1,93 @@
+CREATE TYPE "pending_transfer_status" AS ENUM (
+  'pending',
+  'processing',
+  'executed',
+  'rejected',
+  'failed'
+);
+
+CREATE TABLE "approval_policies" (
+  "account_id" bigint PRIMARY KEY,
+  "threshold" bigint NOT NULL,
+  "required_approvals" int NOT NULL DEFAULT 1,
+  "updated_at" TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+COMMENT ON TABLE "approval_policies" IS 'accounts whose large transfers need the approval of a second user';
+
+COMMENT ON COLUMN "approval_policies"."threshold" IS 'transfers of more than this, in the account currency, wait for approval';
+
+ALTER TABLE "approval_policies" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "approval_policies" ADD CONSTRAINT "approval_policy_valid" CHECK ("threshold" >= 0 AND "required_approvals" >= 1);
+
+CREATE TABLE "account_approvers" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username")
+);
+
+COMMENT ON TABLE "account_approvers" IS 'users authorised to approve the pending transfers of an account';
+
+ALTER TABLE "account_approvers" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "account_approvers" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+CREATE INDEX ON "account_approvers" ("username");
+
+CREATE TABLE "pending_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "description" varchar NOT NULL DEFAULT '',
+  "reference" varchar NOT NULL DEFAULT '',
+  "metadata" jsonb NOT NULL DEFAULT '{}',
+  "initiated_by" varchar NOT NULL,
+  "required_approvals" int NOT NULL,
+  "approval_count" int NOT NULL DEFAULT 0,
+  "status" pending_transfer_status NOT NULL DEFAULT 'pending',
+  "rejected_by" varchar,
+  "transfer_id" bigint,
+  "failure_reason" varchar,
+  "decided_at" TIMESTAMPTZ,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "pending_transfers"."required_approvals" IS 'copied from the approval policy when the transfer is started';
+
+COMMENT ON COLUMN "pending_transfers"."transfer_id" IS 'the executed transfer, set once the status is executed';
+
+COMMENT ON COLUMN "pending_transfers"."failure_reason" IS 'why the approved transfer could not be executed, set once the status is failed';
+
+COMMENT ON COLUMN "pending_transfers"."decided_at" IS 'when the last required approval or the rejection came in';
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("initiated_by") REFERENCES "users" ("username");
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("rejected_by") REFERENCES "users" ("username");
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "pending_transfers" ADD CONSTRAINT "pending_amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "pending_transfers" ADD CONSTRAINT "pending_metadata_is_object" CHECK (jsonb_typeof("metadata") = 'object');
+
+CREATE INDEX ON "pending_transfers" ("from_account_id", "status");
+
+CREATE INDEX ON "pending_transfers" ("initiated_by", "status");
+
+CREATE TABLE "pending_transfer_approvals" (
+  "pending_transfer_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("pending_transfer_id", "username")
+);
+
+ALTER TABLE "pending_transfer_approvals" ADD FOREIGN KEY ("pending_transfer_id") REFERENCES "pending_transfers" ("id");
+
+ALTER TABLE "pending_transfer_approvals" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
@@ -0,0 +1,9 @@
+ALTER TABLE IF EXISTS "pending_transfers" DROP COLUMN IF EXISTS "reviewed_at";
+
+ALTER TABLE IF EXISTS "pending_transfers" DROP COLUMN IF EXISTS "reviewed_by";
+
+ALTER TABLE IF EXISTS "pending_transfers" DROP COLUMN IF EXISTS "risk_assessment_id";
+
+DROP TABLE IF EXISTS "risk_assessments";
+
+DROP TYPE IF EXISTS "risk_outcome";
@@ -0,0 +1,49 @@
+CREATE TYPE "risk_outcome" AS ENUM (
+  'allow',
+  'review',
+  'deny'
+);
+
+CREATE TABLE "risk_assessments" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "outcome" risk_outcome NOT NULL,
+  "rule" varchar NOT NULL DEFAULT '',
+  "reason_code" varchar NOT NULL DEFAULT '',
+  "reason" varchar NOT NULL DEFAULT '',
+  "decisions" jsonb NOT NULL DEFAULT '[]',
+  "created_at" TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+COMMENT ON TABLE "risk_assessments" IS 'every screening of a transfer by the risk engine, kept for analysis';
+
+COMMENT ON COLUMN "risk_assessments"."rule" IS 'the rule that decided the outcome, empty when every rule allowed the transfer';
+
+COMMENT ON COLUMN "risk_assessments"."decisions" IS 'the decision of each rule that ran';
+
+ALTER TABLE "risk_assessments" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "risk_assessments" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "risk_assessments" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+CREATE INDEX ON "risk_assessments" ("outcome", "created_at");
+
+CREATE INDEX ON "risk_assessments" ("from_account_id");
+
+ALTER TABLE "pending_transfers" ADD COLUMN "risk_assessment_id" bigint;
+
+ALTER TABLE "pending_transfers" ADD COLUMN "reviewed_by" varchar;
+
+ALTER TABLE "pending_transfers" ADD COLUMN "reviewed_at" TIMESTAMPTZ;
+
+COMMENT ON COLUMN "pending_transfers"."risk_assessment_id" IS 'set when the risk engine held the transfer for review, it cannot run until an admin reviews it';
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("risk_assessment_id") REFERENCES "risk_assessments" ("id");
+
+ALTER TABLE "pending_transfers" ADD FOREIGN KEY ("reviewed_by") REFERENCES "users" ("username");
+
+CREATE INDEX ON "pending_transfers" ("status") WHERE "risk_assessment_id" IS NOT NULL AND "reviewed_by" IS NULL;
@@ -0,0 +1,17 @@
+UPDATE "accounts" SET "product_code" = 'checking' WHERE "product_code" IN ('business', 'system');
+
+DELETE FROM "products" WHERE "code" IN ('business', 'system');
+
+ALTER TABLE IF EXISTS "products" DROP CONSTRAINT IF EXISTS "product_rules_valid";
+
+ALTER TABLE IF EXISTS "products" DROP COLUMN IF EXISTS "can_be_source";
+
+ALTER TABLE IF EXISTS "products" DROP COLUMN IF EXISTS "max_withdrawals_per_month";
+
+ALTER TABLE IF EXISTS "products" DROP COLUMN IF EXISTS "min_balance";
+
+ALTER TABLE IF EXISTS "products" DROP COLUMN IF EXISTS "allowed_currencies";
+
+ALTER TABLE IF EXISTS "products" DROP COLUMN IF EXISTS "account_type";
+
+DROP TYPE IF EXISTS "account_type";
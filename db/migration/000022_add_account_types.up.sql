@@ -0,0 +1,36 @@
+CREATE TYPE "account_type" AS ENUM (
+  'checking',
+  'savings',
+  'business',
+  'system'
+);
+
+ALTER TABLE "products" ADD COLUMN "account_type" account_type NOT NULL DEFAULT 'checking';
+
+ALTER TABLE "products" ADD COLUMN "allowed_currencies" varchar[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE "products" ADD COLUMN "min_balance" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "products" ADD COLUMN "max_withdrawals_per_month" int;
+
+ALTER TABLE "products" ADD COLUMN "can_be_source" boolean NOT NULL DEFAULT true;
+
+COMMENT ON COLUMN "products"."allowed_currencies" IS 'the currencies an account of the product can hold, empty allows any';
+
+COMMENT ON COLUMN "products"."min_balance" IS 'a transfer cannot take the available balance below it, zero leaves the floor to the overdraft limit';
+
+COMMENT ON COLUMN "products"."max_withdrawals_per_month" IS 'outgoing transfers allowed per UTC calendar month, null for no limit';
+
+COMMENT ON COLUMN "products"."can_be_source" IS 'false for products money can only be paid into';
+
+ALTER TABLE "products" ADD CONSTRAINT "product_rules_valid" CHECK ("min_balance" >= 0 AND "max_withdrawals_per_month" >= 0);
+
+UPDATE "products" SET "account_type" = 'savings', "max_withdrawals_per_month" = 6 WHERE "code" = 'savings';
+
+INSERT INTO "products" ("code", "name", "account_type") VALUES
+  ('business', 'Business', 'business'),
+  ('system', 'System', 'system');
+
+-- the accounts the bank books its own income and expenses against
+UPDATE "accounts" SET "product_code" = 'system'
+WHERE "owner" IN (SELECT "username" FROM "users" WHERE "tier" = 'system');
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS "owner_currency_key";
+
+-- fails once an owner has reopened an account in the currency of a closed one
+ALTER TABLE IF EXISTS "accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "status_changed_at";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "status";
+
+DROP TYPE IF EXISTS "account_status";
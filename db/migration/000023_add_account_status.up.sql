@@ -0,0 +1,18 @@
+CREATE TYPE "account_status" AS ENUM (
+  'active',
+  'frozen',
+  'closed'
+);
+
+ALTER TABLE "accounts" ADD COLUMN "status" account_status NOT NULL DEFAULT 'active';
+
+ALTER TABLE "accounts" ADD COLUMN "status_changed_at" TIMESTAMPTZ;
+
+COMMENT ON COLUMN "accounts"."status" IS 'only active accounts are debited or credited, a closed account is kept for its history';
+
+COMMENT ON COLUMN "accounts"."status_changed_at" IS 'null while the account has the status it was opened with';
+
+-- a closed account no longer takes up the currency of its owner, who may open a new one
+ALTER TABLE "accounts" DROP CONSTRAINT "owner_currency_key";
+
+CREATE UNIQUE INDEX "owner_currency_key" ON "accounts" ("owner", "currency") WHERE "status" <> 'closed';
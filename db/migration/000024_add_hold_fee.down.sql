@@ -0,0 +1,5 @@
+COMMENT ON COLUMN "accounts"."held_amount" IS 'total of the active holds on the account';
+
+ALTER TABLE IF EXISTS "holds" DROP CONSTRAINT IF EXISTS "hold_fee_non_negative";
+
+ALTER TABLE IF EXISTS "holds" DROP COLUMN IF EXISTS "fee";
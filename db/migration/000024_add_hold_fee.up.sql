@@ -0,0 +1,7 @@
+ALTER TABLE "holds" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "holds"."fee" IS 'fee on capturing the whole hold, reserved with the amount so the capture can always pay it';
+
+ALTER TABLE "holds" ADD CONSTRAINT "hold_fee_non_negative" CHECK ("fee" >= 0);
+
+COMMENT ON COLUMN "accounts"."held_amount" IS 'total of the active holds on the account, their reserved fees included';
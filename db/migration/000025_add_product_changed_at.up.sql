@@ -0,0 +1,3 @@
+ALTER TABLE "accounts" ADD COLUMN "product_changed_at" TIMESTAMPTZ;
+
+COMMENT ON COLUMN "accounts"."product_changed_at" IS 'when the account last moved to another product, null if it never did. Interest is not accrued for days before it';
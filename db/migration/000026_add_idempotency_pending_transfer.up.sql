@@ -0,0 +1,5 @@
+ALTER TABLE "idempotency_keys" ADD COLUMN "pending_transfer_id" bigint;
+
+COMMENT ON COLUMN "idempotency_keys"."pending_transfer_id" IS 'set when the request was held for approval, a retry returns the pending transfer instead of the response';
+
+ALTER TABLE "idempotency_keys" ADD FOREIGN KEY ("pending_transfer_id") REFERENCES "pending_transfers" ("id");
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "owner_currency_product_key";
+
+-- fails once an owner holds open accounts of two products in one currency
+CREATE UNIQUE INDEX "owner_currency_key" ON "accounts" ("owner", "currency") WHERE "status" <> 'closed';
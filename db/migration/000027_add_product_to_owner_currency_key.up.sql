@@ -0,0 +1,4 @@
+-- an owner may hold one open account per product in a currency, a checking and a savings account in USD for example
+DROP INDEX IF EXISTS "owner_currency_key";
+
+CREATE UNIQUE INDEX "owner_currency_product_key" ON "accounts" ("owner", "currency", "product_code") WHERE "status" <> 'closed';
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS "pending_transfers" DROP CONSTRAINT IF EXISTS "decided_by_other_than_initiator";
@@ -0,0 +1,4 @@
+-- the user who started a transfer can neither reject nor review it, approvals are guarded by the query adding them
+ALTER TABLE "pending_transfers" ADD CONSTRAINT "decided_by_other_than_initiator" CHECK (
+  "rejected_by" <> "initiated_by" AND "reviewed_by" <> "initiated_by"
+);
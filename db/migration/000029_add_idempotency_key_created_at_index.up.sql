@@ -0,0 +1,2 @@
+-- the retention sweep finds expired keys by age
+CREATE INDEX ON "idempotency_keys" ("created_at");
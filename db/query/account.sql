@@ -2,9 +2,10 @@
 INSERT INTO Accounts (
     owner,
     balance,
-    currency
+    currency,
+    product_code
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4
 ) RETURNING *;
 
 -- name: GetAccount :one
@@ -16,6 +17,13 @@ SELECT * FROM Accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE;
 
+-- name: GetOwnerAccount :one
+-- the first account the owner opened in the currency, when they hold several products in it
+SELECT * FROM Accounts
+WHERE owner = $1 AND currency = $2 AND status <> 'closed'
+ORDER BY id
+LIMIT 1;
+
 -- name: ListAccounts :many
 SELECT * FROM Accounts 
 WHERE owner = $1
@@ -35,6 +43,33 @@ SET balance = balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
+-- name: AddAccountHeldAmount :one
+UPDATE Accounts
+SET held_amount = held_amount + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
 -- name: DeleteAccount :exec
 DELETE FROM Accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: SetAccountOverdraftLimit :one
+UPDATE Accounts
+SET overdraft_limit = sqlc.arg(overdraft_limit)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: SetAccountProduct :one
+-- the change date only moves when the product does, setting the same product again changes nothing
+UPDATE Accounts
+SET product_code = sqlc.arg(product_code),
+    product_changed_at = CASE WHEN product_code = sqlc.arg(product_code) THEN product_changed_at ELSE now() END
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: TransitionAccountStatus :one
+-- moves the account to to_status only if it still has from_status
+UPDATE Accounts
+SET status = sqlc.arg(to_status), status_changed_at = now()
+WHERE id = sqlc.arg(id) AND status = sqlc.arg(from_status)
+RETURNING *;
@@ -0,0 +1,38 @@
+-- name: CountUserAliases :one
+SELECT COUNT(*) FROM aliases
+WHERE username = $1;
+
+-- name: CreateAlias :one
+INSERT INTO aliases (
+    alias,
+    username
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: DeleteAlias :execrows
+DELETE FROM aliases
+WHERE alias = $1 AND username = $2;
+
+-- name: GetAlias :one
+SELECT * FROM aliases
+WHERE alias = $1 LIMIT 1;
+
+-- name: ListUserAliases :many
+SELECT * FROM aliases
+WHERE username = $1
+ORDER BY alias;
+
+-- name: ResolveRecipient :one
+-- a username wins over a verified email and an email over an alias, aliases are kept distinct from usernames so
+-- only one of them can match in practice. System users are never a recipient
+SELECT username FROM (
+    SELECT username, 1 AS priority FROM users WHERE username = sqlc.arg(recipient) AND tier <> 'system'
+    UNION ALL
+    SELECT username, 2 AS priority FROM users
+    WHERE lower(email) = lower(sqlc.arg(recipient)) AND email_verified_at IS NOT NULL AND tier <> 'system'
+    UNION ALL
+    SELECT username, 3 AS priority FROM aliases WHERE alias = lower(sqlc.arg(recipient))
+) AS matches
+ORDER BY priority
+LIMIT 1;
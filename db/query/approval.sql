@@ -0,0 +1,44 @@
+-- name: GetApprovalPolicy :one
+SELECT * FROM approval_policies
+WHERE account_id = $1 LIMIT 1;
+
+-- name: UpsertApprovalPolicy :one
+INSERT INTO approval_policies (
+    account_id,
+    threshold,
+    required_approvals
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (account_id) DO UPDATE
+SET threshold = EXCLUDED.threshold,
+    required_approvals = EXCLUDED.required_approvals,
+    updated_at = now()
+RETURNING *;
+
+-- name: DeleteApprovalPolicy :execrows
+DELETE FROM approval_policies
+WHERE account_id = $1;
+
+-- name: AddAccountApprover :one
+INSERT INTO account_approvers (
+    account_id,
+    username
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: DeleteAccountApprovers :exec
+DELETE FROM account_approvers
+WHERE account_id = $1;
+
+-- name: ListAccountApprovers :many
+SELECT * FROM account_approvers
+WHERE account_id = $1
+ORDER BY username;
+
+-- name: IsAccountApprover :one
+SELECT EXISTS (
+    SELECT 1 FROM account_approvers
+    WHERE account_id = $1 AND username = $2
+);
@@ -0,0 +1,32 @@
+-- name: CreateBalanceSnapshots :execrows
+INSERT INTO balance_snapshots (
+    account_id,
+    snapshot_at,
+    balance
+)
+SELECT
+    a.id,
+    sqlc.arg(snapshot_at),
+    COALESCE(previous.balance, 0) + (
+        SELECT COALESCE(SUM(e.amount), 0)
+        FROM entries e
+        WHERE e.account_id = a.id
+        AND e.created_at >= COALESCE(previous.snapshot_at, '-infinity')
+        AND e.created_at < sqlc.arg(snapshot_at)
+    )
+FROM accounts a
+LEFT JOIN LATERAL (
+    SELECT s.snapshot_at, s.balance
+    FROM balance_snapshots s
+    WHERE s.account_id = a.id AND s.snapshot_at < sqlc.arg(snapshot_at)
+    ORDER BY s.snapshot_at DESC
+    LIMIT 1
+) previous ON true
+WHERE a.created_at < sqlc.arg(snapshot_at)
+ON CONFLICT (account_id, snapshot_at) DO NOTHING;
+
+-- name: GetLatestBalanceSnapshot :one
+SELECT * FROM balance_snapshots
+WHERE account_id = $1 AND snapshot_at <= $2
+ORDER BY snapshot_at DESC
+LIMIT 1;
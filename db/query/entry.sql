@@ -1,18 +1,74 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
     account_id,
-    amount
+    amount,
+    transfer_id,
+    created_at,
+    seq,
+    prev_hash,
+    hash
 ) VALUES (
-    $1, $2
+    $1, $2, $3, $4, $5, $6, $7
 ) RETURNING *;
 
 -- name: GetEntry :one
 SELECT * FROM entries
 WHERE id = $1 LIMIT 1;
 
+-- name: GetEntryChainHead :one
+SELECT * FROM entries
+WHERE account_id = $1
+ORDER BY seq DESC
+LIMIT 1;
+
 -- name: ListEntries :many
 SELECT * FROM entries
 WHERE account_id = $1
 ORDER BY id
 LIMIT $2
-OFFSET $3;
\ No newline at end of file
+OFFSET $3;
+
+-- name: ListEntryChain :many
+SELECT * FROM entries
+WHERE account_id = $1 AND seq > sqlc.arg(after_seq)
+ORDER BY seq
+LIMIT sqlc.arg(chunk_size);
+
+-- name: SumEntriesBefore :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS balance
+FROM entries
+WHERE account_id = $1 AND created_at < $2;
+
+-- name: SumOutgoingEntriesSince :one
+SELECT COALESCE(-SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = $1 AND amount < 0 AND created_at >= $2;
+
+-- name: ListStatementEntries :many
+SELECT
+    e.id,
+    e.account_id,
+    e.amount,
+    e.created_at,
+    e.transfer_id,
+    t.description,
+    t.reference,
+    COALESCE(t.metadata, '{}')::jsonb AS metadata,
+    c.id AS counterparty_account_id,
+    c.owner AS counterparty_owner,
+    c.currency AS counterparty_currency
+FROM entries e
+LEFT JOIN transfers t ON t.id = e.transfer_id
+LEFT JOIN accounts c ON c.id = CASE
+    WHEN t.from_account_id = e.account_id THEN t.to_account_id
+    ELSE t.from_account_id
+END
+WHERE e.account_id = sqlc.arg(account_id)
+AND e.created_at >= sqlc.arg(start_time)
+AND e.created_at < sqlc.arg(end_time)
+ORDER BY e.created_at, e.id;
+
+-- name: SumEntriesBetween :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS balance
+FROM entries
+WHERE account_id = $1 AND created_at >= sqlc.arg(start_time) AND created_at < sqlc.arg(end_time);
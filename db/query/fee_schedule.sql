@@ -0,0 +1,28 @@
+-- name: DeleteFeeSchedule :execrows
+DELETE FROM fee_schedules
+WHERE currency = $1;
+
+-- name: GetFeeSchedule :one
+SELECT * FROM fee_schedules
+WHERE currency = $1 LIMIT 1;
+
+-- name: ListFeeSchedules :many
+SELECT * FROM fee_schedules
+ORDER BY currency;
+
+-- name: UpsertFeeSchedule :one
+INSERT INTO fee_schedules (
+    currency,
+    flat_fee,
+    percentage,
+    min_fee,
+    max_fee
+) VALUES (
+    $1, $2, $3, $4, $5
+) ON CONFLICT (currency) DO UPDATE SET
+    flat_fee = EXCLUDED.flat_fee,
+    percentage = EXCLUDED.percentage,
+    min_fee = EXCLUDED.min_fee,
+    max_fee = EXCLUDED.max_fee,
+    updated_at = now()
+RETURNING *;
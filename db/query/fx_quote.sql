@@ -0,0 +1,27 @@
+-- name: CreateFxQuote :one
+INSERT INTO fx_quotes (
+    id,
+    username,
+    from_currency,
+    to_currency,
+    rate,
+    from_amount,
+    to_amount,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: GetFxQuote :one
+SELECT * FROM fx_quotes
+WHERE id = $1 LIMIT 1;
+
+-- name: GetFxQuoteForUpdate :one
+SELECT * FROM fx_quotes
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: MarkFxQuoteUsed :exec
+UPDATE fx_quotes
+SET used_at = now()
+WHERE id = $1;
@@ -0,0 +1,32 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+    from_account_id,
+    to_account_id,
+    amount,
+    expires_at,
+    fee
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetExpiredHoldForUpdate :one
+SELECT * FROM holds
+WHERE status = 'active' AND expires_at <= sqlc.arg(now)
+ORDER BY expires_at
+LIMIT 1
+FOR NO KEY UPDATE SKIP LOCKED;
+
+-- name: UpdateHoldStatus :one
+UPDATE holds
+SET status = $2, transfer_id = $3
+WHERE id = $1
+RETURNING *;
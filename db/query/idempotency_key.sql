@@ -0,0 +1,37 @@
+-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (
+    username,
+    key,
+    request_hash
+) VALUES (
+    $1, $2, $3
+) ON CONFLICT (username, key) DO NOTHING
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE username = $1 AND key = $2 LIMIT 1;
+
+-- name: UpdateIdempotencyKeyResponse :exec
+UPDATE idempotency_keys
+SET response = $3
+WHERE username = $1 AND key = $2;
+
+-- name: SetIdempotencyKeyPendingTransfer :exec
+UPDATE idempotency_keys
+SET pending_transfer_id = $3
+WHERE username = $1 AND key = $2;
+
+-- name: DeleteExpiredIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE username = $1 AND key = $2 AND created_at < $3;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+-- removes a batch of keys past their retention, oldest first
+DELETE FROM idempotency_keys
+WHERE (username, key) IN (
+    SELECT username, key FROM idempotency_keys
+    WHERE created_at < sqlc.arg(before)
+    ORDER BY created_at
+    LIMIT sqlc.arg(batch_size)
+);
@@ -0,0 +1,59 @@
+-- name: CreateInterestAccrual :one
+INSERT INTO interest_accruals (
+    account_id,
+    accrual_date,
+    principal,
+    annual_interest_rate,
+    amount,
+    remainder
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: GetAccountDueForAccrual :one
+-- an account that moved to another product starts accruing at the new rate on the day it moved
+SELECT
+    a.id AS account_id,
+    p.annual_interest_rate,
+    p.compounding,
+    GREATEST(
+        COALESCE(last.accrual_date + 1, (a.created_at AT TIME ZONE 'UTC')::date),
+        (a.product_changed_at AT TIME ZONE 'UTC')::date
+    )::date AS accrual_date,
+    COALESCE(last.remainder, 0)::bigint AS remainder
+FROM accounts a
+JOIN products p ON p.code = a.product_code
+LEFT JOIN LATERAL (
+    SELECT ia.accrual_date, ia.remainder
+    FROM interest_accruals ia
+    WHERE ia.account_id = a.id
+    ORDER BY ia.accrual_date DESC
+    LIMIT 1
+) last ON true
+WHERE p.annual_interest_rate > 0
+AND a.status <> 'closed'
+AND GREATEST(
+    COALESCE(last.accrual_date + 1, (a.created_at AT TIME ZONE 'UTC')::date),
+    (a.product_changed_at AT TIME ZONE 'UTC')::date
+) < sqlc.arg(before)::date
+ORDER BY a.id
+LIMIT 1
+FOR NO KEY UPDATE OF a SKIP LOCKED;
+
+-- name: GetAccountDueForInterestPosting :one
+-- interest on a frozen account waits until it is active again
+SELECT ia.account_id FROM interest_accruals ia
+JOIN accounts a ON a.id = ia.account_id
+WHERE ia.posted_at IS NULL AND ia.accrual_date < sqlc.arg(before)::date AND a.status = 'active'
+ORDER BY ia.account_id
+LIMIT 1;
+
+-- name: MarkInterestAccrualsPosted :execrows
+UPDATE interest_accruals
+SET posted_at = now(), transfer_id = sqlc.narg(transfer_id)
+WHERE account_id = sqlc.arg(account_id) AND posted_at IS NULL AND accrual_date < sqlc.arg(before)::date;
+
+-- name: SumUnpostedInterest :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM interest_accruals
+WHERE account_id = sqlc.arg(account_id) AND posted_at IS NULL AND accrual_date < sqlc.arg(before)::date;
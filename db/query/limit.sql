@@ -0,0 +1,42 @@
+-- name: GetAccountLimits :one
+SELECT
+    a.id AS account_id,
+    a.currency,
+    COALESCE(al.max_transfer, tl.max_transfer) AS max_transfer,
+    COALESCE(al.daily_limit, tl.daily_limit) AS daily_limit,
+    COALESCE(al.monthly_limit, tl.monthly_limit) AS monthly_limit
+FROM accounts a
+JOIN users u ON u.username = a.owner
+LEFT JOIN transfer_limits tl ON tl.tier = u.tier AND tl.currency = a.currency
+LEFT JOIN account_limits al ON al.account_id = a.id
+WHERE a.id = $1 LIMIT 1;
+
+-- name: ListOwnerAccountLimits :many
+SELECT
+    a.id AS account_id,
+    a.currency,
+    a.held_amount,
+    COALESCE(al.max_transfer, tl.max_transfer) AS max_transfer,
+    COALESCE(al.daily_limit, tl.daily_limit) AS daily_limit,
+    COALESCE(al.monthly_limit, tl.monthly_limit) AS monthly_limit
+FROM accounts a
+JOIN users u ON u.username = a.owner
+LEFT JOIN transfer_limits tl ON tl.tier = u.tier AND tl.currency = a.currency
+LEFT JOIN account_limits al ON al.account_id = a.id
+WHERE a.owner = $1
+ORDER BY a.id;
+
+-- name: UpsertAccountLimits :one
+INSERT INTO account_limits (
+    account_id,
+    max_transfer,
+    daily_limit,
+    monthly_limit
+) VALUES (
+    $1, $2, $3, $4
+) ON CONFLICT (account_id) DO UPDATE SET
+    max_transfer = EXCLUDED.max_transfer,
+    daily_limit = EXCLUDED.daily_limit,
+    monthly_limit = EXCLUDED.monthly_limit,
+    updated_at = now()
+RETURNING *;
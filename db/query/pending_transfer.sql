@@ -0,0 +1,93 @@
+-- name: CreatePendingTransfer :one
+INSERT INTO pending_transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    description,
+    reference,
+    metadata,
+    initiated_by,
+    required_approvals,
+    risk_assessment_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: GetPendingTransfer :one
+SELECT * FROM pending_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: GetPendingTransferForUpdate :one
+SELECT * FROM pending_transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListPendingTransfers :many
+-- the transfers waiting for a decision that the user started or may approve
+SELECT * FROM pending_transfers
+WHERE status = 'pending' AND (
+    initiated_by = sqlc.arg(username)
+    OR from_account_id IN (SELECT account_id FROM account_approvers WHERE username = sqlc.arg(username))
+)
+ORDER BY id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListPendingTransfersInReview :many
+-- the transfers held by the risk engine that no admin has reviewed yet
+SELECT * FROM pending_transfers
+WHERE status = 'pending' AND risk_assessment_id IS NOT NULL AND reviewed_by IS NULL
+ORDER BY id
+LIMIT $1
+OFFSET $2;
+
+-- name: AddPendingTransferApproval :execrows
+-- the initiator of a transfer never counts as one of its approvers
+INSERT INTO pending_transfer_approvals (
+    pending_transfer_id,
+    username
+)
+SELECT id, sqlc.arg(username)::varchar FROM pending_transfers
+WHERE id = sqlc.arg(pending_transfer_id) AND initiated_by <> sqlc.arg(username)::varchar
+ON CONFLICT DO NOTHING;
+
+-- name: IncrementPendingTransferApprovals :one
+UPDATE pending_transfers
+SET approval_count = approval_count + 1
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: MarkPendingTransferReviewed :one
+UPDATE pending_transfers
+SET reviewed_by = $2, reviewed_at = now()
+WHERE id = $1 AND status = 'pending' AND risk_assessment_id IS NOT NULL AND reviewed_by IS NULL
+RETURNING *;
+
+-- name: ClaimPendingTransfer :one
+UPDATE pending_transfers
+SET status = 'processing', decided_at = now()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: RejectPendingTransfer :one
+UPDATE pending_transfers
+SET status = 'rejected', rejected_by = $2, decided_at = now()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: CompletePendingTransfer :one
+UPDATE pending_transfers
+SET status = 'executed', transfer_id = $2
+WHERE id = $1 AND status = 'processing'
+RETURNING *;
+
+-- name: FailPendingTransfer :one
+UPDATE pending_transfers
+SET status = 'failed', failure_reason = $2
+WHERE id = $1 AND status = 'processing'
+RETURNING *;
+
+-- name: FailAccountPendingTransfers :exec
+UPDATE pending_transfers
+SET status = 'failed', failure_reason = $2, decided_at = now()
+WHERE (from_account_id = $1 OR to_account_id = $1) AND status = 'pending';
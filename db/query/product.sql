@@ -0,0 +1,31 @@
+-- name: GetProduct :one
+SELECT * FROM products
+WHERE code = $1 LIMIT 1;
+
+-- name: ListProducts :many
+SELECT * FROM products
+ORDER BY code;
+
+-- name: UpsertProduct :one
+INSERT INTO products (
+    code,
+    name,
+    account_type,
+    annual_interest_rate,
+    compounding,
+    allowed_currencies,
+    min_balance,
+    max_withdrawals_per_month,
+    can_be_source
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) ON CONFLICT (code) DO UPDATE SET
+    name = EXCLUDED.name,
+    account_type = EXCLUDED.account_type,
+    annual_interest_rate = EXCLUDED.annual_interest_rate,
+    compounding = EXCLUDED.compounding,
+    allowed_currencies = EXCLUDED.allowed_currencies,
+    min_balance = EXCLUDED.min_balance,
+    max_withdrawals_per_month = EXCLUDED.max_withdrawals_per_month,
+    can_be_source = EXCLUDED.can_be_source
+RETURNING *;
@@ -0,0 +1,15 @@
+-- name: CreateRate :one
+INSERT INTO rates (
+    base_currency,
+    quote_currency,
+    rate,
+    effective_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetEffectiveRate :one
+SELECT * FROM rates
+WHERE base_currency = $1 AND quote_currency = $2 AND effective_at <= $3
+ORDER BY effective_at DESC, id DESC
+LIMIT 1;
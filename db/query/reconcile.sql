@@ -0,0 +1,28 @@
+-- name: ReconcileAccounts :many
+SELECT
+    a.id,
+    a.balance,
+    COALESCE(SUM(e.amount), 0)::bigint AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+WHERE a.id > sqlc.arg(after_id)
+GROUP BY a.id
+ORDER BY a.id
+LIMIT sqlc.arg(chunk_size);
+
+-- name: ReconcileTransfers :many
+SELECT
+    t.id,
+    t.from_account_id,
+    t.to_account_id,
+    t.amount,
+    t.to_amount,
+    COUNT(e.id) AS entry_count,
+    COALESCE(SUM(e.amount) FILTER (WHERE e.account_id = t.from_account_id), 0)::bigint AS debited,
+    COALESCE(SUM(e.amount) FILTER (WHERE e.account_id = t.to_account_id), 0)::bigint AS credited
+FROM transfers t
+LEFT JOIN entries e ON e.transfer_id = t.id
+WHERE t.id > sqlc.arg(after_id)
+GROUP BY t.id
+ORDER BY t.id
+LIMIT sqlc.arg(chunk_size);
@@ -0,0 +1,38 @@
+-- name: CreateRiskAssessment :one
+INSERT INTO risk_assessments (
+    username,
+    from_account_id,
+    to_account_id,
+    amount,
+    outcome,
+    rule,
+    reason_code,
+    reason,
+    decisions
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: ListRiskAssessments :many
+-- newest first, a null outcome lists every assessment
+SELECT * FROM risk_assessments
+WHERE sqlc.narg(outcome)::risk_outcome IS NULL OR outcome = sqlc.narg(outcome)
+ORDER BY id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: CountOwnerTransfersSince :one
+-- transfers sent from any account of the owner, fees and refunds are left out
+SELECT COUNT(*) FROM transfers
+JOIN accounts ON accounts.id = transfers.from_account_id
+WHERE accounts.owner = sqlc.arg(owner)
+    AND transfers.created_at >= sqlc.arg(since)
+    AND transfers.fee_of IS NULL
+    AND transfers.reversal_of IS NULL;
+
+-- name: HasPaidAccount :one
+SELECT EXISTS (
+    SELECT 1 FROM transfers
+    JOIN accounts ON accounts.id = transfers.from_account_id
+    WHERE accounts.owner = sqlc.arg(owner) AND transfers.to_account_id = sqlc.arg(to_account_id)
+);
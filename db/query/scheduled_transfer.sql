@@ -0,0 +1,50 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+    from_account_id,
+    to_account_id,
+    amount,
+    execute_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: ListScheduledTransfers :many
+SELECT * FROM scheduled_transfers
+WHERE from_account_id = $1
+ORDER BY execute_at, id
+LIMIT $2
+OFFSET $3;
+
+-- name: CancelScheduledTransfer :one
+UPDATE scheduled_transfers
+SET status = 'canceled'
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: GetDueScheduledTransferForUpdate :one
+SELECT * FROM scheduled_transfers
+WHERE status = 'pending' AND execute_at <= sqlc.arg(now)
+ORDER BY execute_at
+LIMIT 1
+FOR NO KEY UPDATE SKIP LOCKED;
+
+-- name: CompleteScheduledTransfer :one
+UPDATE scheduled_transfers
+SET status = 'succeeded', transfer_id = $2, executed_at = now()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: FailScheduledTransfer :one
+UPDATE scheduled_transfers
+SET status = 'failed', failure_reason = $2, executed_at = now()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: CancelAccountScheduledTransfers :exec
+UPDATE scheduled_transfers
+SET status = 'canceled'
+WHERE (from_account_id = $1 OR to_account_id = $1) AND status = 'pending';
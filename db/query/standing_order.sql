@@ -0,0 +1,69 @@
+-- name: CreateStandingOrder :one
+INSERT INTO standing_orders (
+    from_account_id,
+    to_account_id,
+    amount,
+    frequency,
+    day_of_month,
+    insufficient_funds_policy,
+    max_retries,
+    occurrence_at,
+    next_run_at,
+    end_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING *;
+
+-- name: GetStandingOrder :one
+SELECT * FROM standing_orders
+WHERE id = $1 LIMIT 1;
+
+-- name: ListStandingOrders :many
+SELECT * FROM standing_orders
+WHERE from_account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: CancelStandingOrder :one
+UPDATE standing_orders
+SET status = 'canceled'
+WHERE id = $1 AND status = 'active'
+RETURNING *;
+
+-- name: GetDueStandingOrderForUpdate :one
+SELECT * FROM standing_orders
+WHERE status = 'active' AND next_run_at <= $1
+ORDER BY next_run_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: UpdateStandingOrderSchedule :one
+UPDATE standing_orders
+SET occurrence_at = $2, next_run_at = $3, retry_count = $4, status = $5
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateStandingOrderExecution :one
+INSERT INTO standing_order_executions (
+    standing_order_id,
+    occurrence_at,
+    attempt,
+    status,
+    transfer_id,
+    failure_reason
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ListStandingOrderExecutions :many
+SELECT * FROM standing_order_executions
+WHERE standing_order_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: CancelAccountStandingOrders :exec
+UPDATE standing_orders
+SET status = 'canceled'
+WHERE (from_account_id = $1 OR to_account_id = $1) AND status = 'active';
@@ -2,9 +2,16 @@
 INSERT INTO transfers (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    to_amount,
+    exchange_rate,
+    reversal_of,
+    description,
+    reference,
+    metadata,
+    fee_of
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
 ) RETURNING *;
 
 -- name: GetTransfer :one
@@ -12,9 +19,65 @@ SELECT *
 FROM transfers
 WHERE id = $1 LIMIT 1;
 
+-- name: GetTransferForUpdate :one
+SELECT *
+FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
 -- name: ListTransfers :many
 SELECT * FROM transfers
 WHERE from_account_id = $1 OR to_account_id = $2
 ORDER BY id
 LIMIT $3 
-OFFSET $4;
\ No newline at end of file
+OFFSET $4;
+
+-- name: ListAccountTransfers :many
+SELECT
+    t.id,
+    t.from_account_id,
+    t.to_account_id,
+    t.amount,
+    t.created_at,
+    t.to_amount,
+    t.exchange_rate,
+    t.reversal_of,
+    t.description,
+    t.reference,
+    t.metadata,
+    t.fee_of,
+    c.id AS counterparty_account_id,
+    c.owner AS counterparty_owner,
+    c.currency AS counterparty_currency
+FROM transfers t
+JOIN accounts c ON c.id = CASE
+    WHEN t.from_account_id = sqlc.arg(account_id) THEN t.to_account_id
+    ELSE t.from_account_id
+END
+WHERE (
+    (sqlc.arg(outgoing)::bool AND t.from_account_id = sqlc.arg(account_id))
+    OR (sqlc.arg(incoming)::bool AND t.to_account_id = sqlc.arg(account_id))
+)
+AND (sqlc.narg(start_time)::timestamptz IS NULL OR t.created_at >= sqlc.narg(start_time))
+AND (sqlc.narg(end_time)::timestamptz IS NULL OR t.created_at < sqlc.narg(end_time))
+AND (sqlc.narg(min_amount)::bigint IS NULL OR t.amount >= sqlc.narg(min_amount))
+AND (sqlc.narg(max_amount)::bigint IS NULL OR t.amount <= sqlc.narg(max_amount))
+AND (sqlc.narg(reference)::varchar IS NULL OR t.reference = sqlc.narg(reference))
+ORDER BY t.id DESC
+LIMIT sqlc.arg(page_limit)
+OFFSET sqlc.arg(page_offset);
+
+-- name: SumTransferReversals :one
+SELECT
+    COALESCE(SUM(amount), 0)::bigint AS debited,
+    COALESCE(SUM(COALESCE(to_amount, amount)), 0)::bigint AS refunded
+FROM transfers
+WHERE reversal_of = $1;
+
+-- name: CountAccountWithdrawalsSince :one
+-- fees and refunds are not withdrawals the account holder made
+SELECT COUNT(*) FROM transfers
+WHERE from_account_id = sqlc.arg(account_id)
+    AND created_at >= sqlc.arg(since)
+    AND fee_of IS NULL
+    AND reversal_of IS NULL;
@@ -13,7 +13,7 @@ const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE Accounts
 SET balance = balance + $1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, held_amount, available_balance, product_code, status, status_changed_at, product_changed_at
 `
 
 type AddAccountBalanceParams struct {
@@ -30,6 +30,45 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+		&i.ProductCode,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ProductChangedAt,
+	)
+	return i, err
+}
+
+const addAccountHeldAmount = `-- name: AddAccountHeldAmount :one
+UPDATE Accounts
+SET held_amount = held_amount + $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, held_amount, available_balance, product_code, status, status_changed_at, product_changed_at
+`
+
+type AddAccountHeldAmountParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+func (q *Queries) AddAccountHeldAmount(ctx context.Context, arg AddAccountHeldAmountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, addAccountHeldAmount, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+		&i.ProductCode,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ProductChangedAt,
 	)
 	return i, err
 }
@@ -38,20 +77,27 @@ const createAccount = `-- name: CreateAccount :one
 INSERT INTO Accounts (
     owner,
     balance,
-    currency
+    currency,
+    product_code
 ) VALUES (
-    $1, $2, $3
-) RETURNING id, owner, balance, currency, created_at
+    $1, $2, $3, $4
+) RETURNING id, owner, balance, currency, created_at, overdraft_limit, held_amount, available_balance, product_code, status, status_changed_at, product_changed_at
 `
 
 type CreateAccountParams struct {
-	Owner    string `json:"owner"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
+	Owner       string `json:"owner"`
+	Balance     int64  `json:"balance"`
+	Currency    string `json:"currency"`
+	ProductCode string `json:"product_code"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.db.QueryRowContext(ctx, createAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.ProductCode,
+	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -59,6 +105,13 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+		&i.ProductCode,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ProductChangedAt,
 	)
 	return i, err
 }
@@ -74,7 +127,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM Accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, held_amount, available_balance, product_code, status, status_changed_at, product_changed_at FROM Accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -87,12 +140,19 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+		&i.ProductCode,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ProductChangedAt,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM Accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, held_amount, available_balance, product_code, status, status_changed_at, product_changed_at FROM Accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -106,12 +166,52 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+		&i.ProductCode,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ProductChangedAt,
+	)
+	return i, err
+}
+
+const getOwnerAccount = `-- name: GetOwnerAccount :one
+SELECT id, owner, balance, currency, created_at, overdraft_limit, held_amount, available_balance, product_code, status, status_changed_at, product_changed_at FROM Accounts
+WHERE owner = $1 AND currency = $2 AND status <> 'closed'
+ORDER BY id
+LIMIT 1
+`
+
+type GetOwnerAccountParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+// the first account the owner opened in the currency, when they hold several products in it
+func (q *Queries) GetOwnerAccount(ctx context.Context, arg GetOwnerAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getOwnerAccount, arg.Owner, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+		&i.ProductCode,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ProductChangedAt,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM Accounts 
+SELECT id, owner, balance, currency, created_at, overdraft_limit, held_amount, available_balance, product_code, status, status_changed_at, product_changed_at FROM Accounts 
 WHERE owner = $1
 ORDER BY id 
 LIMIT $2
@@ -139,6 +239,13 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.HeldAmount,
+			&i.AvailableBalance,
+			&i.ProductCode,
+			&i.Status,
+			&i.StatusChangedAt,
+			&i.ProductChangedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -153,11 +260,111 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
+const setAccountOverdraftLimit = `-- name: SetAccountOverdraftLimit :one
+UPDATE Accounts
+SET overdraft_limit = $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, held_amount, available_balance, product_code, status, status_changed_at, product_changed_at
+`
+
+type SetAccountOverdraftLimitParams struct {
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	ID             int64 `json:"id"`
+}
+
+func (q *Queries) SetAccountOverdraftLimit(ctx context.Context, arg SetAccountOverdraftLimitParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, setAccountOverdraftLimit, arg.OverdraftLimit, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+		&i.ProductCode,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ProductChangedAt,
+	)
+	return i, err
+}
+
+const setAccountProduct = `-- name: SetAccountProduct :one
+UPDATE Accounts
+SET product_code = $2,
+    product_changed_at = CASE WHEN product_code = $2 THEN product_changed_at ELSE now() END
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, held_amount, available_balance, product_code, status, status_changed_at, product_changed_at
+`
+
+type SetAccountProductParams struct {
+	ID          int64  `json:"id"`
+	ProductCode string `json:"product_code"`
+}
+
+// the change date only moves when the product does, setting the same product again changes nothing
+func (q *Queries) SetAccountProduct(ctx context.Context, arg SetAccountProductParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, setAccountProduct, arg.ID, arg.ProductCode)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+		&i.ProductCode,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ProductChangedAt,
+	)
+	return i, err
+}
+
+const transitionAccountStatus = `-- name: TransitionAccountStatus :one
+UPDATE Accounts
+SET status = $1, status_changed_at = now()
+WHERE id = $2 AND status = $3
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, held_amount, available_balance, product_code, status, status_changed_at, product_changed_at
+`
+
+type TransitionAccountStatusParams struct {
+	ToStatus   AccountStatus `json:"to_status"`
+	ID         int64         `json:"id"`
+	FromStatus AccountStatus `json:"from_status"`
+}
+
+// moves the account to to_status only if it still has from_status
+func (q *Queries) TransitionAccountStatus(ctx context.Context, arg TransitionAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, transitionAccountStatus, arg.ToStatus, arg.ID, arg.FromStatus)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+		&i.ProductCode,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ProductChangedAt,
+	)
+	return i, err
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE Accounts
 SET balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, held_amount, available_balance, product_code, status, status_changed_at, product_changed_at
 `
 
 type UpdateAccountParams struct {
@@ -174,6 +381,13 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.HeldAmount,
+		&i.AvailableBalance,
+		&i.ProductCode,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.ProductChangedAt,
 	)
 	return i, err
 }
@@ -14,9 +14,10 @@ import (
 func createRandomAccount(t *testing.T) Account {
 	owner := createRandomUser(t)
 	arg := CreateAccountParams{
-		Owner:    owner.Username,
-		Balance:  utils.GenerateRandomMoney(),
-		Currency: utils.GenerateRandomCurrency(),
+		Owner:       owner.Username,
+		Balance:     utils.GenerateRandomMoney(),
+		Currency:    utils.GenerateRandomCurrency(),
+		ProductCode: DefaultProductCode,
 	}
 
 	account, err := testQueries.CreateAccount(context.Background(), arg)
@@ -26,6 +27,7 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, AccountStatusActive, account.Status)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
@@ -36,6 +38,41 @@ func TestCreateAccount(t *testing.T) {
 	createRandomAccount(t)
 }
 
+func TestCreateAccountPerProduct(t *testing.T) {
+	owner := createRandomUser(t)
+	checking, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:       owner.Username,
+		Currency:    utils.USD,
+		ProductCode: DefaultProductCode,
+	})
+	require.NoError(t, err)
+
+	// a savings account in the same currency sits next to the checking one
+	savings, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:       owner.Username,
+		Currency:    utils.USD,
+		ProductCode: "savings",
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, checking.ID, savings.ID)
+
+	// but not a second checking account
+	_, err = testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:       owner.Username,
+		Currency:    utils.USD,
+		ProductCode: DefaultProductCode,
+	})
+	require.Error(t, err)
+
+	// payments to the owner go to the account opened first
+	account, err := testQueries.GetOwnerAccount(context.Background(), GetOwnerAccountParams{
+		Owner:    owner.Username,
+		Currency: utils.USD,
+	})
+	require.NoError(t, err)
+	require.Equal(t, checking.ID, account.ID)
+}
+
 func TestGetAccount(t *testing.T) {
 	account := createRandomAccount(t)
 	require.NotEmpty(t, account)
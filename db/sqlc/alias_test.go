@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAlias(t *testing.T, username string) Alias {
+	arg := CreateAliasParams{
+		Alias:    "a." + utils.GenerateRandomString(10),
+		Username: username,
+	}
+	alias, err := testQueries.CreateAlias(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Alias, alias.Alias)
+	require.Equal(t, arg.Username, alias.Username)
+	require.NotZero(t, alias.CreatedAt)
+	return alias
+}
+
+func TestCreateAlias(t *testing.T) {
+	user := createRandomUser(t)
+	alias := createRandomAlias(t, user.Username)
+
+	// aliases are unique across users
+	other := createRandomUser(t)
+	_, err := testQueries.CreateAlias(context.Background(), CreateAliasParams{Alias: alias.Alias, Username: other.Username})
+	require.Error(t, err)
+
+	// and must be lowercase
+	_, err = testQueries.CreateAlias(context.Background(), CreateAliasParams{Alias: "Upper.Case", Username: user.Username})
+	require.Error(t, err)
+}
+
+func TestDeleteAlias(t *testing.T) {
+	user := createRandomUser(t)
+	alias := createRandomAlias(t, user.Username)
+
+	// only the owner can delete an alias
+	other := createRandomUser(t)
+	deleted, err := testQueries.DeleteAlias(context.Background(), DeleteAliasParams{Alias: alias.Alias, Username: other.Username})
+	require.NoError(t, err)
+	require.Zero(t, deleted)
+
+	deleted, err = testQueries.DeleteAlias(context.Background(), DeleteAliasParams{Alias: alias.Alias, Username: user.Username})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	count, err := testQueries.CountUserAliases(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
+
+func TestResolveRecipient(t *testing.T) {
+	user := createRandomUser(t)
+	alias := createRandomAlias(t, user.Username)
+
+	username, err := testQueries.ResolveRecipient(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, username)
+
+	// aliases match in any case
+	username, err = testQueries.ResolveRecipient(context.Background(), strings.ToUpper(alias.Alias))
+	require.NoError(t, err)
+	require.Equal(t, user.Username, username)
+
+	// an email only resolves once it is verified
+	_, err = testQueries.ResolveRecipient(context.Background(), user.Email)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	verified, err := testQueries.VerifyUserEmail(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.True(t, verified.EmailVerifiedAt.Valid)
+
+	username, err = testQueries.ResolveRecipient(context.Background(), strings.ToUpper(user.Email))
+	require.NoError(t, err)
+	require.Equal(t, user.Username, username)
+
+	// system users cannot be paid by name
+	_, err = testQueries.ResolveRecipient(context.Background(), "fee_revenue")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// BalanceAt returns the balance of the account at asOf, the sum of its entries created before that instant. Only the
+// entries since the latest daily snapshot at or before asOf are replayed
+func BalanceAt(ctx context.Context, q Querier, accountID int64, asOf time.Time) (int64, error) {
+	snapshot, err := q.GetLatestBalanceSnapshot(ctx, GetLatestBalanceSnapshotParams{
+		AccountID:  accountID,
+		SnapshotAt: asOf,
+	})
+	if err == sql.ErrNoRows {
+		return q.SumEntriesBefore(ctx, SumEntriesBeforeParams{
+			AccountID: accountID,
+			CreatedAt: asOf,
+		})
+	}
+	if err != nil {
+		return 0, err
+	}
+	replayed, err := q.SumEntriesBetween(ctx, SumEntriesBetweenParams{
+		AccountID: accountID,
+		StartTime: snapshot.SnapshotAt,
+		EndTime:   asOf,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return snapshot.Balance + replayed, nil
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceAt(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+	transfer := func(amount int64) time.Time {
+		result, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountId: fromAccount.ID,
+			ToAccountId:   toAccount.ID,
+			Amount:        amount,
+		})
+		require.NoError(t, err)
+		return result.FromEntry.CreatedAt
+	}
+
+	first := transfer(10)
+	// without a snapshot the whole history is replayed
+	balance, err := BalanceAt(context.Background(), store, fromAccount.ID, first)
+	require.NoError(t, err)
+	require.Zero(t, balance)
+	balance, err = BalanceAt(context.Background(), store, fromAccount.ID, first.Add(time.Microsecond))
+	require.NoError(t, err)
+	require.Equal(t, int64(-10), balance)
+
+	snapshotAt := time.Now().Truncate(time.Microsecond)
+	count, err := store.CreateBalanceSnapshots(context.Background(), snapshotAt)
+	require.NoError(t, err)
+	require.Positive(t, count)
+
+	snapshot, err := store.GetLatestBalanceSnapshot(context.Background(), GetLatestBalanceSnapshotParams{
+		AccountID:  fromAccount.ID,
+		SnapshotAt: snapshotAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-10), snapshot.Balance)
+
+	// the next snapshot builds on the previous one
+	second := transfer(25)
+	balance, err = BalanceAt(context.Background(), store, fromAccount.ID, second.Add(time.Microsecond))
+	require.NoError(t, err)
+	require.Equal(t, int64(-35), balance)
+
+	count, err = store.CreateBalanceSnapshots(context.Background(), second.Add(time.Microsecond))
+	require.NoError(t, err)
+	require.Positive(t, count)
+	snapshot, err = store.GetLatestBalanceSnapshot(context.Background(), GetLatestBalanceSnapshotParams{
+		AccountID:  toAccount.ID,
+		SnapshotAt: second.Add(time.Microsecond),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(35), snapshot.Balance)
+
+	// taking a snapshot again changes nothing
+	count, err = store.CreateBalanceSnapshots(context.Background(), snapshotAt)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
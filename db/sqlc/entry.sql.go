@@ -7,36 +7,61 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
     account_id,
-    amount
+    amount,
+    transfer_id,
+    created_at,
+    seq,
+    prev_hash,
+    hash
 ) VALUES (
-    $1, $2
-) RETURNING id, account_id, amount, created_at
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, account_id, amount, created_at, transfer_id, seq, prev_hash, hash
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID  int64         `json:"account_id"`
+	Amount     int64         `json:"amount"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	CreatedAt  time.Time     `json:"created_at"`
+	Seq        int64         `json:"seq"`
+	PrevHash   []byte        `json:"prev_hash"`
+	Hash       []byte        `json:"hash"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.TransferID,
+		arg.CreatedAt,
+		arg.Seq,
+		arg.PrevHash,
+		arg.Hash,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.Seq,
+		&i.PrevHash,
+		&i.Hash,
 	)
 	return i, err
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id, seq, prev_hash, hash FROM entries
 WHERE id = $1 LIMIT 1
 `
 
@@ -48,12 +73,39 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.Seq,
+		&i.PrevHash,
+		&i.Hash,
+	)
+	return i, err
+}
+
+const getEntryChainHead = `-- name: GetEntryChainHead :one
+SELECT id, account_id, amount, created_at, transfer_id, seq, prev_hash, hash FROM entries
+WHERE account_id = $1
+ORDER BY seq DESC
+LIMIT 1
+`
+
+func (q *Queries) GetEntryChainHead(ctx context.Context, accountID int64) (Entry, error) {
+	row := q.db.QueryRowContext(ctx, getEntryChainHead, accountID)
+	var i Entry
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.TransferID,
+		&i.Seq,
+		&i.PrevHash,
+		&i.Hash,
 	)
 	return i, err
 }
 
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id, seq, prev_hash, hash FROM entries
 WHERE account_id = $1
 ORDER BY id
 LIMIT $2
@@ -80,6 +132,135 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.Seq,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEntryChain = `-- name: ListEntryChain :many
+SELECT id, account_id, amount, created_at, transfer_id, seq, prev_hash, hash FROM entries
+WHERE account_id = $1 AND seq > $2
+ORDER BY seq
+LIMIT $3
+`
+
+type ListEntryChainParams struct {
+	AccountID int64 `json:"account_id"`
+	AfterSeq  int64 `json:"after_seq"`
+	ChunkSize int32 `json:"chunk_size"`
+}
+
+func (q *Queries) ListEntryChain(ctx context.Context, arg ListEntryChainParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listEntryChain, arg.AccountID, arg.AfterSeq, arg.ChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.Seq,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatementEntries = `-- name: ListStatementEntries :many
+SELECT
+    e.id,
+    e.account_id,
+    e.amount,
+    e.created_at,
+    e.transfer_id,
+    t.description,
+    t.reference,
+    COALESCE(t.metadata, '{}')::jsonb AS metadata,
+    c.id AS counterparty_account_id,
+    c.owner AS counterparty_owner,
+    c.currency AS counterparty_currency
+FROM entries e
+LEFT JOIN transfers t ON t.id = e.transfer_id
+LEFT JOIN accounts c ON c.id = CASE
+    WHEN t.from_account_id = e.account_id THEN t.to_account_id
+    ELSE t.from_account_id
+END
+WHERE e.account_id = $1
+AND e.created_at >= $2
+AND e.created_at < $3
+ORDER BY e.created_at, e.id
+`
+
+type ListStatementEntriesParams struct {
+	AccountID int64     `json:"account_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type ListStatementEntriesRow struct {
+	ID                    int64           `json:"id"`
+	AccountID             int64           `json:"account_id"`
+	Amount                int64           `json:"amount"`
+	CreatedAt             time.Time       `json:"created_at"`
+	TransferID            sql.NullInt64   `json:"transfer_id"`
+	Description           sql.NullString  `json:"description"`
+	Reference             sql.NullString  `json:"reference"`
+	Metadata              json.RawMessage `json:"metadata"`
+	CounterpartyAccountID sql.NullInt64   `json:"counterparty_account_id"`
+	CounterpartyOwner     sql.NullString  `json:"counterparty_owner"`
+	CounterpartyCurrency  sql.NullString  `json:"counterparty_currency"`
+}
+
+func (q *Queries) ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStatementEntries, arg.AccountID, arg.StartTime, arg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListStatementEntriesRow{}
+	for rows.Next() {
+		var i ListStatementEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+			&i.CounterpartyAccountID,
+			&i.CounterpartyOwner,
+			&i.CounterpartyCurrency,
 		); err != nil {
 			return nil, err
 		}
@@ -93,3 +274,58 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 	}
 	return items, nil
 }
+
+const sumEntriesBefore = `-- name: SumEntriesBefore :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS balance
+FROM entries
+WHERE account_id = $1 AND created_at < $2
+`
+
+type SumEntriesBeforeParams struct {
+	AccountID int64     `json:"account_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) SumEntriesBefore(ctx context.Context, arg SumEntriesBeforeParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumEntriesBefore, arg.AccountID, arg.CreatedAt)
+	var balance int64
+	err := row.Scan(&balance)
+	return balance, err
+}
+
+const sumEntriesBetween = `-- name: SumEntriesBetween :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS balance
+FROM entries
+WHERE account_id = $1 AND created_at >= $2 AND created_at < $3
+`
+
+type SumEntriesBetweenParams struct {
+	AccountID int64     `json:"account_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+func (q *Queries) SumEntriesBetween(ctx context.Context, arg SumEntriesBetweenParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumEntriesBetween, arg.AccountID, arg.StartTime, arg.EndTime)
+	var balance int64
+	err := row.Scan(&balance)
+	return balance, err
+}
+
+const sumOutgoingEntriesSince = `-- name: SumOutgoingEntriesSince :one
+SELECT COALESCE(-SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = $1 AND amount < 0 AND created_at >= $2
+`
+
+type SumOutgoingEntriesSinceParams struct {
+	AccountID int64     `json:"account_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) SumOutgoingEntriesSince(ctx context.Context, arg SumOutgoingEntriesSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumOutgoingEntriesSince, arg.AccountID, arg.CreatedAt)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
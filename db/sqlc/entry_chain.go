@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// GenesisHash returns the previous hash of the first entry of every account, 32 zero bytes
+func GenesisHash() []byte {
+	return make([]byte, sha256.Size)
+}
+
+// EntryHash returns the hash chaining the entry to the previous entry of its account: SHA-256 of the previous hash
+// followed by the sequence number, account id, amount, transfer id (0 without a transfer) and created_at in unix
+// microseconds, each written as a big-endian int64. The id and hash of the entry are not part of it. The migration that
+// chained the existing entries computes the same hash in SQL, so the two must change together
+func EntryHash(entry Entry) []byte {
+	hash := sha256.New()
+	hash.Write(entry.PrevHash)
+	var field [8]byte
+	for _, value := range []int64{
+		entry.Seq,
+		entry.AccountID,
+		entry.Amount,
+		entry.TransferID.Int64,
+		entry.CreatedAt.UnixMicro(),
+	} {
+		binary.BigEndian.PutUint64(field[:], uint64(value))
+		hash.Write(field[:])
+	}
+	return hash.Sum(nil)
+}
+
+// appendEntry adds an entry at the head of the hash chain of its account. The account must be locked, otherwise two
+// transactions could both extend the same head and one would fail on the unique sequence number
+func appendEntry(ctx context.Context, q *Queries, accountID int64, amount int64, transferID int64, createdAt time.Time) (Entry, error) {
+	entry := Entry{
+		AccountID:  accountID,
+		Amount:     amount,
+		TransferID: sql.NullInt64{Int64: transferID, Valid: transferID > 0},
+		// postgres keeps microseconds, the hash must be of the time that is stored
+		CreatedAt: createdAt.Truncate(time.Microsecond),
+		Seq:       1,
+		PrevHash:  GenesisHash(),
+	}
+	head, err := q.GetEntryChainHead(ctx, accountID)
+	switch {
+	case err == nil:
+		entry.Seq = head.Seq + 1
+		entry.PrevHash = head.Hash
+	case !errors.Is(err, sql.ErrNoRows):
+		return entry, err
+	}
+	return q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  entry.AccountID,
+		Amount:     entry.Amount,
+		TransferID: entry.TransferID,
+		CreatedAt:  entry.CreatedAt,
+		Seq:        entry.Seq,
+		PrevHash:   entry.PrevHash,
+		Hash:       EntryHash(entry),
+	})
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntryHash(t *testing.T) {
+	entry := Entry{
+		ID:         99,
+		AccountID:  7,
+		Amount:     -250,
+		CreatedAt:  time.Unix(1_700_000_000, 123_456_000),
+		TransferID: sql.NullInt64{Int64: 3, Valid: true},
+		Seq:        2,
+		PrevHash:   GenesisHash(),
+	}
+	// the layout the migration reproduces with int8send: the previous hash, then seq, account_id, amount,
+	// transfer_id and created_at in unix microseconds as big-endian int64s
+	layout, err := hex.DecodeString(
+		"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000002" +
+			"0000000000000007" +
+			"ffffffffffffff06" +
+			"0000000000000003" +
+			"00060a2418202240")
+	require.NoError(t, err)
+	expected := sha256.Sum256(layout)
+	require.Equal(t, expected[:], EntryHash(entry))
+
+	// the id and the stored hash are not hashed
+	entry.ID = 100
+	entry.Hash = []byte("anything")
+	require.Equal(t, expected[:], EntryHash(entry))
+
+	// a missing transfer hashes as zero
+	entry.TransferID = sql.NullInt64{}
+	require.NotEqual(t, expected[:], EntryHash(entry))
+}
+
+func TestTransferTxChainsEntries(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+
+	var previous []byte
+	for i := int64(1); i <= 3; i++ {
+		result, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountId: fromAccount.ID,
+			ToAccountId:   toAccount.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+
+		entry := result.FromEntry
+		require.Equal(t, i, entry.Seq)
+		if i == 1 {
+			require.Equal(t, GenesisHash(), entry.PrevHash)
+		} else {
+			require.Equal(t, previous, entry.PrevHash)
+		}
+		require.Equal(t, EntryHash(entry), entry.Hash)
+		previous = entry.Hash
+
+		stored, err := testQueries.GetEntry(context.Background(), entry.ID)
+		require.NoError(t, err)
+		require.Equal(t, entry.Hash, EntryHash(stored))
+	}
+
+	head, err := testQueries.GetEntryChainHead(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), head.Seq)
+	require.Equal(t, previous, head.Hash)
+
+	chain, err := testQueries.ListEntryChain(context.Background(), ListEntryChainParams{
+		AccountID: fromAccount.ID,
+		AfterSeq:  1,
+		ChunkSize: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, chain, 2)
+	require.Equal(t, int64(2), chain[0].Seq)
+}
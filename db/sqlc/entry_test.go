@@ -11,17 +11,14 @@ import (
 )
 
 func createRandomEntry(t *testing.T, account Account) Entry {
-	arg := CreateEntryParams{
-		AccountID: account.ID,
-		Amount:    utils.GenerateRandomMoney(),
-	}
-
-	entry, err := testQueries.CreateEntry(context.Background(), arg)
+	amount := utils.GenerateRandomMoney()
+	entry, err := appendEntry(context.Background(), testQueries, account.ID, amount, 0, time.Now())
 	require.NoError(t, err)
 	require.NotEmpty(t, entry)
 
-	require.Equal(t, arg.AccountID, entry.AccountID)
-	require.Equal(t, arg.Amount, entry.Amount)
+	require.Equal(t, account.ID, entry.AccountID)
+	require.Equal(t, amount, entry.Amount)
+	require.Equal(t, EntryHash(entry), entry.Hash)
 
 	require.NotZero(t, entry.ID)
 	require.NotZero(t, entry.CreatedAt)
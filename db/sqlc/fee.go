@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// FeeRevenuePurpose is the purpose of the system accounts that transfer fees are credited to, one per currency
+const FeeRevenuePurpose = "fee_revenue"
+
+// CalculateFee returns the fee the schedule charges on a transfer of amount: the flat fee plus the percentage of the
+// amount rounded down, raised to the minimum and capped at the maximum
+func CalculateFee(schedule FeeSchedule, amount int64) int64 {
+	fee := schedule.FlatFee + mulDiv(amount, schedule.Percentage, rateScale)
+	fee = max(fee, schedule.MinFee)
+	if schedule.MaxFee.Valid {
+		fee = min(fee, schedule.MaxFee.Int64)
+	}
+	return fee
+}
+
+// TransferFee returns the fee charged to the sender of a transfer of amount in currency, zero when the currency has
+// no fee schedule
+func TransferFee(ctx context.Context, q Querier, currency string, amount int64) (int64, error) {
+	schedule, err := q.GetFeeSchedule(ctx, currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return CalculateFee(schedule, amount), nil
+}
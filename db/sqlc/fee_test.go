@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateFee(t *testing.T) {
+	schedule := FeeSchedule{
+		FlatFee:    25,
+		Percentage: 1_000_000,
+		MinFee:     50,
+		MaxFee:     sql.NullInt64{Int64: 500, Valid: true},
+	}
+	// 25 + 1% of 1000 is below the minimum
+	require.Equal(t, int64(50), CalculateFee(schedule, 1000))
+	// 25 + 1% of 10099, rounded down
+	require.Equal(t, int64(125), CalculateFee(schedule, 10099))
+	// 25 + 1% of 100000 is above the maximum
+	require.Equal(t, int64(500), CalculateFee(schedule, 100000))
+
+	// without a cap the percentage keeps growing
+	schedule.MaxFee = sql.NullInt64{}
+	require.Equal(t, int64(1025), CalculateFee(schedule, 100000))
+	require.Zero(t, CalculateFee(FeeSchedule{}, 100000))
+}
+
+func createCurrencyAccount(t *testing.T, currency string, balance int64) Account {
+	owner := createRandomUser(t)
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:       owner.Username,
+		Balance:     balance,
+		Currency:    currency,
+		ProductCode: DefaultProductCode,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestTransferTxFee(t *testing.T) {
+	store := NewStore(testDB)
+	_, err := testQueries.UpsertFeeSchedule(context.Background(), UpsertFeeScheduleParams{
+		Currency: utils.SGD,
+		FlatFee:  30,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := testQueries.DeleteFeeSchedule(context.Background(), utils.SGD)
+		require.NoError(t, err)
+	})
+	revenue, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Purpose:  FeeRevenuePurpose,
+		Currency: utils.SGD,
+	})
+	require.NoError(t, err)
+	revenueBefore, err := testQueries.GetAccount(context.Background(), revenue.AccountID)
+	require.NoError(t, err)
+
+	fromAccount := createCurrencyAccount(t, utils.SGD, 1000)
+	toAccount := createCurrencyAccount(t, utils.SGD, 0)
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(30), result.Fee)
+	require.NotNil(t, result.FeeTransfer)
+	require.Equal(t, result.Transfer.ID, result.FeeTransfer.FeeOf.Int64)
+	require.Equal(t, revenue.AccountID, result.FeeTransfer.ToAccountID)
+	require.Equal(t, int64(30), result.FeeTransfer.Amount)
+	require.Equal(t, int64(870), result.FromAccount.Balance)
+	require.Equal(t, int64(100), result.ToAccount.Balance)
+
+	revenueAfter, err := testQueries.GetAccount(context.Background(), revenue.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, revenueBefore.Balance+30, revenueAfter.Balance)
+
+	// the fee counts towards the funds the sender needs
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        850,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// refunds are free
+	reversal, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{TransferID: result.Transfer.ID})
+	require.NoError(t, err)
+	require.Zero(t, reversal.Fee)
+	require.Nil(t, reversal.FeeTransfer)
+	require.Zero(t, reversal.FromAccount.Balance)
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomFxQuote(t *testing.T, username string, expiresAt time.Time) FxQuote {
+	arg := CreateFxQuoteParams{
+		ID:           uuid.New(),
+		Username:     username,
+		FromCurrency: utils.USD,
+		ToCurrency:   utils.EUR,
+		Rate:         92_000_000,
+		FromAmount:   100,
+		ToAmount:     92,
+		ExpiresAt:    expiresAt,
+	}
+	quote, err := testQueries.CreateFxQuote(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, quote.ID)
+	require.Equal(t, arg.Username, quote.Username)
+	require.Equal(t, arg.Rate, quote.Rate)
+	require.Equal(t, arg.FromAmount, quote.FromAmount)
+	require.Equal(t, arg.ToAmount, quote.ToAmount)
+	require.WithinDuration(t, arg.ExpiresAt, quote.ExpiresAt, time.Second)
+	require.False(t, quote.UsedAt.Valid)
+	return quote
+}
+
+func TestGetFxQuote(t *testing.T) {
+	user := createRandomUser(t)
+	quote1 := createRandomFxQuote(t, user.Username, time.Now().Add(time.Minute))
+
+	quote2, err := testQueries.GetFxQuote(context.Background(), quote1.ID)
+	require.NoError(t, err)
+	require.Equal(t, quote1.ID, quote2.ID)
+	require.Equal(t, quote1.ToAmount, quote2.ToAmount)
+
+	err = testQueries.MarkFxQuoteUsed(context.Background(), quote1.ID)
+	require.NoError(t, err)
+	quote3, err := testQueries.GetFxQuote(context.Background(), quote1.ID)
+	require.NoError(t, err)
+	require.True(t, quote3.UsedAt.Valid)
+}
@@ -0,0 +1,23 @@
+package db
+
+import "math/big"
+
+// InterestExpensePurpose is the purpose of the system accounts that pay out interest, one per currency
+const InterestExpensePurpose = "interest_expense"
+
+// interest accrues on an actual/365 basis, a day earns the same 1/365 of the annual rate in leap years too
+const daysPerYear = 365
+
+// AccrueInterest returns the interest earned in a day on principal at annualRate, scaled by 1e8, in whole minor units.
+// The exact interest is rounded down and the fraction left over is returned as the remainder for the next day, in
+// 1/(365 * 1e8) minor units, so nothing is lost to rounding: over any run of days the whole units accrued are the exact
+// total rounded down once. A principal that is not positive earns nothing and leaves the remainder as it was.
+func AccrueInterest(principal int64, annualRate int64, remainder int64) (amount int64, newRemainder int64) {
+	if principal <= 0 || annualRate <= 0 {
+		return 0, remainder
+	}
+	exact := new(big.Int).Mul(big.NewInt(principal), big.NewInt(annualRate))
+	exact.Add(exact, big.NewInt(remainder))
+	quotient, modulus := exact.QuoRem(exact, big.NewInt(daysPerYear*rateScale), new(big.Int))
+	return quotient.Int64(), modulus.Int64()
+}
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccrueInterest(t *testing.T) {
+	// 10000.00 at 2% earns 0.5479... a day
+	amount, remainder := AccrueInterest(1_000_000, 2_000_000, 0)
+	require.Equal(t, int64(54), amount)
+	require.Equal(t, int64(29_000_000_000), remainder)
+
+	// the fractions carried forward add up to the exact interest for the year
+	total, remainder := int64(0), int64(0)
+	for day := 0; day < daysPerYear; day++ {
+		amount, remainder = AccrueInterest(1_000_000, 2_000_000, remainder)
+		total += amount
+	}
+	require.Equal(t, int64(20_000), total)
+	require.Zero(t, remainder)
+
+	// a day too small to earn a whole unit still counts towards the next one
+	amount, remainder = AccrueInterest(100, 2_000_000, 0)
+	require.Zero(t, amount)
+	require.Equal(t, int64(200_000_000), remainder)
+
+	// an overdrawn account earns nothing and keeps its remainder
+	amount, remainder = AccrueInterest(-500, 2_000_000, 7)
+	require.Zero(t, amount)
+	require.Equal(t, int64(7), remainder)
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+
+// LimitPeriods returns the start of the UTC day and month containing now, the windows the daily and monthly limits
+// are counted over
+func LimitPeriods(now time.Time) (dayStart time.Time, monthStart time.Time) {
+	year, month, day := now.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// checkTransferLimits rejects a debit of amount that breaks a limit of the account. Usage is summed from the
+// committed outgoing entries, fee debits included, so amount has to include the fee as well. Funds reserved by active
+// holds count as used until the hold is captured, voided or expires. The account must be locked first for concurrent
+// transfers to be counted one after another instead of each passing against the same total
+func checkTransferLimits(ctx context.Context, q *Queries, account Account, amount int64, now time.Time) error {
+	limits, err := q.GetAccountLimits(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+	if limits.MaxTransfer.Valid && amount > limits.MaxTransfer.Int64 {
+		return fmt.Errorf("%w: a single transfer may be at most %d", ErrTransferLimitExceeded, limits.MaxTransfer.Int64)
+	}
+	dayStart, monthStart := LimitPeriods(now)
+	periods := []struct {
+		name  string
+		limit sql.NullInt64
+		since time.Time
+	}{
+		{"daily", limits.DailyLimit, dayStart},
+		{"monthly", limits.MonthlyLimit, monthStart},
+	}
+	for _, period := range periods {
+		if !period.limit.Valid {
+			continue
+		}
+		used, err := q.SumOutgoingEntriesSince(ctx, SumOutgoingEntriesSinceParams{
+			AccountID: account.ID,
+			CreatedAt: period.since,
+		})
+		if err != nil {
+			return err
+		}
+		used += account.HeldAmount
+		if used+amount > period.limit.Int64 {
+			remaining := max(period.limit.Int64-used, 0)
+			return fmt.Errorf("%w: %d left of the %s limit", ErrTransferLimitExceeded, remaining, period.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferLimits(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 10000)
+	toAccount := createRandomAccount(t)
+
+	_, err := store.UpsertAccountLimits(context.Background(), UpsertAccountLimitsParams{
+		AccountID:   fromAccount.ID,
+		MaxTransfer: sql.NullInt64{Int64: 300, Valid: true},
+		DailyLimit:  sql.NullInt64{Int64: 500, Valid: true},
+	})
+	require.NoError(t, err)
+
+	limits, err := store.GetAccountLimits(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(300), limits.MaxTransfer.Int64)
+	require.Equal(t, int64(500), limits.DailyLimit.Int64)
+
+	arg := TransferTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        301,
+	}
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	arg.Amount = 300
+	first, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// 300 of the 500 daily limit is used
+	arg.Amount = 201
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	arg.Amount = 200
+	_, err = store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// refunds to the account are not counted against the limits of the payee
+	_, err = store.UpsertAccountLimits(context.Background(), UpsertAccountLimitsParams{
+		AccountID:   toAccount.ID,
+		MaxTransfer: sql.NullInt64{Int64: 0, Valid: true},
+	})
+	require.NoError(t, err)
+	result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{TransferID: first.Transfer.ID})
+	require.NoError(t, err)
+	require.Equal(t, fromAccount.Balance-200, result.ToAccount.Balance)
+}
+
+func TestTransferLimitsConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 10000)
+	toAccount := createRandomAccount(t)
+
+	_, err := store.UpsertAccountLimits(context.Background(), UpsertAccountLimitsParams{
+		AccountID:  fromAccount.ID,
+		DailyLimit: sql.NullInt64{Int64: 250, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// only two of the transfers fit in the daily limit however they interleave
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountId: fromAccount.ID,
+				ToAccountId:   toAccount.ID,
+				Amount:        100,
+			})
+			errs <- err
+		}()
+	}
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrTransferLimitExceeded)
+	}
+	require.Equal(t, 2, succeeded)
+
+	account, err := store.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, fromAccount.Balance-200, account.Balance)
+}
+
+func TestTransferLimitsCountFees(t *testing.T) {
+	store := NewStore(testDB)
+	_, err := testQueries.UpsertFeeSchedule(context.Background(), UpsertFeeScheduleParams{
+		Currency: utils.SGD,
+		FlatFee:  10,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := testQueries.DeleteFeeSchedule(context.Background(), utils.SGD)
+		require.NoError(t, err)
+	})
+
+	fromAccount := createCurrencyAccount(t, utils.SGD, 1000)
+	toAccount := createCurrencyAccount(t, utils.SGD, 0)
+	_, err = store.UpsertAccountLimits(context.Background(), UpsertAccountLimitsParams{
+		AccountID:  fromAccount.ID,
+		DailyLimit: sql.NullInt64{Int64: 220, Valid: true},
+	})
+	require.NoError(t, err)
+
+	arg := TransferTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        100,
+	}
+	_, err = store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// 110 is used with the fee, a second 100 plus its fee would take the day to 220 and a cent more does not fit
+	arg.Amount = 101
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	arg.Amount = 100
+	_, err = store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+}
@@ -5,24 +5,773 @@
 package db
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
+
+	"github.com/google/uuid"
+)
+
+type AccountStatus string
+
+const (
+	AccountStatusActive AccountStatus = "active"
+	AccountStatusFrozen AccountStatus = "frozen"
+	AccountStatusClosed AccountStatus = "closed"
+)
+
+func (e *AccountStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AccountStatus(s)
+	case string:
+		*e = AccountStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AccountStatus: %T", src)
+	}
+	return nil
+}
+
+type NullAccountStatus struct {
+	AccountStatus AccountStatus `json:"account_status"`
+	Valid         bool          `json:"valid"` // Valid is true if AccountStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAccountStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.AccountStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AccountStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAccountStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.AccountStatus), nil
+}
+
+type AccountType string
+
+const (
+	AccountTypeChecking AccountType = "checking"
+	AccountTypeSavings  AccountType = "savings"
+	AccountTypeBusiness AccountType = "business"
+	AccountTypeSystem   AccountType = "system"
+)
+
+func (e *AccountType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AccountType(s)
+	case string:
+		*e = AccountType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AccountType: %T", src)
+	}
+	return nil
+}
+
+type NullAccountType struct {
+	AccountType AccountType `json:"account_type"`
+	Valid       bool        `json:"valid"` // Valid is true if AccountType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAccountType) Scan(value interface{}) error {
+	if value == nil {
+		ns.AccountType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AccountType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAccountType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.AccountType), nil
+}
+
+type Compounding string
+
+const (
+	CompoundingDaily   Compounding = "daily"
+	CompoundingMonthly Compounding = "monthly"
+)
+
+func (e *Compounding) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = Compounding(s)
+	case string:
+		*e = Compounding(s)
+	default:
+		return fmt.Errorf("unsupported scan type for Compounding: %T", src)
+	}
+	return nil
+}
+
+type NullCompounding struct {
+	Compounding Compounding `json:"compounding"`
+	Valid       bool        `json:"valid"` // Valid is true if Compounding is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullCompounding) Scan(value interface{}) error {
+	if value == nil {
+		ns.Compounding, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.Compounding.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullCompounding) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.Compounding), nil
+}
+
+type HoldStatus string
+
+const (
+	HoldStatusActive   HoldStatus = "active"
+	HoldStatusCaptured HoldStatus = "captured"
+	HoldStatusVoided   HoldStatus = "voided"
+	HoldStatusExpired  HoldStatus = "expired"
+)
+
+func (e *HoldStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = HoldStatus(s)
+	case string:
+		*e = HoldStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for HoldStatus: %T", src)
+	}
+	return nil
+}
+
+type NullHoldStatus struct {
+	HoldStatus HoldStatus `json:"hold_status"`
+	Valid      bool       `json:"valid"` // Valid is true if HoldStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullHoldStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.HoldStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.HoldStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullHoldStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.HoldStatus), nil
+}
+
+type InsufficientFundsPolicy string
+
+const (
+	InsufficientFundsPolicySkip  InsufficientFundsPolicy = "skip"
+	InsufficientFundsPolicyRetry InsufficientFundsPolicy = "retry"
+)
+
+func (e *InsufficientFundsPolicy) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = InsufficientFundsPolicy(s)
+	case string:
+		*e = InsufficientFundsPolicy(s)
+	default:
+		return fmt.Errorf("unsupported scan type for InsufficientFundsPolicy: %T", src)
+	}
+	return nil
+}
+
+type NullInsufficientFundsPolicy struct {
+	InsufficientFundsPolicy InsufficientFundsPolicy `json:"insufficient_funds_policy"`
+	Valid                   bool                    `json:"valid"` // Valid is true if InsufficientFundsPolicy is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullInsufficientFundsPolicy) Scan(value interface{}) error {
+	if value == nil {
+		ns.InsufficientFundsPolicy, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.InsufficientFundsPolicy.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullInsufficientFundsPolicy) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.InsufficientFundsPolicy), nil
+}
+
+type PendingTransferStatus string
+
+const (
+	PendingTransferStatusPending    PendingTransferStatus = "pending"
+	PendingTransferStatusProcessing PendingTransferStatus = "processing"
+	PendingTransferStatusExecuted   PendingTransferStatus = "executed"
+	PendingTransferStatusRejected   PendingTransferStatus = "rejected"
+	PendingTransferStatusFailed     PendingTransferStatus = "failed"
+)
+
+func (e *PendingTransferStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PendingTransferStatus(s)
+	case string:
+		*e = PendingTransferStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PendingTransferStatus: %T", src)
+	}
+	return nil
+}
+
+type NullPendingTransferStatus struct {
+	PendingTransferStatus PendingTransferStatus `json:"pending_transfer_status"`
+	Valid                 bool                  `json:"valid"` // Valid is true if PendingTransferStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPendingTransferStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.PendingTransferStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PendingTransferStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPendingTransferStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PendingTransferStatus), nil
+}
+
+type RiskOutcome string
+
+const (
+	RiskOutcomeAllow  RiskOutcome = "allow"
+	RiskOutcomeReview RiskOutcome = "review"
+	RiskOutcomeDeny   RiskOutcome = "deny"
+)
+
+func (e *RiskOutcome) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RiskOutcome(s)
+	case string:
+		*e = RiskOutcome(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RiskOutcome: %T", src)
+	}
+	return nil
+}
+
+type NullRiskOutcome struct {
+	RiskOutcome RiskOutcome `json:"risk_outcome"`
+	Valid       bool        `json:"valid"` // Valid is true if RiskOutcome is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullRiskOutcome) Scan(value interface{}) error {
+	if value == nil {
+		ns.RiskOutcome, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.RiskOutcome.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullRiskOutcome) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.RiskOutcome), nil
+}
+
+type ScheduledTransferStatus string
+
+const (
+	ScheduledTransferStatusPending    ScheduledTransferStatus = "pending"
+	ScheduledTransferStatusProcessing ScheduledTransferStatus = "processing"
+	ScheduledTransferStatusSucceeded  ScheduledTransferStatus = "succeeded"
+	ScheduledTransferStatusFailed     ScheduledTransferStatus = "failed"
+	ScheduledTransferStatusCanceled   ScheduledTransferStatus = "canceled"
+)
+
+func (e *ScheduledTransferStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ScheduledTransferStatus(s)
+	case string:
+		*e = ScheduledTransferStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ScheduledTransferStatus: %T", src)
+	}
+	return nil
+}
+
+type NullScheduledTransferStatus struct {
+	ScheduledTransferStatus ScheduledTransferStatus `json:"scheduled_transfer_status"`
+	Valid                   bool                    `json:"valid"` // Valid is true if ScheduledTransferStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullScheduledTransferStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.ScheduledTransferStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ScheduledTransferStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullScheduledTransferStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ScheduledTransferStatus), nil
+}
+
+type StandingOrderExecutionStatus string
+
+const (
+	StandingOrderExecutionStatusSucceeded StandingOrderExecutionStatus = "succeeded"
+	StandingOrderExecutionStatusRetrying  StandingOrderExecutionStatus = "retrying"
+	StandingOrderExecutionStatusSkipped   StandingOrderExecutionStatus = "skipped"
 )
 
+func (e *StandingOrderExecutionStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = StandingOrderExecutionStatus(s)
+	case string:
+		*e = StandingOrderExecutionStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for StandingOrderExecutionStatus: %T", src)
+	}
+	return nil
+}
+
+type NullStandingOrderExecutionStatus struct {
+	StandingOrderExecutionStatus StandingOrderExecutionStatus `json:"standing_order_execution_status"`
+	Valid                        bool                         `json:"valid"` // Valid is true if StandingOrderExecutionStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullStandingOrderExecutionStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.StandingOrderExecutionStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.StandingOrderExecutionStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullStandingOrderExecutionStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.StandingOrderExecutionStatus), nil
+}
+
+type StandingOrderFrequency string
+
+const (
+	StandingOrderFrequencyWeekly          StandingOrderFrequency = "weekly"
+	StandingOrderFrequencyMonthly         StandingOrderFrequency = "monthly"
+	StandingOrderFrequencyLastBusinessDay StandingOrderFrequency = "last_business_day"
+)
+
+func (e *StandingOrderFrequency) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = StandingOrderFrequency(s)
+	case string:
+		*e = StandingOrderFrequency(s)
+	default:
+		return fmt.Errorf("unsupported scan type for StandingOrderFrequency: %T", src)
+	}
+	return nil
+}
+
+type NullStandingOrderFrequency struct {
+	StandingOrderFrequency StandingOrderFrequency `json:"standing_order_frequency"`
+	Valid                  bool                   `json:"valid"` // Valid is true if StandingOrderFrequency is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullStandingOrderFrequency) Scan(value interface{}) error {
+	if value == nil {
+		ns.StandingOrderFrequency, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.StandingOrderFrequency.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullStandingOrderFrequency) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.StandingOrderFrequency), nil
+}
+
+type StandingOrderStatus string
+
+const (
+	StandingOrderStatusActive    StandingOrderStatus = "active"
+	StandingOrderStatusCompleted StandingOrderStatus = "completed"
+	StandingOrderStatusCanceled  StandingOrderStatus = "canceled"
+)
+
+func (e *StandingOrderStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = StandingOrderStatus(s)
+	case string:
+		*e = StandingOrderStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for StandingOrderStatus: %T", src)
+	}
+	return nil
+}
+
+type NullStandingOrderStatus struct {
+	StandingOrderStatus StandingOrderStatus `json:"standing_order_status"`
+	Valid               bool                `json:"valid"` // Valid is true if StandingOrderStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullStandingOrderStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.StandingOrderStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.StandingOrderStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullStandingOrderStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.StandingOrderStatus), nil
+}
+
 type Account struct {
 	ID        int64     `json:"id"`
 	Owner     string    `json:"owner"`
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// how far below zero the balance may go
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	// total of the active holds on the account, their reserved fees included
+	HeldAmount int64 `json:"held_amount"`
+	// balance less held_amount, what transfers and new holds can spend
+	AvailableBalance int64 `json:"available_balance"`
+	// the product of the account, which sets the interest it earns
+	ProductCode string `json:"product_code"`
+	// only active accounts are debited or credited, a closed account is kept for its history
+	Status AccountStatus `json:"status"`
+	// null while the account has the status it was opened with
+	StatusChangedAt sql.NullTime `json:"status_changed_at"`
+	// when the account last moved to another product, null if it never did. Interest is not accrued for days before it
+	ProductChangedAt sql.NullTime `json:"product_changed_at"`
 }
 
-type Entry struct {
-	ID        int64     `json:"id"`
+// per account overrides of transfer_limits, a null column keeps the tier limit
+// users authorised to approve the pending transfers of an account
+type AccountApprover struct {
 	AccountID int64     `json:"account_id"`
-	Amount    int64     `json:"amount"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AccountLimit struct {
+	AccountID    int64         `json:"account_id"`
+	MaxTransfer  sql.NullInt64 `json:"max_transfer"`
+	DailyLimit   sql.NullInt64 `json:"daily_limit"`
+	MonthlyLimit sql.NullInt64 `json:"monthly_limit"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+// names a user chooses to be paid by, lowercase and never the same as a username
+type Alias struct {
+	Alias     string    `json:"alias"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// accounts whose large transfers need the approval of a second user
+type ApprovalPolicy struct {
+	AccountID int64 `json:"account_id"`
+	// transfers of more than this, in the account currency, wait for approval
+	Threshold         int64     `json:"threshold"`
+	RequiredApprovals int32     `json:"required_approvals"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type BalanceSnapshot struct {
+	AccountID int64 `json:"account_id"`
+	// start of a UTC day
+	SnapshotAt time.Time `json:"snapshot_at"`
+	// sum of the entries of the account created before snapshot_at
+	Balance   int64     `json:"balance"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type Entry struct {
+	ID         int64         `json:"id"`
+	AccountID  int64         `json:"account_id"`
+	Amount     int64         `json:"amount"`
+	CreatedAt  time.Time     `json:"created_at"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	// position of the entry in the hash chain of its account, starting at 1
+	Seq int64 `json:"seq"`
+	// hash of the previous entry of the account, 32 zero bytes for the first
+	PrevHash []byte `json:"prev_hash"`
+	// SHA-256 of prev_hash followed by seq, account_id, amount, transfer_id (0 when null) and created_at in unix microseconds, each as a big-endian int64
+	Hash []byte `json:"hash"`
+}
+
+// fee charged to the sender of a transfer by the currency debited, no row charges nothing
+type FeeSchedule struct {
+	Currency string `json:"currency"`
+	FlatFee  int64  `json:"flat_fee"`
+	// share of the amount scaled by 1e8, 0.5% is 500000
+	Percentage int64 `json:"percentage"`
+	// smallest fee after adding the flat fee and the percentage
+	MinFee int64 `json:"min_fee"`
+	// largest fee, null for no cap
+	MaxFee    sql.NullInt64 `json:"max_fee"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+type FxQuote struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	// locked rate scaled by 1e8
+	Rate       int64        `json:"rate"`
+	FromAmount int64        `json:"from_amount"`
+	ToAmount   int64        `json:"to_amount"`
+	ExpiresAt  time.Time    `json:"expires_at"`
+	UsedAt     sql.NullTime `json:"used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type Hold struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	// reserved on the from account while the hold is active
+	Amount int64      `json:"amount"`
+	Status HoldStatus `json:"status"`
+	// the transfer the hold was captured into, set once the status is captured
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+	// fee on capturing the whole hold, reserved with the amount so the capture can always pay it
+	Fee int64 `json:"fee"`
+}
+
+type IdempotencyKey struct {
+	Username    string          `json:"username"`
+	Key         string          `json:"key"`
+	RequestHash string          `json:"request_hash"`
+	Response    json.RawMessage `json:"response"`
+	CreatedAt   time.Time       `json:"created_at"`
+	// set when the request was held for approval, a retry returns the pending transfer instead of the response
+	PendingTransferID sql.NullInt64 `json:"pending_transfer_id"`
+}
+
+type InterestAccrual struct {
+	AccountID   int64     `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+	// the balance interest was earned on at the end of the UTC day
+	Principal          int64 `json:"principal"`
+	AnnualInterestRate int64 `json:"annual_interest_rate"`
+	// whole minor units of interest earned on the day
+	Amount int64 `json:"amount"`
+	// fraction of a minor unit carried to the next day, in 1/(365 * 1e8) minor units
+	Remainder int64 `json:"remainder"`
+	// the transfer the interest was posted with, null if the posted amount was zero
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	// null until the interest is posted
+	PostedAt  sql.NullTime `json:"posted_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type PendingTransfer struct {
+	ID            int64           `json:"id"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
+	InitiatedBy   string          `json:"initiated_by"`
+	// copied from the approval policy when the transfer is started
+	RequiredApprovals int32                 `json:"required_approvals"`
+	ApprovalCount     int32                 `json:"approval_count"`
+	Status            PendingTransferStatus `json:"status"`
+	RejectedBy        sql.NullString        `json:"rejected_by"`
+	// the executed transfer, set once the status is executed
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	// why the approved transfer could not be executed, set once the status is failed
+	FailureReason sql.NullString `json:"failure_reason"`
+	// when the last required approval or the rejection came in
+	DecidedAt sql.NullTime `json:"decided_at"`
+	CreatedAt time.Time    `json:"created_at"`
+	// set when the risk engine held the transfer for review, it cannot run until an admin reviews it
+	RiskAssessmentID sql.NullInt64  `json:"risk_assessment_id"`
+	ReviewedBy       sql.NullString `json:"reviewed_by"`
+	ReviewedAt       sql.NullTime   `json:"reviewed_at"`
+}
+
+type PendingTransferApproval struct {
+	PendingTransferID int64     `json:"pending_transfer_id"`
+	Username          string    `json:"username"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type Product struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// scaled by 1e8, 2% is 2000000
+	AnnualInterestRate int64 `json:"annual_interest_rate"`
+	// daily compounds interest accrued but not yet posted, monthly only the posted interest
+	Compounding Compounding `json:"compounding"`
+	CreatedAt   time.Time   `json:"created_at"`
+	AccountType AccountType `json:"account_type"`
+	// the currencies an account of the product can hold, empty allows any
+	AllowedCurrencies []string `json:"allowed_currencies"`
+	// a transfer cannot take the available balance below it, zero leaves the floor to the overdraft limit
+	MinBalance int64 `json:"min_balance"`
+	// outgoing transfers allowed per UTC calendar month, null for no limit
+	MaxWithdrawalsPerMonth sql.NullInt32 `json:"max_withdrawals_per_month"`
+	// false for products money can only be paid into
+	CanBeSource bool `json:"can_be_source"`
+}
+
+type Rate struct {
+	ID            int64  `json:"id"`
+	BaseCurrency  string `json:"base_currency"`
+	QuoteCurrency string `json:"quote_currency"`
+	// quote currency units per base currency unit, scaled by 1e8
+	Rate        int64     `json:"rate"`
+	EffectiveAt time.Time `json:"effective_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// every screening of a transfer by the risk engine, kept for analysis
+type RiskAssessment struct {
+	ID            int64       `json:"id"`
+	Username      string      `json:"username"`
+	FromAccountID int64       `json:"from_account_id"`
+	ToAccountID   int64       `json:"to_account_id"`
+	Amount        int64       `json:"amount"`
+	Outcome       RiskOutcome `json:"outcome"`
+	// the rule that decided the outcome, empty when every rule allowed the transfer
+	Rule       string `json:"rule"`
+	ReasonCode string `json:"reason_code"`
+	Reason     string `json:"reason"`
+	// the decision of each rule that ran
+	Decisions json.RawMessage `json:"decisions"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	// must be positive
+	Amount    int64                   `json:"amount"`
+	ExecuteAt time.Time               `json:"execute_at"`
+	Status    ScheduledTransferStatus `json:"status"`
+	// the executed transfer, set once the status is succeeded
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	// why the transfer could not be executed, set once the status is failed
+	FailureReason sql.NullString `json:"failure_reason"`
+	ExecutedAt    sql.NullTime   `json:"executed_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+type StandingOrder struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	// must be positive
+	Amount    int64                  `json:"amount"`
+	Frequency StandingOrderFrequency `json:"frequency"`
+	// day a monthly order runs on, the last day of shorter months
+	DayOfMonth              int32                   `json:"day_of_month"`
+	InsufficientFundsPolicy InsufficientFundsPolicy `json:"insufficient_funds_policy"`
+	MaxRetries              int32                   `json:"max_retries"`
+	RetryCount              int32                   `json:"retry_count"`
+	// the occurrence currently due
+	OccurrenceAt time.Time `json:"occurrence_at"`
+	// when the occurrence is next attempted, later than occurrence_at while retrying
+	NextRunAt time.Time           `json:"next_run_at"`
+	EndAt     sql.NullTime        `json:"end_at"`
+	Status    StandingOrderStatus `json:"status"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+type StandingOrderExecution struct {
+	ID              int64                        `json:"id"`
+	StandingOrderID int64                        `json:"standing_order_id"`
+	OccurrenceAt    time.Time                    `json:"occurrence_at"`
+	Attempt         int32                        `json:"attempt"`
+	Status          StandingOrderExecutionStatus `json:"status"`
+	// the executed transfer, set once the status is succeeded
+	TransferID    sql.NullInt64  `json:"transfer_id"`
+	FailureReason sql.NullString `json:"failure_reason"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// accounts owned by the bank that it books its own income and expenses against
+type SystemAccount struct {
+	Purpose   string `json:"purpose"`
+	Currency  string `json:"currency"`
+	AccountID int64  `json:"account_id"`
+}
+
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
@@ -30,6 +779,31 @@ type Transfer struct {
 	// must be postive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// amount credited in the destination currency, null when both accounts share a currency
+	ToAmount sql.NullInt64 `json:"to_amount"`
+	// applied rate scaled by 1e8, null when both accounts share a currency
+	ExchangeRate sql.NullInt64 `json:"exchange_rate"`
+	// the transfer this one refunds, null for a regular transfer
+	ReversalOf sql.NullInt64 `json:"reversal_of"`
+	// free text shown to both parties
+	Description string `json:"description"`
+	// end-to-end reference chosen by the sender, empty when none was given
+	Reference string `json:"reference"`
+	// arbitrary JSON object attached by the sender
+	Metadata json.RawMessage `json:"metadata"`
+	// the transfer this one charges the fee for, null for a regular transfer
+	FeeOf sql.NullInt64 `json:"fee_of"`
+}
+
+type TransferLimit struct {
+	Tier     string `json:"tier"`
+	Currency string `json:"currency"`
+	// largest single transfer, null for no limit
+	MaxTransfer sql.NullInt64 `json:"max_transfer"`
+	// total outgoing per UTC day, null for no limit
+	DailyLimit sql.NullInt64 `json:"daily_limit"`
+	// total outgoing per UTC month, null for no limit
+	MonthlyLimit sql.NullInt64 `json:"monthly_limit"`
 }
 
 type User struct {
@@ -39,4 +813,10 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	// depositor or admin
+	Role string `json:"role"`
+	// selects the transfer limits that apply to the accounts of the user
+	Tier string `json:"tier"`
+	// null until the email is verified, only a verified email can be paid to
+	EmailVerifiedAt sql.NullTime `json:"email_verified_at"`
 }
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DefaultProductCode is the product of accounts opened without one
+const DefaultProductCode = "checking"
+
+var (
+	ErrProductRuleViolated  = errors.New("transfer breaks a rule of the account product")
+	ErrCurrencyNotAvailable = errors.New("currency is not available for the product")
+)
+
+// ProductAllowsCurrency reports whether an account of the product can hold currency
+func ProductAllowsCurrency(product Product, currency string) bool {
+	return len(product.AllowedCurrencies) == 0 || slices.Contains(product.AllowedCurrencies, currency)
+}
+
+// checkProductRules rejects a debit of amount, fee included, that the product of the source account does not allow.
+// Withdrawals are counted from the committed transfers, so the account must be locked first like for the limits
+func checkProductRules(ctx context.Context, q *Queries, account Account, amount int64, now time.Time) error {
+	product, err := q.GetProduct(ctx, account.ProductCode)
+	if err != nil {
+		return err
+	}
+	if !product.CanBeSource {
+		return fmt.Errorf("%w: %s accounts cannot send transfers", ErrProductRuleViolated, product.Name)
+	}
+	if product.MinBalance > 0 && account.AvailableBalance-amount < product.MinBalance {
+		return fmt.Errorf("%w: %s accounts must keep a balance of %d", ErrProductRuleViolated, product.Name, product.MinBalance)
+	}
+	if !product.MaxWithdrawalsPerMonth.Valid {
+		return nil
+	}
+	_, monthStart := LimitPeriods(now)
+	withdrawals, err := q.CountAccountWithdrawalsSince(ctx, CountAccountWithdrawalsSinceParams{
+		AccountID: account.ID,
+		Since:     monthStart,
+	})
+	if err != nil {
+		return err
+	}
+	if withdrawals >= int64(product.MaxWithdrawalsPerMonth.Int32) {
+		return fmt.Errorf("%w: %s accounts allow %d withdrawals a month", ErrProductRuleViolated, product.Name, product.MaxWithdrawalsPerMonth.Int32)
+	}
+	return nil
+}
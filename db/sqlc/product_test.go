@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomProduct(t *testing.T, arg UpsertProductParams) Product {
+	arg.Code = "test_" + utils.GenerateRandomString(8)
+	arg.Name = "Test product"
+	arg.AccountType = AccountTypeChecking
+	arg.Compounding = CompoundingMonthly
+	if arg.AllowedCurrencies == nil {
+		arg.AllowedCurrencies = []string{}
+	}
+	product, err := testQueries.UpsertProduct(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Code, product.Code)
+	require.Equal(t, arg.AllowedCurrencies, product.AllowedCurrencies)
+	require.Equal(t, arg.MinBalance, product.MinBalance)
+	require.Equal(t, arg.MaxWithdrawalsPerMonth, product.MaxWithdrawalsPerMonth)
+	require.Equal(t, arg.CanBeSource, product.CanBeSource)
+	return product
+}
+
+func createProductAccount(t *testing.T, product Product, balance int64) Account {
+	account := createFundedAccount(t, balance)
+	account, err := testQueries.SetAccountProduct(context.Background(), SetAccountProductParams{
+		ID:          account.ID,
+		ProductCode: product.Code,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestSeededProducts(t *testing.T) {
+	savings, err := testQueries.GetProduct(context.Background(), "savings")
+	require.NoError(t, err)
+	require.Equal(t, AccountTypeSavings, savings.AccountType)
+	require.True(t, savings.MaxWithdrawalsPerMonth.Valid)
+
+	system, err := testQueries.GetProduct(context.Background(), "system")
+	require.NoError(t, err)
+	require.Equal(t, AccountTypeSystem, system.AccountType)
+
+	// the bank's own accounts moved to the system product
+	revenue, err := testQueries.GetSystemAccount(context.Background(), GetSystemAccountParams{Purpose: FeeRevenuePurpose, Currency: utils.USD})
+	require.NoError(t, err)
+	account, err := testQueries.GetAccount(context.Background(), revenue.AccountID)
+	require.NoError(t, err)
+	require.Equal(t, system.Code, account.ProductCode)
+}
+
+func TestProductAllowsCurrency(t *testing.T) {
+	require.True(t, ProductAllowsCurrency(Product{}, utils.CAD))
+	product := Product{AllowedCurrencies: []string{utils.USD, utils.EUR}}
+	require.True(t, ProductAllowsCurrency(product, utils.EUR))
+	require.False(t, ProductAllowsCurrency(product, utils.CAD))
+}
+
+func TestTransferTxProductRules(t *testing.T) {
+	store := NewStore(testDB)
+
+	t.Run("MinBalance", func(t *testing.T) {
+		product := createRandomProduct(t, UpsertProductParams{MinBalance: 500, CanBeSource: true})
+		account := createProductAccount(t, product, 1000)
+		toAccount := createRandomAccount(t)
+
+		_, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountId: account.ID, ToAccountId: toAccount.ID, Amount: 501})
+		require.ErrorIs(t, err, ErrProductRuleViolated)
+
+		result, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountId: account.ID, ToAccountId: toAccount.ID, Amount: 500})
+		require.NoError(t, err)
+		require.Equal(t, int64(500), result.FromAccount.Balance)
+	})
+
+	t.Run("MaxWithdrawals", func(t *testing.T) {
+		product := createRandomProduct(t, UpsertProductParams{
+			MaxWithdrawalsPerMonth: sql.NullInt32{Int32: 2, Valid: true},
+			CanBeSource:            true,
+		})
+		account := createProductAccount(t, product, 1000)
+		toAccount := createRandomAccount(t)
+
+		for i := 0; i < 2; i++ {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountId: account.ID, ToAccountId: toAccount.ID, Amount: 10})
+			require.NoError(t, err)
+		}
+		_, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountId: account.ID, ToAccountId: toAccount.ID, Amount: 10})
+		require.ErrorIs(t, err, ErrProductRuleViolated)
+
+		// money can still be paid in
+		_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountId: toAccount.ID, ToAccountId: account.ID, Amount: 10})
+		require.NoError(t, err)
+	})
+
+	t.Run("NotASource", func(t *testing.T) {
+		product := createRandomProduct(t, UpsertProductParams{CanBeSource: false})
+		account := createProductAccount(t, product, 1000)
+		toAccount := createRandomAccount(t)
+
+		_, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountId: account.ID, ToAccountId: toAccount.ID, Amount: 10})
+		require.ErrorIs(t, err, ErrProductRuleViolated)
+
+		// a refund of a payment into the account is not held to the rules
+		payment, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountId: toAccount.ID, ToAccountId: account.ID, Amount: 10})
+		require.NoError(t, err)
+		_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{TransferID: payment.Transfer.ID})
+		require.NoError(t, err)
+	})
+}
@@ -6,24 +6,143 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
+	AddAccountApprover(ctx context.Context, arg AddAccountApproverParams) (AccountApprover, error)
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddAccountHeldAmount(ctx context.Context, arg AddAccountHeldAmountParams) (Account, error)
+	// the initiator of a transfer never counts as one of its approvers
+	AddPendingTransferApproval(ctx context.Context, arg AddPendingTransferApprovalParams) (int64, error)
+	CancelAccountScheduledTransfers(ctx context.Context, fromAccountID int64) error
+	CancelAccountStandingOrders(ctx context.Context, fromAccountID int64) error
+	CancelScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	CancelStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
+	ClaimPendingTransfer(ctx context.Context, id int64) (PendingTransfer, error)
+	CompletePendingTransfer(ctx context.Context, arg CompletePendingTransferParams) (PendingTransfer, error)
+	CompleteScheduledTransfer(ctx context.Context, arg CompleteScheduledTransferParams) (ScheduledTransfer, error)
+	// fees and refunds are not withdrawals the account holder made
+	CountAccountWithdrawalsSince(ctx context.Context, arg CountAccountWithdrawalsSinceParams) (int64, error)
+	// transfers sent from any account of the owner, fees and refunds are left out
+	CountOwnerTransfersSince(ctx context.Context, arg CountOwnerTransfersSinceParams) (int64, error)
+	CountUserAliases(ctx context.Context, username string) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAlias(ctx context.Context, arg CreateAliasParams) (Alias, error)
+	CreateBalanceSnapshots(ctx context.Context, snapshotAt time.Time) (int64, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateFxQuote(ctx context.Context, arg CreateFxQuoteParams) (FxQuote, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) (InterestAccrual, error)
+	CreatePendingTransfer(ctx context.Context, arg CreatePendingTransferParams) (PendingTransfer, error)
+	CreateRate(ctx context.Context, arg CreateRateParams) (Rate, error)
+	CreateRiskAssessment(ctx context.Context, arg CreateRiskAssessmentParams) (RiskAssessment, error)
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	CreateStandingOrder(ctx context.Context, arg CreateStandingOrderParams) (StandingOrder, error)
+	CreateStandingOrderExecution(ctx context.Context, arg CreateStandingOrderExecutionParams) (StandingOrderExecution, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteAccountApprovers(ctx context.Context, accountID int64) error
+	DeleteAlias(ctx context.Context, arg DeleteAliasParams) (int64, error)
+	DeleteApprovalPolicy(ctx context.Context, accountID int64) (int64, error)
+	DeleteExpiredIdempotencyKey(ctx context.Context, arg DeleteExpiredIdempotencyKeyParams) error
+	// removes a batch of keys past their retention, oldest first
+	DeleteExpiredIdempotencyKeys(ctx context.Context, arg DeleteExpiredIdempotencyKeysParams) (int64, error)
+	DeleteFeeSchedule(ctx context.Context, currency string) (int64, error)
+	FailAccountPendingTransfers(ctx context.Context, arg FailAccountPendingTransfersParams) error
+	FailPendingTransfer(ctx context.Context, arg FailPendingTransferParams) (PendingTransfer, error)
+	FailScheduledTransfer(ctx context.Context, arg FailScheduledTransferParams) (ScheduledTransfer, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	// an account that moved to another product starts accruing at the new rate on the day it moved
+	GetAccountDueForAccrual(ctx context.Context, before time.Time) (GetAccountDueForAccrualRow, error)
+	// interest on a frozen account waits until it is active again
+	GetAccountDueForInterestPosting(ctx context.Context, before time.Time) (int64, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountLimits(ctx context.Context, id int64) (GetAccountLimitsRow, error)
+	GetAlias(ctx context.Context, alias string) (Alias, error)
+	GetApprovalPolicy(ctx context.Context, accountID int64) (ApprovalPolicy, error)
+	GetDueScheduledTransferForUpdate(ctx context.Context, now time.Time) (ScheduledTransfer, error)
+	GetDueStandingOrderForUpdate(ctx context.Context, nextRunAt time.Time) (StandingOrder, error)
+	GetEffectiveRate(ctx context.Context, arg GetEffectiveRateParams) (Rate, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetEntryChainHead(ctx context.Context, accountID int64) (Entry, error)
+	GetExpiredHoldForUpdate(ctx context.Context, now time.Time) (Hold, error)
+	GetFeeSchedule(ctx context.Context, currency string) (FeeSchedule, error)
+	GetFxQuote(ctx context.Context, id uuid.UUID) (FxQuote, error)
+	GetFxQuoteForUpdate(ctx context.Context, id uuid.UUID) (FxQuote, error)
+	GetHold(ctx context.Context, id int64) (Hold, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (Hold, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetLatestBalanceSnapshot(ctx context.Context, arg GetLatestBalanceSnapshotParams) (BalanceSnapshot, error)
+	// the first account the owner opened in the currency, when they hold several products in it
+	GetOwnerAccount(ctx context.Context, arg GetOwnerAccountParams) (Account, error)
+	GetPendingTransfer(ctx context.Context, id int64) (PendingTransfer, error)
+	GetPendingTransferForUpdate(ctx context.Context, id int64) (PendingTransfer, error)
+	GetProduct(ctx context.Context, code string) (Product, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
+	GetSystemAccount(ctx context.Context, arg GetSystemAccountParams) (SystemAccount, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	HasPaidAccount(ctx context.Context, arg HasPaidAccountParams) (bool, error)
+	IncrementPendingTransferApprovals(ctx context.Context, id int64) (PendingTransfer, error)
+	IsAccountApprover(ctx context.Context, arg IsAccountApproverParams) (bool, error)
+	ListAccountApprovers(ctx context.Context, accountID int64) ([]AccountApprover, error)
+	ListAccountTransfers(ctx context.Context, arg ListAccountTransfersParams) ([]ListAccountTransfersRow, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListEntryChain(ctx context.Context, arg ListEntryChainParams) ([]Entry, error)
+	ListFeeSchedules(ctx context.Context) ([]FeeSchedule, error)
+	ListOwnerAccountLimits(ctx context.Context, owner string) ([]ListOwnerAccountLimitsRow, error)
+	// the transfers waiting for a decision that the user started or may approve
+	ListPendingTransfers(ctx context.Context, arg ListPendingTransfersParams) ([]PendingTransfer, error)
+	// the transfers held by the risk engine that no admin has reviewed yet
+	ListPendingTransfersInReview(ctx context.Context, arg ListPendingTransfersInReviewParams) ([]PendingTransfer, error)
+	ListProducts(ctx context.Context) ([]Product, error)
+	// newest first, a null outcome lists every assessment
+	ListRiskAssessments(ctx context.Context, arg ListRiskAssessmentsParams) ([]RiskAssessment, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error)
+	ListStandingOrderExecutions(ctx context.Context, arg ListStandingOrderExecutionsParams) ([]StandingOrderExecution, error)
+	ListStandingOrders(ctx context.Context, arg ListStandingOrdersParams) ([]StandingOrder, error)
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListUserAliases(ctx context.Context, username string) ([]Alias, error)
+	MarkFxQuoteUsed(ctx context.Context, id uuid.UUID) error
+	MarkInterestAccrualsPosted(ctx context.Context, arg MarkInterestAccrualsPostedParams) (int64, error)
+	MarkPendingTransferReviewed(ctx context.Context, arg MarkPendingTransferReviewedParams) (PendingTransfer, error)
+	ReconcileAccounts(ctx context.Context, arg ReconcileAccountsParams) ([]ReconcileAccountsRow, error)
+	ReconcileTransfers(ctx context.Context, arg ReconcileTransfersParams) ([]ReconcileTransfersRow, error)
+	RejectPendingTransfer(ctx context.Context, arg RejectPendingTransferParams) (PendingTransfer, error)
+	// a username wins over a verified email and an email over an alias, aliases are kept distinct from usernames so
+	// only one of them can match in practice. System users are never a recipient
+	ResolveRecipient(ctx context.Context, recipient string) (string, error)
+	SetAccountOverdraftLimit(ctx context.Context, arg SetAccountOverdraftLimitParams) (Account, error)
+	// the change date only moves when the product does, setting the same product again changes nothing
+	SetAccountProduct(ctx context.Context, arg SetAccountProductParams) (Account, error)
+	SetIdempotencyKeyPendingTransfer(ctx context.Context, arg SetIdempotencyKeyPendingTransferParams) error
+	SumEntriesBefore(ctx context.Context, arg SumEntriesBeforeParams) (int64, error)
+	SumEntriesBetween(ctx context.Context, arg SumEntriesBetweenParams) (int64, error)
+	SumOutgoingEntriesSince(ctx context.Context, arg SumOutgoingEntriesSinceParams) (int64, error)
+	SumTransferReversals(ctx context.Context, reversalOf sql.NullInt64) (SumTransferReversalsRow, error)
+	SumUnpostedInterest(ctx context.Context, arg SumUnpostedInterestParams) (int64, error)
+	// moves the account to to_status only if it still has from_status
+	TransitionAccountStatus(ctx context.Context, arg TransitionAccountStatusParams) (Account, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateHoldStatus(ctx context.Context, arg UpdateHoldStatusParams) (Hold, error)
+	UpdateIdempotencyKeyResponse(ctx context.Context, arg UpdateIdempotencyKeyResponseParams) error
+	UpdateStandingOrderSchedule(ctx context.Context, arg UpdateStandingOrderScheduleParams) (StandingOrder, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
+	UpsertAccountLimits(ctx context.Context, arg UpsertAccountLimitsParams) (AccountLimit, error)
+	UpsertApprovalPolicy(ctx context.Context, arg UpsertApprovalPolicyParams) (ApprovalPolicy, error)
+	UpsertFeeSchedule(ctx context.Context, arg UpsertFeeScheduleParams) (FeeSchedule, error)
+	UpsertProduct(ctx context.Context, arg UpsertProductParams) (Product, error)
+	VerifyUserEmail(ctx context.Context, username string) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomRate(t *testing.T, base string, quote string, effectiveAt time.Time) Rate {
+	arg := CreateRateParams{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          utils.GenerateRandomInt(1, 200_000_000),
+		EffectiveAt:   effectiveAt,
+	}
+	rate, err := testQueries.CreateRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, rate.ID)
+	require.Equal(t, arg.BaseCurrency, rate.BaseCurrency)
+	require.Equal(t, arg.QuoteCurrency, rate.QuoteCurrency)
+	require.Equal(t, arg.Rate, rate.Rate)
+	require.WithinDuration(t, arg.EffectiveAt, rate.EffectiveAt, time.Second)
+	return rate
+}
+
+func TestGetEffectiveRate(t *testing.T) {
+	base := utils.GenerateRandomString(3)
+	quote := utils.GenerateRandomString(3)
+	now := time.Now()
+
+	older := createRandomRate(t, base, quote, now.Add(-2*time.Hour))
+	newer := createRandomRate(t, base, quote, now.Add(-time.Hour))
+	createRandomRate(t, base, quote, now.Add(time.Hour))
+
+	rate, err := testQueries.GetEffectiveRate(context.Background(), GetEffectiveRateParams{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		EffectiveAt:   now,
+	})
+	require.NoError(t, err)
+	require.Equal(t, newer.ID, rate.ID)
+
+	rate, err = testQueries.GetEffectiveRate(context.Background(), GetEffectiveRateParams{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		EffectiveAt:   now.Add(-90 * time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, older.ID, rate.ID)
+
+	_, err = testQueries.GetEffectiveRate(context.Background(), GetEffectiveRateParams{
+		BaseCurrency:  quote,
+		QuoteCurrency: base,
+		EffectiveAt:   now,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
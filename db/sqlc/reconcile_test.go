@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileAccounts(t *testing.T) {
+	store := NewStore(testDB)
+
+	// funding writes the balance directly, without entries
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	rows, err := testQueries.ReconcileAccounts(context.Background(), ReconcileAccountsParams{
+		AfterID:   fromAccount.ID - 1,
+		ChunkSize: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, fromAccount.ID, rows[0].ID)
+	require.Equal(t, int64(990), rows[0].Balance)
+	require.Equal(t, int64(-10), rows[0].EntriesTotal)
+}
+
+func TestReconcileTransfers(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	rows, err := testQueries.ReconcileTransfers(context.Background(), ReconcileTransfersParams{
+		AfterID:   result.Transfer.ID - 1,
+		ChunkSize: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, result.Transfer.ID, rows[0].ID)
+	require.Equal(t, int64(2), rows[0].EntryCount)
+	require.Equal(t, int64(-10), rows[0].Debited)
+	require.Equal(t, int64(10), rows[0].Credited)
+	require.False(t, rows[0].ToAmount.Valid)
+}
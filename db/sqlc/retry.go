@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// TxConfig controls how execTx runs transactions. Serialization failures and deadlocks are retried from the start
+// MaxRetries times, waiting a random time up to RetryBackoff doubled on every attempt and capped at MaxRetryBackoff
+type TxConfig struct {
+	IsolationLevel  sql.IsolationLevel
+	MaxRetries      int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+}
+
+// DefaultTxConfig runs transactions at serializable, the budget is sized for a handful of transfers racing on the
+// same account, where every waiter fails once the transfer ahead of it commits
+var DefaultTxConfig = TxConfig{
+	IsolationLevel:  sql.LevelSerializable,
+	MaxRetries:      10,
+	RetryBackoff:    5 * time.Millisecond,
+	MaxRetryBackoff: 200 * time.Millisecond,
+}
+
+// ParseIsolationLevel maps the isolation level names used in the config to their database/sql level
+func ParseIsolationLevel(name string) (sql.IsolationLevel, error) {
+	switch name {
+	case "serializable":
+		return sql.LevelSerializable, nil
+	case "repeatable_read":
+		return sql.LevelRepeatableRead, nil
+	case "read_committed":
+		return sql.LevelReadCommitted, nil
+	}
+	return sql.LevelDefault, fmt.Errorf("unknown isolation level %q", name)
+}
+
+// TxStats counts the transactions that had to be retried since the store was created
+type TxStats struct {
+	// attempts started again after a serialization failure or deadlock
+	Retries               int64 `json:"retries"`
+	SerializationFailures int64 `json:"serialization_failures"`
+	Deadlocks             int64 `json:"deadlocks"`
+	// transactions that still failed after the last retry
+	Exhausted int64 `json:"exhausted"`
+}
+
+type txCounters struct {
+	retries               atomic.Int64
+	serializationFailures atomic.Int64
+	deadlocks             atomic.Int64
+	exhausted             atomic.Int64
+}
+
+func (store *SqlStore) TxStats() TxStats {
+	return TxStats{
+		Retries:               store.counters.retries.Load(),
+		SerializationFailures: store.counters.serializationFailures.Load(),
+		Deadlocks:             store.counters.deadlocks.Load(),
+		Exhausted:             store.counters.exhausted.Load(),
+	}
+}
+
+// retryableCode returns the code of a serialization failure or deadlock, the errors that go away when the
+// transaction is run again
+func retryableCode(err error) (string, bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return "", false
+	}
+	code := string(pqErr.Code)
+	return code, code == serializationFailure || code == deadlockDetected
+}
+
+// countFailure records a retryable failure and reports whether the transaction may run again
+func (store *SqlStore) countFailure(code string, attempt int) bool {
+	if code == deadlockDetected {
+		store.counters.deadlocks.Add(1)
+	} else {
+		store.counters.serializationFailures.Add(1)
+	}
+	if attempt >= store.config.MaxRetries {
+		store.counters.exhausted.Add(1)
+		return false
+	}
+	store.counters.retries.Add(1)
+	return true
+}
+
+// retryBackoff picks the wait before retry number attempt+1 with full jitter, so transactions that failed together
+// do not collide again
+func retryBackoff(config TxConfig, attempt int) time.Duration {
+	ceiling := config.RetryBackoff
+	for i := 0; i < attempt && ceiling < config.MaxRetryBackoff; i++ {
+		ceiling *= 2
+	}
+	ceiling = min(ceiling, config.MaxRetryBackoff)
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling) + 1
+}
+
+// sleep waits for d or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
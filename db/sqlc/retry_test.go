@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIsolationLevel(t *testing.T) {
+	level, err := ParseIsolationLevel("serializable")
+	require.NoError(t, err)
+	require.Equal(t, sql.LevelSerializable, level)
+
+	level, err = ParseIsolationLevel("read_committed")
+	require.NoError(t, err)
+	require.Equal(t, sql.LevelReadCommitted, level)
+
+	_, err = ParseIsolationLevel("snapshot")
+	require.Error(t, err)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	config := TxConfig{RetryBackoff: 10 * time.Millisecond, MaxRetryBackoff: 50 * time.Millisecond}
+	for attempt := 0; attempt < 10; attempt++ {
+		ceiling := min(config.RetryBackoff<<attempt, config.MaxRetryBackoff)
+		for i := 0; i < 100; i++ {
+			backoff := retryBackoff(config, attempt)
+			require.Positive(t, backoff)
+			require.LessOrEqual(t, backoff, ceiling)
+		}
+	}
+}
+
+func TestExecTxRetry(t *testing.T) {
+	store := &SqlStore{
+		Queries: New(testDB),
+		db:      testDB,
+		config:  TxConfig{IsolationLevel: sql.LevelSerializable, MaxRetries: 2},
+	}
+
+	// a wrapped serialization failure is retried until it goes away
+	attempts := 0
+	err := store.execTx(context.Background(), func(queries *Queries) error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("transfer: %w", &pq.Error{Code: serializationFailure})
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, TxStats{Retries: 2, SerializationFailures: 2}, store.TxStats())
+
+	// a deadlock is given up on after the last retry
+	attempts = 0
+	err = store.execTx(context.Background(), func(queries *Queries) error {
+		attempts++
+		return &pq.Error{Code: deadlockDetected}
+	})
+	var pqErr *pq.Error
+	require.ErrorAs(t, err, &pqErr)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, TxStats{Retries: 4, SerializationFailures: 2, Deadlocks: 3, Exhausted: 1}, store.TxStats())
+
+	// other errors are returned straight away
+	attempts = 0
+	err = store.execTx(context.Background(), func(queries *Queries) error {
+		attempts++
+		return ErrInsufficientFunds
+	})
+	require.True(t, errors.Is(err, ErrInsufficientFunds))
+	require.Equal(t, 1, attempts)
+}
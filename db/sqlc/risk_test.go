@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomRiskAssessment(t *testing.T, fromAccount Account, toAccount Account, outcome RiskOutcome) RiskAssessment {
+	arg := CreateRiskAssessmentParams{
+		Username:      fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        100,
+		Outcome:       outcome,
+		Decisions:     json.RawMessage(`[{"rule": "new_payee", "outcome": "allow"}]`),
+	}
+	if outcome != RiskOutcomeAllow {
+		arg.Rule = "new_payee"
+		arg.ReasonCode = "new_payee"
+		arg.Reason = "first payment to this account"
+	}
+	assessment, err := testQueries.CreateRiskAssessment(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, assessment.ID)
+	require.Equal(t, arg.Outcome, assessment.Outcome)
+	require.Equal(t, arg.ReasonCode, assessment.ReasonCode)
+	require.JSONEq(t, string(arg.Decisions), string(assessment.Decisions))
+	require.NotZero(t, assessment.CreatedAt)
+	return assessment
+}
+
+func TestListRiskAssessments(t *testing.T) {
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+	denied := createRandomRiskAssessment(t, fromAccount, toAccount, RiskOutcomeDeny)
+	createRandomRiskAssessment(t, fromAccount, toAccount, RiskOutcomeAllow)
+
+	assessments, err := testQueries.ListRiskAssessments(context.Background(), ListRiskAssessmentsParams{
+		Outcome: NullRiskOutcome{RiskOutcome: RiskOutcomeDeny, Valid: true},
+		Limit:   100,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, assessments)
+	found := false
+	for _, assessment := range assessments {
+		require.Equal(t, RiskOutcomeDeny, assessment.Outcome)
+		found = found || assessment.ID == denied.ID
+	}
+	require.True(t, found)
+
+	// without an outcome every assessment is listed, newest first
+	assessments, err = testQueries.ListRiskAssessments(context.Background(), ListRiskAssessmentsParams{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, assessments, 2)
+	require.Greater(t, assessments[0].ID, assessments[1].ID)
+}
+
+func TestOwnerTransferHistory(t *testing.T) {
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+	since := time.Now().Add(-time.Minute)
+
+	paid, err := testQueries.HasPaidAccount(context.Background(), HasPaidAccountParams{Owner: fromAccount.Owner, ToAccountID: toAccount.ID})
+	require.NoError(t, err)
+	require.False(t, paid)
+
+	createRandomTransfer(t, fromAccount, toAccount)
+	createRandomTransfer(t, fromAccount, toAccount)
+	// transfers into the owner's account are not counted
+	createRandomTransfer(t, toAccount, fromAccount)
+
+	count, err := testQueries.CountOwnerTransfersSince(context.Background(), CountOwnerTransfersSinceParams{Owner: fromAccount.Owner, Since: since})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+
+	paid, err = testQueries.HasPaidAccount(context.Background(), HasPaidAccountParams{Owner: fromAccount.Owner, ToAccountID: toAccount.ID})
+	require.NoError(t, err)
+	require.True(t, paid)
+}
+
+func TestReviewPendingTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+	approver := createRandomUser(t).Username
+	reviewer := createAdminUser(t).Username
+	assessment := createRandomRiskAssessment(t, fromAccount, toAccount, RiskOutcomeReview)
+	_, err := store.SetApprovalPolicyTx(context.Background(), SetApprovalPolicyTxParams{
+		AccountID:         fromAccount.ID,
+		RequiredApprovals: 1,
+		Approvers:         []string{approver},
+	})
+	require.NoError(t, err)
+
+	pending, err := store.CreatePendingTransfer(context.Background(), CreatePendingTransferParams{
+		FromAccountID:     fromAccount.ID,
+		ToAccountID:       toAccount.ID,
+		Amount:            500,
+		Metadata:          json.RawMessage("{}"),
+		InitiatedBy:       fromAccount.Owner,
+		RequiredApprovals: 1,
+		RiskAssessmentID:  sql.NullInt64{Int64: assessment.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// the approval alone does not execute a transfer in review
+	approved, err := store.ApprovePendingTransferTx(context.Background(), ApprovePendingTransferTxParams{ID: pending.ID, Username: approver})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), approved.ApprovalCount)
+	require.Equal(t, PendingTransferStatusPending, approved.Status)
+
+	inReview, err := store.ListPendingTransfersInReview(context.Background(), ListPendingTransfersInReviewParams{Limit: 1000})
+	require.NoError(t, err)
+	require.Contains(t, pendingTransferIDs(inReview), pending.ID)
+
+	// the review does
+	reviewed, err := store.ReviewPendingTransferTx(context.Background(), ReviewPendingTransferTxParams{ID: pending.ID, Username: reviewer})
+	require.NoError(t, err)
+	require.Equal(t, reviewer, reviewed.ReviewedBy.String)
+	require.True(t, reviewed.ReviewedAt.Valid)
+	require.Equal(t, PendingTransferStatusExecuted, reviewed.Status)
+
+	_, err = store.ReviewPendingTransferTx(context.Background(), ReviewPendingTransferTxParams{ID: pending.ID, Username: reviewer})
+	require.ErrorIs(t, err, ErrPendingTransferDecided)
+
+	// a transfer the risk engine did not hold has nothing to review
+	plain, err := store.CreatePendingTransfer(context.Background(), CreatePendingTransferParams{
+		FromAccountID:     fromAccount.ID,
+		ToAccountID:       toAccount.ID,
+		Amount:            10,
+		Metadata:          json.RawMessage("{}"),
+		InitiatedBy:       fromAccount.Owner,
+		RequiredApprovals: 1,
+	})
+	require.NoError(t, err)
+	_, err = store.ReviewPendingTransferTx(context.Background(), ReviewPendingTransferTxParams{ID: plain.ID, Username: reviewer})
+	require.ErrorIs(t, err, ErrNoReviewPending)
+}
+
+func pendingTransferIDs(transfers []PendingTransfer) []int64 {
+	ids := make([]int64, 0, len(transfers))
+	for _, pending := range transfers {
+		ids = append(ids, pending.ID)
+	}
+	return ids
+}
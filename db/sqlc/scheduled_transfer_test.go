@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomScheduledTransfer(t *testing.T, fromAccount Account, toAccount Account, executeAt time.Time) ScheduledTransfer {
+	arg := CreateScheduledTransferParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        10,
+		ExecuteAt:     executeAt,
+	}
+	scheduled, err := testQueries.CreateScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, scheduled.ID)
+	require.Equal(t, arg.FromAccountID, scheduled.FromAccountID)
+	require.Equal(t, arg.ToAccountID, scheduled.ToAccountID)
+	require.Equal(t, arg.Amount, scheduled.Amount)
+	require.WithinDuration(t, arg.ExecuteAt, scheduled.ExecuteAt, time.Second)
+	require.Equal(t, ScheduledTransferStatusPending, scheduled.Status)
+	require.False(t, scheduled.TransferID.Valid)
+	return scheduled
+}
+
+func TestExecuteScheduledTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createFundedAccount(t, 15)
+	toAccount := createRandomAccount(t)
+	// far in the past so rows left behind by other tests run first and the second one finds the funds spent
+	succeeding := createRandomScheduledTransfer(t, fromAccount, toAccount, time.Now().Add(-100*365*24*time.Hour))
+	failing := createRandomScheduledTransfer(t, fromAccount, toAccount, time.Now().Add(-100*365*24*time.Hour+time.Second))
+	notDue := createRandomScheduledTransfer(t, fromAccount, toAccount, time.Now().Add(time.Hour))
+
+	executed := make(map[int64]ScheduledTransfer)
+	for {
+		scheduled, err := store.ExecuteScheduledTransferTx(context.Background(), time.Now())
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+		executed[scheduled.ID] = scheduled
+	}
+
+	require.Equal(t, ScheduledTransferStatusSucceeded, executed[succeeding.ID].Status)
+	require.True(t, executed[succeeding.ID].TransferID.Valid)
+	require.True(t, executed[succeeding.ID].ExecutedAt.Valid)
+
+	require.Equal(t, ScheduledTransferStatusFailed, executed[failing.ID].Status)
+	require.Equal(t, ErrInsufficientFunds.Error(), executed[failing.ID].FailureReason.String)
+	require.True(t, executed[failing.ID].ExecutedAt.Valid)
+
+	_, ok := executed[notDue.ID]
+	require.False(t, ok)
+	pending, err := testQueries.GetScheduledTransfer(context.Background(), notDue.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferStatusPending, pending.Status)
+
+	account, err := testQueries.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), account.Balance)
+}
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+	scheduled := createRandomScheduledTransfer(t, fromAccount, toAccount, time.Now().Add(time.Hour))
+
+	canceled, err := testQueries.CancelScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferStatusCanceled, canceled.Status)
+
+	// only a pending transfer can be canceled
+	_, err = testQueries.CancelScheduledTransfer(context.Background(), scheduled.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestExecuteScheduledTransferTxApprovalPolicy(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+	setTestApprovalPolicy(t, store, fromAccount, 5)
+	scheduled := createRandomScheduledTransfer(t, fromAccount, toAccount, time.Now().Add(-100*365*24*time.Hour))
+
+	for {
+		executed, err := store.ExecuteScheduledTransferTx(context.Background(), time.Now())
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+		if executed.ID == scheduled.ID {
+			require.Equal(t, ScheduledTransferStatusFailed, executed.Status)
+			require.Equal(t, ErrApprovalRequired.Error(), executed.FailureReason.String)
+		}
+	}
+	failed, err := testQueries.GetScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferStatusFailed, failed.Status)
+}
@@ -3,27 +3,81 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 )
 
+// ErrInsufficientFunds is returned when a transfer would take the available balance of the source account
+// below its overdraft limit
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
 type Store interface {
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	FxTransferTx(ctx context.Context, arg FxTransferTxParams) (TransferTxResult, error)
+	IdempotentTransferTx(ctx context.Context, arg IdempotentTransferTxParams) (IdempotentTransferTxResult, error)
+	IdempotentPendingTransferTx(ctx context.Context, arg IdempotentPendingTransferTxParams) (IdempotentPendingTransferTxResult, error)
+	StatementTx(ctx context.Context, arg StatementTxParams) (StatementTxResult, error)
+	ExecuteStandingOrderTx(ctx context.Context, arg ExecuteStandingOrderTxParams) (ExecuteStandingOrderTxResult, error)
+	ExecuteScheduledTransferTx(ctx context.Context, now time.Time) (ScheduledTransfer, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error)
+	AuthorizeHoldTx(ctx context.Context, arg AuthorizeHoldTxParams) (HoldTxResult, error)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error)
+	VoidHoldTx(ctx context.Context, holdID int64) (HoldTxResult, error)
+	ExpireHoldTx(ctx context.Context, now time.Time) (HoldTxResult, error)
+	BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error)
+	AccrueInterestTx(ctx context.Context, arg AccrueInterestTxParams) (InterestAccrual, error)
+	PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error)
+	ApprovePendingTransferTx(ctx context.Context, arg ApprovePendingTransferTxParams) (PendingTransfer, error)
+	ReviewPendingTransferTx(ctx context.Context, arg ReviewPendingTransferTxParams) (PendingTransfer, error)
+	RejectPendingTransferTx(ctx context.Context, arg RejectPendingTransferTxParams) (PendingTransfer, error)
+	SetApprovalPolicyTx(ctx context.Context, arg SetApprovalPolicyTxParams) (SetApprovalPolicyTxResult, error)
+	CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (CloseAccountTxResult, error)
+	TxStats() TxStats
 	Querier
 }
 type SqlStore struct {
 	*Queries
-	db *sql.DB
+	db       *sql.DB
+	config   TxConfig
+	counters txCounters
 }
 
 func NewStore(db *sql.DB) Store {
+	return NewStoreWithConfig(db, DefaultTxConfig)
+}
+
+func NewStoreWithConfig(db *sql.DB, config TxConfig) Store {
 	return &SqlStore{
 		Queries: New(db),
 		db:      db,
+		config:  config,
 	}
 }
 
+// execTx runs fn in a transaction at the configured isolation level
 func (store *SqlStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.db.BeginTx(ctx, nil)
+	return store.execTxOptions(ctx, &sql.TxOptions{Isolation: store.config.IsolationLevel}, fn)
+}
+
+// execTxOptions runs fn in a transaction, running it again from the start after a serialization failure or deadlock.
+// fn may be called more than once and must not keep anything from an attempt that failed
+func (store *SqlStore) execTxOptions(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) error {
+	for attempt := 0; ; attempt++ {
+		err := store.runTx(ctx, opts, fn)
+		code, retryable := retryableCode(err)
+		if !retryable || !store.countFailure(code, attempt) {
+			return err
+		}
+		if err := sleep(ctx, retryBackoff(store.config, attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+func (store *SqlStore) runTx(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) error {
+	tx, err := store.db.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
@@ -39,9 +93,13 @@ func (store *SqlStore) execTx(ctx context.Context, fn func(*Queries) error) erro
 }
 
 type TransferTxParams struct {
-	FromAccountId int64 `json:"from_account_id"`
-	ToAccountId   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountId int64  `json:"from_account_id"`
+	ToAccountId   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Description   string `json:"description"`
+	Reference     string `json:"reference"`
+	// Metadata must be a JSON object, empty stores {}
+	Metadata json.RawMessage `json:"metadata"`
 }
 
 type TransferTxResult struct {
@@ -50,6 +108,10 @@ type TransferTxResult struct {
 	Transfer    Transfer `json:"transfer"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
+	// Fee is debited from the source account on top of the transfer amount, in its currency
+	Fee int64 `json:"fee"`
+	// FeeTransfer moves the fee to the fee revenue account, nil when no fee was charged
+	FeeTransfer *Transfer `json:"fee_transfer,omitempty"`
 }
 
 // Performs a money transfer from one account to another. A transfer record, account entries and update account balance in
@@ -59,41 +121,173 @@ func (store *SqlStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 	// begin Transaction
 	err := store.execTx(ctx, func(queries *Queries) error {
 		var err error
-		result.Transfer, err = queries.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountId,
-			ToAccountID:   arg.ToAccountId,
-			Amount:        arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
-		result.FromEntry, err = queries.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountId,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
+		result, err = transfer(ctx, queries, FxTransferTxParams{TransferTxParams: arg})
+		return err
+	})
+	return result, err
+}
+
+// transfer moves money between two accounts using the given queries, it must run inside a transaction. Without an
+// exchange rate the destination is credited the same amount that the source is debited. The fee in the currency of
+// the source account is charged as a second transfer to the fee revenue account
+func transfer(ctx context.Context, queries *Queries, arg FxTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+	if arg.QuoteID.Valid {
+		if err = consumeQuote(ctx, queries, arg.QuoteID.UUID); err != nil {
+			return result, err
 		}
-		result.ToEntry, err = queries.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountId,
-			Amount:    arg.Amount,
-		})
-		if err != nil {
-			return err
+	}
+	createTransferParams := CreateTransferParams{
+		FromAccountID: arg.FromAccountId,
+		ToAccountID:   arg.ToAccountId,
+		Amount:        arg.Amount,
+		ReversalOf:    sql.NullInt64{Int64: arg.reversalOf, Valid: arg.reversalOf > 0},
+		Description:   arg.Description,
+		Reference:     arg.Reference,
+		Metadata:      arg.Metadata,
+	}
+	if len(createTransferParams.Metadata) == 0 {
+		createTransferParams.Metadata = json.RawMessage("{}")
+	}
+	toAmount := arg.Amount
+	if arg.ExchangeRate > 0 {
+		toAmount = arg.ToAmount
+		createTransferParams.ToAmount = sql.NullInt64{Int64: arg.ToAmount, Valid: true}
+		createTransferParams.ExchangeRate = sql.NullInt64{Int64: arg.ExchangeRate, Valid: true}
+	}
+	// lock both accounts, and the fee revenue account when a fee is charged, in id order before checking the balance
+	// to avoid deadlocks. A refund is not charged a fee either
+	charged := arg.reversalOf == 0 && !arg.waiveFee
+	var lockIds []int64
+	var revenueAccountId int64
+	lockIds, result.Fee, revenueAccountId, err = transferLocks(ctx, queries, arg.FromAccountId, arg.ToAccountId, arg.Amount, charged)
+	if err != nil {
+		return result, err
+	}
+	locked, _, err := lockAccountsInOrder(ctx, queries, lockIds)
+	if err != nil {
+		return result, err
+	}
+	fromAccount, toAccount := locked[arg.FromAccountId], locked[arg.ToAccountId]
+	if err = checkAccountsActive(fromAccount, toAccount); err != nil {
+		return result, err
+	}
+	if fromAccount.AvailableBalance-arg.Amount-result.Fee < -fromAccount.OverdraftLimit {
+		return result, ErrInsufficientFunds
+	}
+	now := time.Now()
+	// a refund gives money back and is never held to the limits, product rules or approval policy of the account
+	// refunding it
+	if arg.reversalOf == 0 && !arg.sweep {
+		if err = checkProductRules(ctx, queries, fromAccount, arg.Amount+result.Fee, now); err != nil {
+			return result, err
 		}
-		if arg.FromAccountId < arg.ToAccountId {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, queries, arg.FromAccountId, -arg.Amount, arg.ToAccountId, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, queries, arg.ToAccountId, arg.Amount, arg.FromAccountId, -arg.Amount)
+		if err = checkTransferLimits(ctx, queries, fromAccount, arg.Amount+result.Fee, now); err != nil {
+			return result, err
 		}
-		if err != nil {
-			return err
+		if !arg.approved {
+			if err = checkApprovalPolicy(ctx, queries, arg.FromAccountId, arg.Amount); err != nil {
+				return result, err
+			}
 		}
-		return nil
-	})
+	}
+	result.Transfer, err = queries.CreateTransfer(ctx, createTransferParams)
+	if err != nil {
+		return result, err
+	}
+	result.FromEntry, err = appendEntry(ctx, queries, arg.FromAccountId, -arg.Amount, result.Transfer.ID, now)
+	if err != nil {
+		return result, err
+	}
+	result.ToEntry, err = appendEntry(ctx, queries, arg.ToAccountId, toAmount, result.Transfer.ID, now)
+	if err != nil {
+		return result, err
+	}
+	if arg.FromAccountId < arg.ToAccountId {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, queries, arg.FromAccountId, -arg.Amount, arg.ToAccountId, toAmount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, queries, arg.ToAccountId, toAmount, arg.FromAccountId, -arg.Amount)
+	}
+	if err != nil || result.Fee == 0 {
+		return result, err
+	}
+	result.FeeTransfer, result.FromAccount, err = chargeFee(ctx, queries, result.Transfer, revenueAccountId, result.Fee, now)
 	return result, err
 }
 
+// transferRefused reports whether transfer turned the transfer down before writing anything, in which case the
+// transaction can carry on and record the refusal
+func transferRefused(err error) bool {
+	return errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrTransferLimitExceeded) ||
+		errors.Is(err, ErrProductRuleViolated) || errors.Is(err, ErrAccountNotActive) ||
+		errors.Is(err, ErrApprovalRequired)
+}
+
+// chargeFee moves the fee for a transfer from its source account to the fee revenue account, which must already be
+// locked, returning the fee transfer and the source account after the debit
+func chargeFee(ctx context.Context, q *Queries, charged Transfer, revenueAccountId int64, fee int64, now time.Time) (*Transfer, Account, error) {
+	feeTransfer, err := q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: charged.FromAccountID,
+		ToAccountID:   revenueAccountId,
+		Amount:        fee,
+		Description:   fmt.Sprintf("Fee for transfer %d", charged.ID),
+		Metadata:      json.RawMessage("{}"),
+		FeeOf:         sql.NullInt64{Int64: charged.ID, Valid: true},
+	})
+	if err != nil {
+		return nil, Account{}, err
+	}
+	fromAccount, _, err := addMoney(ctx, q, charged.FromAccountID, -fee, revenueAccountId, fee)
+	if err != nil {
+		return nil, Account{}, err
+	}
+	if _, err = appendEntry(ctx, q, charged.FromAccountID, -fee, feeTransfer.ID, now); err != nil {
+		return nil, Account{}, err
+	}
+	if _, err = appendEntry(ctx, q, revenueAccountId, fee, feeTransfer.ID, now); err != nil {
+		return nil, Account{}, err
+	}
+	return &feeTransfer, fromAccount, nil
+}
+
+// lockAccounts takes a row lock on both accounts, callers must pass the ids in ascending order
+func lockAccounts(ctx context.Context, q *Queries, accountId1 int64, accountId2 int64) (account1 Account, account2 Account, err error) {
+	account1, err = q.GetAccountForUpdate(ctx, accountId1)
+	if err != nil {
+		return
+	}
+	account2, err = q.GetAccountForUpdate(ctx, accountId2)
+	return
+}
+
+// transferLocks returns the ids of the accounts a transfer of amount has to lock. When a fee is charged it is worked
+// out from the currency of the source account and the fee revenue account it is credited to is locked as well, its
+// id is returned so the fee can be charged once the transfer is written. Nothing is locked yet, the currency of an
+// account never changes
+func transferLocks(ctx context.Context, q *Queries, fromAccountId int64, toAccountId int64, amount int64, chargeFee bool) (ids []int64, fee int64, revenueAccountId int64, err error) {
+	ids = []int64{fromAccountId, toAccountId}
+	if !chargeFee {
+		return ids, 0, 0, nil
+	}
+	fromAccount, err := q.GetAccount(ctx, fromAccountId)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	fee, err = TransferFee(ctx, q, fromAccount.Currency, amount)
+	if err != nil || fee == 0 {
+		return ids, 0, 0, err
+	}
+	revenue, err := q.GetSystemAccount(ctx, GetSystemAccountParams{
+		Purpose:  FeeRevenuePurpose,
+		Currency: fromAccount.Currency,
+	})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return append(ids, revenue.AccountID), fee, revenue.AccountID, nil
+}
+
 func addMoney(
 	ctx context.Context,
 	q *Queries,
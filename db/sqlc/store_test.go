@@ -7,10 +7,20 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+func createFundedAccount(t *testing.T, balance int64) Account {
+	account := createRandomAccount(t)
+	account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: balance,
+	})
+	require.NoError(t, err)
+	return account
+}
+
 func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
-	fromAccount := createRandomAccount(t)
+	fromAccount := createFundedAccount(t, 1000)
 	toAccount := createRandomAccount(t)
 	amount := int64(10)
 
@@ -105,8 +115,8 @@ func TestTransferTx(t *testing.T) {
 
 func TestTransferDeadlock(t *testing.T) {
 	store := NewStore(testDB)
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
 	n := 10
 	amount := int64(10)
 	errs := make(chan error)
@@ -145,3 +155,45 @@ func TestTransferDeadlock(t *testing.T) {
 	require.Equal(t, updatedAccount2.Balance, account2.Balance)
 
 }
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createFundedAccount(t, 100)
+	toAccount := createRandomAccount(t)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        101,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// an overdraft limit lets the balance go negative up to the limit
+	_, err = testQueries.SetAccountOverdraftLimit(context.Background(), SetAccountOverdraftLimitParams{
+		ID:             fromAccount.ID,
+		OverdraftLimit: 50,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        150,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-50), result.FromAccount.Balance)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the check constraint rejects negative balances written outside TransferTx
+	_, err = testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     fromAccount.ID,
+		Amount: -1,
+	})
+	require.Error(t, err)
+}
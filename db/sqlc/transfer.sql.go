@@ -7,26 +7,75 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
 )
 
+const countAccountWithdrawalsSince = `-- name: CountAccountWithdrawalsSince :one
+SELECT COUNT(*) FROM transfers
+WHERE from_account_id = $1
+    AND created_at >= $2
+    AND fee_of IS NULL
+    AND reversal_of IS NULL
+`
+
+type CountAccountWithdrawalsSinceParams struct {
+	AccountID int64     `json:"account_id"`
+	Since     time.Time `json:"since"`
+}
+
+// fees and refunds are not withdrawals the account holder made
+func (q *Queries) CountAccountWithdrawalsSince(ctx context.Context, arg CountAccountWithdrawalsSinceParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAccountWithdrawalsSince, arg.AccountID, arg.Since)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    to_amount,
+    exchange_rate,
+    reversal_of,
+    description,
+    reference,
+    metadata,
+    fee_of
 ) VALUES (
-    $1, $2, $3
-) RETURNING id, from_account_id, to_account_id, amount, created_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of, description, reference, metadata, fee_of
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64           `json:"from_account_id"`
+	ToAccountID   int64           `json:"to_account_id"`
+	Amount        int64           `json:"amount"`
+	ToAmount      sql.NullInt64   `json:"to_amount"`
+	ExchangeRate  sql.NullInt64   `json:"exchange_rate"`
+	ReversalOf    sql.NullInt64   `json:"reversal_of"`
+	Description   string          `json:"description"`
+	Reference     string          `json:"reference"`
+	Metadata      json.RawMessage `json:"metadata"`
+	FeeOf         sql.NullInt64   `json:"fee_of"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.ReversalOf,
+		arg.Description,
+		arg.Reference,
+		arg.Metadata,
+		arg.FeeOf,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -34,12 +83,19 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversalOf,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.FeeOf,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of, description, reference, metadata, fee_of
 FROM transfers
 WHERE id = $1 LIMIT 1
 `
@@ -53,12 +109,163 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversalOf,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.FeeOf,
+	)
+	return i, err
+}
+
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of, description, reference, metadata, fee_of
+FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversalOf,
+		&i.Description,
+		&i.Reference,
+		&i.Metadata,
+		&i.FeeOf,
 	)
 	return i, err
 }
 
+const listAccountTransfers = `-- name: ListAccountTransfers :many
+SELECT
+    t.id,
+    t.from_account_id,
+    t.to_account_id,
+    t.amount,
+    t.created_at,
+    t.to_amount,
+    t.exchange_rate,
+    t.reversal_of,
+    t.description,
+    t.reference,
+    t.metadata,
+    t.fee_of,
+    c.id AS counterparty_account_id,
+    c.owner AS counterparty_owner,
+    c.currency AS counterparty_currency
+FROM transfers t
+JOIN accounts c ON c.id = CASE
+    WHEN t.from_account_id = $1 THEN t.to_account_id
+    ELSE t.from_account_id
+END
+WHERE (
+    ($2::bool AND t.from_account_id = $1)
+    OR ($3::bool AND t.to_account_id = $1)
+)
+AND ($4::timestamptz IS NULL OR t.created_at >= $4)
+AND ($5::timestamptz IS NULL OR t.created_at < $5)
+AND ($6::bigint IS NULL OR t.amount >= $6)
+AND ($7::bigint IS NULL OR t.amount <= $7)
+AND ($8::varchar IS NULL OR t.reference = $8)
+ORDER BY t.id DESC
+LIMIT $9
+OFFSET $10
+`
+
+type ListAccountTransfersParams struct {
+	AccountID  int64          `json:"account_id"`
+	Outgoing   bool           `json:"outgoing"`
+	Incoming   bool           `json:"incoming"`
+	StartTime  sql.NullTime   `json:"start_time"`
+	EndTime    sql.NullTime   `json:"end_time"`
+	MinAmount  sql.NullInt64  `json:"min_amount"`
+	MaxAmount  sql.NullInt64  `json:"max_amount"`
+	Reference  sql.NullString `json:"reference"`
+	PageLimit  int32          `json:"page_limit"`
+	PageOffset int32          `json:"page_offset"`
+}
+
+type ListAccountTransfersRow struct {
+	ID                    int64           `json:"id"`
+	FromAccountID         int64           `json:"from_account_id"`
+	ToAccountID           int64           `json:"to_account_id"`
+	Amount                int64           `json:"amount"`
+	CreatedAt             time.Time       `json:"created_at"`
+	ToAmount              sql.NullInt64   `json:"to_amount"`
+	ExchangeRate          sql.NullInt64   `json:"exchange_rate"`
+	ReversalOf            sql.NullInt64   `json:"reversal_of"`
+	Description           string          `json:"description"`
+	Reference             string          `json:"reference"`
+	Metadata              json.RawMessage `json:"metadata"`
+	FeeOf                 sql.NullInt64   `json:"fee_of"`
+	CounterpartyAccountID int64           `json:"counterparty_account_id"`
+	CounterpartyOwner     string          `json:"counterparty_owner"`
+	CounterpartyCurrency  string          `json:"counterparty_currency"`
+}
+
+func (q *Queries) ListAccountTransfers(ctx context.Context, arg ListAccountTransfersParams) ([]ListAccountTransfersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountTransfers,
+		arg.AccountID,
+		arg.Outgoing,
+		arg.Incoming,
+		arg.StartTime,
+		arg.EndTime,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Reference,
+		arg.PageLimit,
+		arg.PageOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountTransfersRow{}
+	for rows.Next() {
+		var i ListAccountTransfersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.ReversalOf,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+			&i.FeeOf,
+			&i.CounterpartyAccountID,
+			&i.CounterpartyOwner,
+			&i.CounterpartyCurrency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reversal_of, description, reference, metadata, fee_of FROM transfers
 WHERE from_account_id = $1 OR to_account_id = $2
 ORDER BY id
 LIMIT $3 
@@ -92,6 +299,13 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.ReversalOf,
+			&i.Description,
+			&i.Reference,
+			&i.Metadata,
+			&i.FeeOf,
 		); err != nil {
 			return nil, err
 		}
@@ -105,3 +319,23 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 	}
 	return items, nil
 }
+
+const sumTransferReversals = `-- name: SumTransferReversals :one
+SELECT
+    COALESCE(SUM(amount), 0)::bigint AS debited,
+    COALESCE(SUM(COALESCE(to_amount, amount)), 0)::bigint AS refunded
+FROM transfers
+WHERE reversal_of = $1
+`
+
+type SumTransferReversalsRow struct {
+	Debited  int64 `json:"debited"`
+	Refunded int64 `json:"refunded"`
+}
+
+func (q *Queries) SumTransferReversals(ctx context.Context, reversalOf sql.NullInt64) (SumTransferReversalsRow, error) {
+	row := q.db.QueryRowContext(ctx, sumTransferReversals, reversalOf)
+	var i SumTransferReversalsRow
+	err := row.Scan(&i.Debited, &i.Refunded)
+	return i, err
+}
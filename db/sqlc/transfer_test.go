@@ -2,6 +2,8 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -15,6 +17,9 @@ func createRandomTransfer(t *testing.T, fromAccount Account, toAccount Account)
 		ToAccountID:   toAccount.ID,
 		FromAccountID: fromAccount.ID,
 		Amount:        utils.GenerateRandomMoney(),
+		Description:   utils.GenerateRandomString(12),
+		Reference:     utils.GenerateRandomString(16),
+		Metadata:      json.RawMessage(`{"source": "test"}`),
 	}
 
 	transfer, err := testQueries.CreateTransfer(context.Background(), arg)
@@ -25,6 +30,9 @@ func createRandomTransfer(t *testing.T, fromAccount Account, toAccount Account)
 	require.Equal(t, arg.ToAccountID, transfer.ToAccountID)
 	require.Equal(t, arg.FromAccountID, transfer.FromAccountID)
 	require.Equal(t, arg.Amount, transfer.Amount)
+	require.Equal(t, arg.Description, transfer.Description)
+	require.Equal(t, arg.Reference, transfer.Reference)
+	require.JSONEq(t, string(arg.Metadata), string(transfer.Metadata))
 	return transfer
 }
 
@@ -69,3 +77,60 @@ func TestListTransfer(t *testing.T) {
 		require.Equal(t, arg.ToAccountID, transfer.ToAccountID)
 	}
 }
+
+func TestListAccountTransfers(t *testing.T) {
+	account := createRandomAccount(t)
+	other := createRandomAccount(t)
+	for i := 0; i < 3; i++ {
+		createRandomTransfer(t, account, other)
+		createRandomTransfer(t, other, account)
+	}
+
+	arg := ListAccountTransfersParams{
+		AccountID:  account.ID,
+		Outgoing:   true,
+		Incoming:   true,
+		PageLimit:  10,
+		PageOffset: 0,
+	}
+	rows, err := testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, rows, 6)
+	for _, row := range rows {
+		require.Equal(t, other.ID, row.CounterpartyAccountID)
+		require.Equal(t, other.Owner, row.CounterpartyOwner)
+		require.Equal(t, other.Currency, row.CounterpartyCurrency)
+	}
+
+	arg.Incoming = false
+	rows, err = testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	for _, row := range rows {
+		require.Equal(t, account.ID, row.FromAccountID)
+	}
+
+	arg.EndTime = sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true}
+	rows, err = testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, rows)
+}
+
+func TestListAccountTransfersByReference(t *testing.T) {
+	account := createRandomAccount(t)
+	other := createRandomAccount(t)
+	createRandomTransfer(t, account, other)
+	transfer := createRandomTransfer(t, other, account)
+
+	rows, err := testQueries.ListAccountTransfers(context.Background(), ListAccountTransfersParams{
+		AccountID: account.ID,
+		Outgoing:  true,
+		Incoming:  true,
+		Reference: sql.NullString{String: transfer.Reference, Valid: true},
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, transfer.ID, rows[0].ID)
+	require.Equal(t, transfer.Description, rows[0].Description)
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrInvalidBatchLeg = errors.New("leg must move a positive amount between two different accounts")
+
+// BatchLegError reports the first leg of a batch that failed, the whole batch is rolled back
+type BatchLegError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchLegError) Error() string {
+	return fmt.Sprintf("leg %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchLegError) Unwrap() error {
+	return e.Err
+}
+
+type BatchTransferTxParams struct {
+	Legs []TransferTxParams `json:"legs"`
+}
+
+type BatchTransferTxResult struct {
+	// Legs holds the result of each leg in the order of the params
+	Legs []TransferTxResult `json:"legs"`
+}
+
+// Performs many transfers in a single all or nothing transaction. Every account involved is locked in id order up
+// front so concurrent batches and transfers cannot deadlock, then the legs run in order so later legs see the balance
+// changes of earlier ones. A failing leg is reported as a *BatchLegError
+func (store *SqlStore) BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error) {
+	for i, leg := range arg.Legs {
+		if leg.Amount <= 0 || leg.FromAccountId == leg.ToAccountId {
+			return BatchTransferTxResult{}, &BatchLegError{Index: i, Err: ErrInvalidBatchLeg}
+		}
+	}
+	var result BatchTransferTxResult
+	err := store.execTx(ctx, func(queries *Queries) error {
+		// every leg that is charged a fee also locks the fee revenue account of its currency
+		accountIds := make([]int64, 0, 3*len(arg.Legs))
+		for i, leg := range arg.Legs {
+			ids, _, _, err := transferLocks(ctx, queries, leg.FromAccountId, leg.ToAccountId, leg.Amount, true)
+			if err != nil {
+				return &BatchLegError{Index: i, Err: err}
+			}
+			accountIds = append(accountIds, ids...)
+		}
+		if _, id, err := lockAccountsInOrder(ctx, queries, accountIds); err != nil {
+			// blame the first leg that uses the account, which is the leg that would have failed without the locking
+			for i, leg := range arg.Legs {
+				if leg.FromAccountId == id || leg.ToAccountId == id {
+					return &BatchLegError{Index: i, Err: err}
+				}
+			}
+			return err
+		}
+
+		// splitting a transfer into legs does not get it under the approval threshold of its source account
+		totals := make(map[int64]int64)
+		for _, leg := range arg.Legs {
+			totals[leg.FromAccountId] += leg.Amount
+		}
+		for i, leg := range arg.Legs {
+			total, ok := totals[leg.FromAccountId]
+			if !ok {
+				continue
+			}
+			delete(totals, leg.FromAccountId)
+			if err := checkApprovalPolicy(ctx, queries, leg.FromAccountId, total); err != nil {
+				return &BatchLegError{Index: i, Err: err}
+			}
+		}
+
+		result.Legs = make([]TransferTxResult, 0, len(arg.Legs))
+		for i, leg := range arg.Legs {
+			legResult, err := transfer(ctx, queries, FxTransferTxParams{TransferTxParams: leg})
+			if err != nil {
+				return &BatchLegError{Index: i, Err: err}
+			}
+			result.Legs = append(result.Legs, legResult)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// lockAccountsInOrder takes a row lock on every account once, in ascending id order whatever the order of ids, and
+// returns the locked accounts by id. On failure it returns the id of the account that could not be locked
+func lockAccountsInOrder(ctx context.Context, q *Queries, ids []int64) (map[int64]Account, int64, error) {
+	sorted := append([]int64(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	locked := make(map[int64]Account, len(sorted))
+	for i, id := range sorted {
+		if i > 0 && id == sorted[i-1] {
+			continue
+		}
+		account, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return nil, id, err
+		}
+		locked[id] = account
+	}
+	return locked, 0, nil
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	payer := createFundedAccount(t, 100)
+	payees := []Account{createRandomAccount(t), createRandomAccount(t), createRandomAccount(t)}
+
+	arg := BatchTransferTxParams{}
+	for _, payee := range payees {
+		arg.Legs = append(arg.Legs, TransferTxParams{FromAccountId: payer.ID, ToAccountId: payee.ID, Amount: 30})
+	}
+	result, err := store.BatchTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, result.Legs, len(payees))
+	for i, leg := range result.Legs {
+		require.Equal(t, payees[i].ID, leg.Transfer.ToAccountID)
+		require.Equal(t, payees[i].Balance+30, leg.ToAccount.Balance)
+		require.Equal(t, int64(100-30*(i+1)), leg.FromAccount.Balance)
+	}
+}
+
+func TestBatchTransferTxRollsBack(t *testing.T) {
+	store := NewStore(testDB)
+	payer := createFundedAccount(t, 50)
+	payee1 := createRandomAccount(t)
+	payee2 := createRandomAccount(t)
+
+	_, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{Legs: []TransferTxParams{
+		{FromAccountId: payer.ID, ToAccountId: payee1.ID, Amount: 30},
+		{FromAccountId: payer.ID, ToAccountId: payee2.ID, Amount: 30},
+	}})
+	var legErr *BatchLegError
+	require.True(t, errors.As(err, &legErr))
+	require.Equal(t, 1, legErr.Index)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// the first leg was rolled back with the second
+	account, err := testQueries.GetAccount(context.Background(), payer.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(50), account.Balance)
+	account, err = testQueries.GetAccount(context.Background(), payee1.ID)
+	require.NoError(t, err)
+	require.Equal(t, payee1.Balance, account.Balance)
+}
+
+func TestBatchTransferTxNoDeadlock(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+	account3 := createFundedAccount(t, 1000)
+
+	// batches touching the same accounts in opposite orders
+	n := 10
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		legs := []TransferTxParams{
+			{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: 10},
+			{FromAccountId: account2.ID, ToAccountId: account3.ID, Amount: 10},
+			{FromAccountId: account3.ID, ToAccountId: account1.ID, Amount: 10},
+		}
+		if i%2 == 1 {
+			legs[0], legs[2] = legs[2], legs[0]
+		}
+		go func() {
+			_, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{Legs: legs})
+			errs <- err
+		}()
+	}
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	for _, account := range []Account{account1, account2, account3} {
+		updated, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, int64(1000), updated.Balance)
+	}
+}
+
+func TestBatchTransferTxInvalidLeg(t *testing.T) {
+	store := NewStore(testDB)
+	account := createFundedAccount(t, 100)
+
+	_, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{Legs: []TransferTxParams{
+		{FromAccountId: account.ID, ToAccountId: account.ID, Amount: 10},
+	}})
+	var legErr *BatchLegError
+	require.True(t, errors.As(err, &legErr))
+	require.Equal(t, 0, legErr.Index)
+	require.ErrorIs(t, err, ErrInvalidBatchLeg)
+}
+
+func TestBatchTransferTxApprovalPolicy(t *testing.T) {
+	store := NewStore(testDB)
+	payer := createFundedAccount(t, 1000)
+	payee1 := createRandomAccount(t)
+	payee2 := createRandomAccount(t)
+	setTestApprovalPolicy(t, store, payer, 100)
+
+	// every leg is below the threshold but together they are above it
+	_, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{Legs: []TransferTxParams{
+		{FromAccountId: payee1.ID, ToAccountId: payee2.ID, Amount: 1},
+		{FromAccountId: payer.ID, ToAccountId: payee1.ID, Amount: 60},
+		{FromAccountId: payer.ID, ToAccountId: payee2.ID, Amount: 60},
+	}})
+	var legErr *BatchLegError
+	require.True(t, errors.As(err, &legErr))
+	require.Equal(t, 1, legErr.Index)
+	require.ErrorIs(t, err, ErrApprovalRequired)
+
+	account, err := testQueries.GetAccount(context.Background(), payer.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), account.Balance)
+}
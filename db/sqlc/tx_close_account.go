@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrAccountNotActive      = errors.New("account is not active")
+	ErrAccountHasHolds       = errors.New("account has active holds")
+	ErrAccountOverdrawn      = errors.New("an overdrawn account cannot be closed")
+	ErrSweepAccountRequired  = errors.New("an account to sweep the remaining balance to is required")
+	ErrInvalidSweepAccount   = errors.New("the remaining balance cannot be swept to the account being closed")
+	ErrAccountStatusConflict = errors.New("account does not have the status the change requires")
+)
+
+// checkAccountsActive rejects a transfer that debits or credits an account which is frozen or closed
+func checkAccountsActive(accounts ...Account) error {
+	for _, account := range accounts {
+		if account.Status != AccountStatusActive {
+			return fmt.Errorf("%w: account %d is %s", ErrAccountNotActive, account.ID, account.Status)
+		}
+	}
+	return nil
+}
+
+type CloseAccountTxParams struct {
+	ID int64 `json:"id"`
+	// SweepToAccountID receives the remaining balance, it may be left zero when there is nothing to sweep
+	SweepToAccountID int64 `json:"sweep_to_account_id"`
+	// ExchangeRate converts the balance when the sweep account holds another currency, scaled by 1e8
+	ExchangeRate int64 `json:"exchange_rate"`
+}
+
+type CloseAccountTxResult struct {
+	Account Account `json:"account"`
+	// Interest is the interest accrued but not yet posted, which is paid in before the sweep
+	Interest int64 `json:"interest"`
+	// Sweep moves the remaining balance, nil when the balance was zero
+	Sweep *TransferTxResult `json:"sweep,omitempty"`
+}
+
+// Closes an active account. Unposted interest is paid in first, then the whole balance is swept to another account
+// free of fees, limits and product rules, and the account is marked closed. Its entries and transfers are kept. Standing
+// orders and scheduled transfers from or to the account are canceled and transfers still waiting for approval fail,
+// none of them could run against a closed account. An account with active holds or a negative balance cannot be closed
+func (store *SqlStore) CloseAccountTx(ctx context.Context, arg CloseAccountTxParams) (CloseAccountTxResult, error) {
+	var result CloseAccountTxResult
+	err := store.execTx(ctx, func(queries *Queries) error {
+		result = CloseAccountTxResult{}
+		if arg.SweepToAccountID == arg.ID {
+			return ErrInvalidSweepAccount
+		}
+		// the workers lock these rows before the accounts, so they are settled before the accounts are locked
+		if err := queries.CancelAccountStandingOrders(ctx, arg.ID); err != nil {
+			return err
+		}
+		if err := queries.CancelAccountScheduledTransfers(ctx, arg.ID); err != nil {
+			return err
+		}
+		err := queries.FailAccountPendingTransfers(ctx, FailAccountPendingTransfersParams{
+			FromAccountID: arg.ID,
+			FailureReason: sql.NullString{String: fmt.Sprintf("account %d was closed", arg.ID), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		// lock both accounts in id order like transfer does, the sweep takes the same locks again
+		var account Account
+		switch {
+		case arg.SweepToAccountID == 0:
+			account, err = queries.GetAccountForUpdate(ctx, arg.ID)
+		case arg.ID < arg.SweepToAccountID:
+			account, _, err = lockAccounts(ctx, queries, arg.ID, arg.SweepToAccountID)
+		default:
+			_, account, err = lockAccounts(ctx, queries, arg.SweepToAccountID, arg.ID)
+		}
+		if err != nil {
+			return err
+		}
+		if err = checkAccountsActive(account); err != nil {
+			return err
+		}
+		if account.HeldAmount > 0 {
+			return ErrAccountHasHolds
+		}
+
+		dayStart, _ := LimitPeriods(time.Now())
+		interest, err := postInterest(ctx, queries, account, dayStart)
+		if err != nil {
+			return err
+		}
+		result.Interest = interest.Amount
+		if interest.Amount > 0 {
+			account = interest.Transfer.ToAccount
+		}
+		if account.Balance < 0 {
+			return ErrAccountOverdrawn
+		}
+
+		if account.Balance > 0 {
+			if arg.SweepToAccountID == 0 {
+				return ErrSweepAccountRequired
+			}
+			sweep := FxTransferTxParams{
+				TransferTxParams: TransferTxParams{
+					FromAccountId: account.ID,
+					ToAccountId:   arg.SweepToAccountID,
+					Amount:        account.Balance,
+					Description:   fmt.Sprintf("Closing balance of account %d", account.ID),
+				},
+				waiveFee: true,
+				sweep:    true,
+			}
+			if arg.ExchangeRate > 0 {
+				sweep.ExchangeRate = arg.ExchangeRate
+				sweep.ToAmount = mulDiv(account.Balance, arg.ExchangeRate, rateScale)
+				if sweep.ToAmount <= 0 {
+					return ErrInvalidExchangeRate
+				}
+			}
+			swept, err := transfer(ctx, queries, sweep)
+			if err != nil {
+				return err
+			}
+			result.Sweep = &swept
+		}
+
+		result.Account, err = queries.TransitionAccountStatus(ctx, TransitionAccountStatusParams{
+			ID:         account.ID,
+			FromStatus: AccountStatusActive,
+			ToStatus:   AccountStatusClosed,
+		})
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitionAccountStatus(t *testing.T) {
+	store := NewStore(testDB)
+	account := createFundedAccount(t, 100)
+	other := createFundedAccount(t, 100)
+
+	frozen, err := testQueries.TransitionAccountStatus(context.Background(), TransitionAccountStatusParams{
+		ID:         account.ID,
+		FromStatus: AccountStatusActive,
+		ToStatus:   AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, frozen.Status)
+	require.True(t, frozen.StatusChangedAt.Valid)
+
+	// a frozen account can neither send nor receive
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountId: account.ID, ToAccountId: other.ID, Amount: 10})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountId: other.ID, ToAccountId: account.ID, Amount: 10})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+	_, err = store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		FromAccountId: account.ID,
+		ToAccountId:   other.ID,
+		Amount:        10,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	// the change only applies from the expected status
+	_, err = testQueries.TransitionAccountStatus(context.Background(), TransitionAccountStatusParams{
+		ID:         account.ID,
+		FromStatus: AccountStatusActive,
+		ToStatus:   AccountStatusFrozen,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	active, err := testQueries.TransitionAccountStatus(context.Background(), TransitionAccountStatusParams{
+		ID:         account.ID,
+		FromStatus: AccountStatusFrozen,
+		ToStatus:   AccountStatusActive,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusActive, active.Status)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountId: account.ID, ToAccountId: other.ID, Amount: 10})
+	require.NoError(t, err)
+}
+
+func TestCloseAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createFundedAccount(t, 100)
+	sweepAccount := createFundedAccount(t, 50)
+
+	result, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{
+		ID:               account.ID,
+		SweepToAccountID: sweepAccount.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, result.Account.Status)
+	require.Zero(t, result.Account.Balance)
+	require.NotNil(t, result.Sweep)
+	require.Equal(t, int64(100), result.Sweep.Transfer.Amount)
+	require.Zero(t, result.Sweep.Fee)
+	require.Equal(t, int64(150), result.Sweep.ToAccount.Balance)
+
+	// the history stays and the account takes no more transfers
+	_, err = testQueries.GetTransfer(context.Background(), result.Sweep.Transfer.ID)
+	require.NoError(t, err)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountId: sweepAccount.ID, ToAccountId: account.ID, Amount: 10})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{ID: account.ID})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+}
+
+func TestCloseAccountTxEmpty(t *testing.T) {
+	store := NewStore(testDB)
+	account := createFundedAccount(t, 0)
+
+	result, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{ID: account.ID})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, result.Account.Status)
+	require.Nil(t, result.Sweep)
+}
+
+func TestCloseAccountTxCancelsPlannedTransfers(t *testing.T) {
+	store := NewStore(testDB)
+	account := createFundedAccount(t, 0)
+	other := createFundedAccount(t, 100)
+	// in the future so the worker tests never pick them up
+	nextRun := time.Now().AddDate(1, 0, 0)
+
+	order, err := testQueries.CreateStandingOrder(context.Background(), CreateStandingOrderParams{
+		FromAccountID:           other.ID,
+		ToAccountID:             account.ID,
+		Amount:                  10,
+		Frequency:               StandingOrderFrequencyWeekly,
+		InsufficientFundsPolicy: InsufficientFundsPolicySkip,
+		OccurrenceAt:            nextRun,
+		NextRunAt:               nextRun,
+	})
+	require.NoError(t, err)
+	scheduled := createRandomScheduledTransfer(t, account, other, nextRun)
+	pending, err := store.CreatePendingTransfer(context.Background(), CreatePendingTransferParams{
+		FromAccountID:     account.ID,
+		ToAccountID:       other.ID,
+		Amount:            10,
+		Metadata:          json.RawMessage("{}"),
+		InitiatedBy:       account.Owner,
+		RequiredApprovals: 1,
+	})
+	require.NoError(t, err)
+	// a transfer between two other accounts is left alone
+	untouched := createRandomScheduledTransfer(t, other, createRandomAccount(t), nextRun)
+
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{ID: account.ID})
+	require.NoError(t, err)
+
+	order, err = testQueries.GetStandingOrder(context.Background(), order.ID)
+	require.NoError(t, err)
+	require.Equal(t, StandingOrderStatusCanceled, order.Status)
+	scheduled, err = testQueries.GetScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferStatusCanceled, scheduled.Status)
+	pending, err = testQueries.GetPendingTransfer(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Equal(t, PendingTransferStatusFailed, pending.Status)
+	require.True(t, pending.DecidedAt.Valid)
+	require.NotEmpty(t, pending.FailureReason.String)
+	untouched, err = testQueries.GetScheduledTransfer(context.Background(), untouched.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferStatusPending, untouched.Status)
+}
+
+func TestCloseAccountTxRejected(t *testing.T) {
+	store := NewStore(testDB)
+	account := createFundedAccount(t, 100)
+	other := createRandomAccount(t)
+
+	_, err := store.CloseAccountTx(context.Background(), CloseAccountTxParams{ID: account.ID})
+	require.ErrorIs(t, err, ErrSweepAccountRequired)
+
+	authorizeTestHold(t, store, account, other, 10, time.Now().Add(time.Hour))
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{ID: account.ID, SweepToAccountID: other.ID})
+	require.ErrorIs(t, err, ErrAccountHasHolds)
+
+	_, err = store.CloseAccountTx(context.Background(), CloseAccountTxParams{ID: account.ID, SweepToAccountID: account.ID})
+	require.ErrorIs(t, err, ErrInvalidSweepAccount)
+
+	// nothing was changed by the failed attempts
+	account, err = testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusActive, account.Status)
+	require.Equal(t, int64(100), account.Balance)
+}
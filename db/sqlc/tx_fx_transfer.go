@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidExchangeRate = errors.New("exchange rate and converted amount must be positive")
+	ErrQuoteExpired        = errors.New("quote expired")
+	ErrQuoteUsed           = errors.New("quote has already been used")
+)
+
+type FxTransferTxParams struct {
+	TransferTxParams
+	// ToAmount is credited to the destination account in its own currency
+	ToAmount int64 `json:"to_amount"`
+	// ExchangeRate is the applied rate scaled by 1e8, zero for a same currency transfer
+	ExchangeRate int64 `json:"exchange_rate"`
+	// QuoteID is a locked quote that is consumed by the transfer
+	QuoteID uuid.NullUUID `json:"quote_id"`
+	// reversalOf links a refund to the transfer it reverses, only ReverseTransferTx sets it
+	reversalOf int64
+	// waiveFee skips the transfer fee, only transfers the bank makes from its own accounts set it
+	waiveFee bool
+	// sweep empties an account that is being closed, which is not held to its limits or product rules. Only
+	// CloseAccountTx sets it
+	sweep bool
+	// approved skips the approval policy of the source account for a pending transfer that got its approvals, only
+	// ApprovePendingTransferTx and ReviewPendingTransferTx set it
+	approved bool
+}
+
+// Performs a cross currency transfer. Amount is debited from the source account in its currency and ToAmount is
+// credited to the destination account in its currency, both amounts and the rate are recorded on the transfer
+func (store *SqlStore) FxTransferTx(ctx context.Context, arg FxTransferTxParams) (TransferTxResult, error) {
+	if arg.ExchangeRate <= 0 || arg.ToAmount <= 0 {
+		return TransferTxResult{}, ErrInvalidExchangeRate
+	}
+	var result TransferTxResult
+	err := store.execTx(ctx, func(queries *Queries) error {
+		var err error
+		result, err = transfer(ctx, queries, arg)
+		return err
+	})
+	return result, err
+}
+
+// consumeQuote marks a locked quote as used so it cannot pay for a second transfer
+func consumeQuote(ctx context.Context, q *Queries, quoteID uuid.UUID) error {
+	quote, err := q.GetFxQuoteForUpdate(ctx, quoteID)
+	if err != nil {
+		return err
+	}
+	if quote.UsedAt.Valid {
+		return ErrQuoteUsed
+	}
+	if time.Now().After(quote.ExpiresAt) {
+		return ErrQuoteExpired
+	}
+	return q.MarkFxQuoteUsed(ctx, quoteID)
+}
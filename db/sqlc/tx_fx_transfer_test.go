@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFxTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+
+	arg := FxTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountId: fromAccount.ID,
+			ToAccountId:   toAccount.ID,
+			Amount:        100,
+		},
+		ToAmount:     135,
+		ExchangeRate: 135_000_000,
+	}
+	result, err := store.FxTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.Amount, result.Transfer.Amount)
+	require.Equal(t, arg.ToAmount, result.Transfer.ToAmount.Int64)
+	require.Equal(t, arg.ExchangeRate, result.Transfer.ExchangeRate.Int64)
+	require.Equal(t, -arg.Amount, result.FromEntry.Amount)
+	require.Equal(t, arg.ToAmount, result.ToEntry.Amount)
+	require.Equal(t, fromAccount.Balance-arg.Amount, result.FromAccount.Balance)
+	require.Equal(t, toAccount.Balance+arg.ToAmount, result.ToAccount.Balance)
+
+	arg.ExchangeRate = 0
+	_, err = store.FxTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInvalidExchangeRate)
+}
+
+func TestFxTransferTxWithQuote(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+	quote := createRandomFxQuote(t, fromAccount.Owner, time.Now().Add(time.Minute))
+
+	arg := FxTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountId: fromAccount.ID,
+			ToAccountId:   toAccount.ID,
+			Amount:        quote.FromAmount,
+		},
+		ToAmount:     quote.ToAmount,
+		ExchangeRate: quote.Rate,
+		QuoteID:      uuid.NullUUID{UUID: quote.ID, Valid: true},
+	}
+	result, err := store.FxTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, quote.ToAmount, result.ToEntry.Amount)
+
+	// a quote is good for one transfer only
+	_, err = store.FxTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrQuoteUsed)
+
+	expired := createRandomFxQuote(t, fromAccount.Owner, time.Now().Add(-time.Minute))
+	arg.QuoteID = uuid.NullUUID{UUID: expired.ID, Valid: true}
+	_, err = store.FxTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrQuoteExpired)
+}
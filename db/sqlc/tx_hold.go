@@ -0,0 +1,210 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrHoldNotActive      = errors.New("hold is no longer active")
+	ErrHoldExpired        = errors.New("hold expired")
+	ErrCaptureExceedsHold = errors.New("capture exceeds the held amount")
+)
+
+type AuthorizeHoldTxParams struct {
+	FromAccountId int64     `json:"from_account_id"`
+	ToAccountId   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type HoldTxResult struct {
+	Hold Hold `json:"hold"`
+	// Account is the from account with its held amount updated
+	Account Account `json:"account"`
+}
+
+// Reserves funds on the from account without moving them. The fee for capturing the whole amount is reserved with
+// it, and both count against the available balance and the transfer limits of the account until the hold is captured,
+// voided or expires. A hold is refused on the same grounds as the transfer capturing it would be
+func (store *SqlStore) AuthorizeHoldTx(ctx context.Context, arg AuthorizeHoldTxParams) (HoldTxResult, error) {
+	var result HoldTxResult
+	err := store.execTx(ctx, func(queries *Queries) error {
+		// lock both accounts in id order like transfer does
+		var account, toAccount Account
+		var err error
+		if arg.FromAccountId < arg.ToAccountId {
+			account, toAccount, err = lockAccounts(ctx, queries, arg.FromAccountId, arg.ToAccountId)
+		} else {
+			toAccount, account, err = lockAccounts(ctx, queries, arg.ToAccountId, arg.FromAccountId)
+		}
+		if err != nil {
+			return err
+		}
+		if err = checkAccountsActive(account, toAccount); err != nil {
+			return err
+		}
+		fee, err := TransferFee(ctx, queries, account.Currency, arg.Amount)
+		if err != nil {
+			return err
+		}
+		if account.AvailableBalance-arg.Amount-fee < -account.OverdraftLimit {
+			return ErrInsufficientFunds
+		}
+		// capturing runs through the same checks again, refusing up front spares the merchant a hold it cannot take
+		now := time.Now()
+		if err = checkProductRules(ctx, queries, account, arg.Amount+fee, now); err != nil {
+			return err
+		}
+		if err = checkTransferLimits(ctx, queries, account, arg.Amount+fee, now); err != nil {
+			return err
+		}
+		if err = checkApprovalPolicy(ctx, queries, arg.FromAccountId, arg.Amount); err != nil {
+			return err
+		}
+		result.Hold, err = queries.CreateHold(ctx, CreateHoldParams{
+			FromAccountID: arg.FromAccountId,
+			ToAccountID:   arg.ToAccountId,
+			Amount:        arg.Amount,
+			ExpiresAt:     arg.ExpiresAt,
+			Fee:           fee,
+		})
+		if err != nil {
+			return err
+		}
+		result.Account, err = queries.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+			ID:     arg.FromAccountId,
+			Amount: arg.Amount + fee,
+		})
+		return err
+	})
+	return result, err
+}
+
+type CaptureHoldTxParams struct {
+	HoldID int64 `json:"hold_id"`
+	// Amount is transferred to the to account, zero captures the whole hold
+	Amount int64 `json:"amount"`
+}
+
+type CaptureHoldTxResult struct {
+	Hold Hold `json:"hold"`
+	TransferTxResult
+}
+
+// Converts a hold into a transfer to the to account. The whole hold is released with its reserved fee before the
+// transfer is charged its own fee, so whatever is not captured becomes available again
+func (store *SqlStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+	err := store.execTx(ctx, func(queries *Queries) error {
+		hold, err := activeHold(ctx, queries, arg.HoldID)
+		if err != nil {
+			return err
+		}
+		amount := arg.Amount
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount > hold.Amount {
+			return ErrCaptureExceedsHold
+		}
+
+		// lock the accounts in id order before releasing the hold, transfer takes the same locks again
+		lockIds, _, _, err := transferLocks(ctx, queries, hold.FromAccountID, hold.ToAccountID, amount, true)
+		if err != nil {
+			return err
+		}
+		if _, _, err = lockAccountsInOrder(ctx, queries, lockIds); err != nil {
+			return err
+		}
+		_, err = queries.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+			ID:     hold.FromAccountID,
+			Amount: -(hold.Amount + hold.Fee),
+		})
+		if err != nil {
+			return err
+		}
+		result.TransferTxResult, err = transfer(ctx, queries, FxTransferTxParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountId: hold.FromAccountID,
+				ToAccountId:   hold.ToAccountID,
+				Amount:        amount,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		result.Hold, err = queries.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+			ID:         hold.ID,
+			Status:     HoldStatusCaptured,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+	return result, err
+}
+
+// Releases a hold without moving any money
+func (store *SqlStore) VoidHoldTx(ctx context.Context, holdID int64) (HoldTxResult, error) {
+	var result HoldTxResult
+	err := store.execTx(ctx, func(queries *Queries) error {
+		hold, err := activeHold(ctx, queries, holdID)
+		if err != nil {
+			return err
+		}
+		result, err = releaseHold(ctx, queries, hold, HoldStatusVoided)
+		return err
+	})
+	return result, err
+}
+
+// Releases the active hold that expired first at or before now. The hold is locked with FOR NO KEY UPDATE SKIP
+// LOCKED so concurrent sweepers each pick a different hold. Returns sql.ErrNoRows when no hold has expired.
+func (store *SqlStore) ExpireHoldTx(ctx context.Context, now time.Time) (HoldTxResult, error) {
+	var result HoldTxResult
+	err := store.execTx(ctx, func(queries *Queries) error {
+		hold, err := queries.GetExpiredHoldForUpdate(ctx, now)
+		if err != nil {
+			return err
+		}
+		result, err = releaseHold(ctx, queries, hold, HoldStatusExpired)
+		return err
+	})
+	return result, err
+}
+
+// activeHold locks the hold and checks it can still be captured or voided
+func activeHold(ctx context.Context, q *Queries, holdID int64) (Hold, error) {
+	hold, err := q.GetHoldForUpdate(ctx, holdID)
+	if err != nil {
+		return hold, err
+	}
+	if hold.Status != HoldStatusActive {
+		return hold, ErrHoldNotActive
+	}
+	// an expired hold the sweeper has not reached yet is only waiting to be released
+	if !time.Now().Before(hold.ExpiresAt) {
+		return hold, ErrHoldExpired
+	}
+	return hold, nil
+}
+
+// releaseHold gives the held amount and fee back to the available balance and closes the hold with the given status
+func releaseHold(ctx context.Context, q *Queries, hold Hold, status HoldStatus) (HoldTxResult, error) {
+	var result HoldTxResult
+	var err error
+	result.Account, err = q.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+		ID:     hold.FromAccountID,
+		Amount: -(hold.Amount + hold.Fee),
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Hold, err = q.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+		ID:     hold.ID,
+		Status: status,
+	})
+	return result, err
+}
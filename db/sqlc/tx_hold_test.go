@@ -0,0 +1,225 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func authorizeTestHold(t *testing.T, store Store, from, to Account, amount int64, expiresAt time.Time) HoldTxResult {
+	result, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		FromAccountId: from.ID,
+		ToAccountId:   to.ID,
+		Amount:        amount,
+		ExpiresAt:     expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusActive, result.Hold.Status)
+	return result
+}
+
+func TestAuthorizeHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createFundedAccount(t, 100)
+	toAccount := createRandomAccount(t)
+
+	result := authorizeTestHold(t, store, fromAccount, toAccount, 60, time.Now().Add(time.Hour))
+	require.Equal(t, int64(100), result.Account.Balance)
+	require.Equal(t, int64(60), result.Account.HeldAmount)
+	require.Equal(t, int64(40), result.Account.AvailableBalance)
+
+	// the hold counts against both new holds and transfers
+	_, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        41,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        41,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestCaptureHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createFundedAccount(t, 100)
+	toAccount := createRandomAccount(t)
+	hold := authorizeTestHold(t, store, fromAccount, toAccount, 60, time.Now().Add(time.Hour)).Hold
+
+	_, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID, Amount: 61})
+	require.ErrorIs(t, err, ErrCaptureExceedsHold)
+
+	// capturing part of the hold releases the rest
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID, Amount: 25})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusCaptured, result.Hold.Status)
+	require.Equal(t, result.Transfer.ID, result.Hold.TransferID.Int64)
+	require.Equal(t, int64(25), result.Transfer.Amount)
+	require.Equal(t, int64(75), result.FromAccount.Balance)
+	require.Zero(t, result.FromAccount.HeldAmount)
+	require.Equal(t, int64(75), result.FromAccount.AvailableBalance)
+	require.Equal(t, toAccount.Balance+25, result.ToAccount.Balance)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID})
+	require.ErrorIs(t, err, ErrHoldNotActive)
+}
+
+func TestCaptureHoldTxWithFee(t *testing.T) {
+	store := NewStore(testDB)
+	_, err := testQueries.UpsertFeeSchedule(context.Background(), UpsertFeeScheduleParams{
+		Currency: utils.SGD,
+		FlatFee:  10,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, err := testQueries.DeleteFeeSchedule(context.Background(), utils.SGD)
+		require.NoError(t, err)
+	})
+	fromAccount := createCurrencyAccount(t, utils.SGD, 100)
+	toAccount := createCurrencyAccount(t, utils.SGD, 0)
+
+	// the fee is reserved with the amount, so the whole balance cannot be held
+	_, err = store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        100,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	authorized := authorizeTestHold(t, store, fromAccount, toAccount, 90, time.Now().Add(time.Hour))
+	require.Equal(t, int64(10), authorized.Hold.Fee)
+	require.Equal(t, int64(100), authorized.Account.HeldAmount)
+	require.Zero(t, authorized.Account.AvailableBalance)
+
+	// a hold that reserved everything can still be captured with its fee
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: authorized.Hold.ID})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), result.Fee)
+	require.Zero(t, result.FromAccount.Balance)
+	require.Zero(t, result.FromAccount.HeldAmount)
+	require.Equal(t, int64(90), result.ToAccount.Balance)
+}
+
+func TestVoidHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createFundedAccount(t, 100)
+	toAccount := createRandomAccount(t)
+	hold := authorizeTestHold(t, store, fromAccount, toAccount, 60, time.Now().Add(time.Hour)).Hold
+
+	result, err := store.VoidHoldTx(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusVoided, result.Hold.Status)
+	require.False(t, result.Hold.TransferID.Valid)
+	require.Equal(t, int64(100), result.Account.Balance)
+	require.Equal(t, int64(100), result.Account.AvailableBalance)
+
+	_, err = store.VoidHoldTx(context.Background(), hold.ID)
+	require.ErrorIs(t, err, ErrHoldNotActive)
+}
+
+func TestExpireHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createFundedAccount(t, 100)
+	toAccount := createRandomAccount(t)
+	hold := authorizeTestHold(t, store, fromAccount, toAccount, 60, time.Now().Add(time.Minute)).Hold
+
+	_, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID})
+	require.NoError(t, err)
+	hold = authorizeTestHold(t, store, fromAccount, toAccount, 20, time.Now().Add(time.Minute)).Hold
+
+	// sweep as of a later time, other tests may have left expired holds behind so drain until ours is released
+	for {
+		result, err := store.ExpireHoldTx(context.Background(), time.Now().Add(time.Hour))
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, HoldStatusExpired, result.Hold.Status)
+	}
+	hold, err = testQueries.GetHold(context.Background(), hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusExpired, hold.Status)
+
+	account, err := testQueries.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Zero(t, account.HeldAmount)
+	require.Equal(t, int64(40), account.AvailableBalance)
+}
+
+func TestHoldTxApprovalPolicy(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+
+	// a hold taken before the policy was set is held to it when captured
+	result := authorizeTestHold(t, store, fromAccount, toAccount, 200, time.Now().Add(time.Hour))
+	setTestApprovalPolicy(t, store, fromAccount, 100)
+
+	_, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        101,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrApprovalRequired)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: result.Hold.ID})
+	require.ErrorIs(t, err, ErrApprovalRequired)
+
+	// a partial capture within the threshold goes through
+	captured, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: result.Hold.ID, Amount: 100})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusCaptured, captured.Hold.Status)
+}
+
+func TestAuthorizeHoldTxRefused(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+	authorize := func(from, to Account, amount int64) error {
+		_, err := store.AuthorizeHoldTx(context.Background(), AuthorizeHoldTxParams{
+			FromAccountId: from.ID,
+			ToAccountId:   to.ID,
+			Amount:        amount,
+			ExpiresAt:     time.Now().Add(time.Hour),
+		})
+		return err
+	}
+
+	// the funds held count against the daily limit like a transfer would
+	_, err := store.UpsertAccountLimits(context.Background(), UpsertAccountLimitsParams{
+		AccountID:  fromAccount.ID,
+		DailyLimit: sql.NullInt64{Int64: 100, Valid: true},
+	})
+	require.NoError(t, err)
+	authorizeTestHold(t, store, fromAccount, toAccount, 60, time.Now().Add(time.Hour))
+	require.ErrorIs(t, authorize(fromAccount, toAccount, 60), ErrTransferLimitExceeded)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        60,
+	})
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+
+	// a frozen receiver could never be paid
+	frozen, err := testQueries.TransitionAccountStatus(context.Background(), TransitionAccountStatusParams{
+		ID:         createRandomAccount(t).ID,
+		FromStatus: AccountStatusActive,
+		ToStatus:   AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	require.ErrorIs(t, authorize(fromAccount, frozen, 10), ErrAccountNotActive)
+
+	// nor can an account whose product does not send transfers
+	product := createRandomProduct(t, UpsertProductParams{CanBeSource: false})
+	require.ErrorIs(t, authorize(createProductAccount(t, product, 100), toAccount, 10), ErrProductRuleViolated)
+}
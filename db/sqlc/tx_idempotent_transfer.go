@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is presented again with a different request
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+
+type IdempotentTransferTxParams struct {
+	FxTransferTxParams
+	Username    string `json:"username"`
+	Key         string `json:"key"`
+	RequestHash string `json:"request_hash"`
+	// keys created before this instant are expired and may be used again
+	ExpiredBefore time.Time `json:"expired_before"`
+}
+
+type IdempotentTransferTxResult struct {
+	TransferTxResult
+	// Replayed is set when the result was read back from an earlier request with the same key
+	Replayed bool `json:"-"`
+}
+
+// Performs a money transfer at most once per idempotency key. The key, the request hash and the transfer result are
+// stored in the same transaction as the transfer, so a repeated request replays the stored result instead
+func (store *SqlStore) IdempotentTransferTx(ctx context.Context, arg IdempotentTransferTxParams) (IdempotentTransferTxResult, error) {
+	var result IdempotentTransferTxResult
+	err := store.execTx(ctx, func(queries *Queries) error {
+		stored, replay, err := useIdempotencyKey(ctx, queries, arg.Username, arg.Key, arg.RequestHash, arg.ExpiredBefore)
+		if err != nil {
+			return err
+		}
+		if replay {
+			// the same request was held for approval the first time, it has no transfer to replay
+			if stored.PendingTransferID.Valid {
+				return ErrIdempotencyKeyReused
+			}
+			result.Replayed = true
+			return json.Unmarshal(stored.Response, &result.TransferTxResult)
+		}
+
+		result.TransferTxResult, err = transfer(ctx, queries, arg.FxTransferTxParams)
+		if err != nil {
+			return err
+		}
+		response, err := json.Marshal(result.TransferTxResult)
+		if err != nil {
+			return err
+		}
+		return queries.UpdateIdempotencyKeyResponse(ctx, UpdateIdempotencyKeyResponseParams{
+			Username: arg.Username,
+			Key:      arg.Key,
+			Response: response,
+		})
+	})
+	return result, err
+}
+
+type IdempotentPendingTransferTxParams struct {
+	CreatePendingTransferParams
+	Key         string `json:"key"`
+	RequestHash string `json:"request_hash"`
+	// keys created before this instant are expired and may be used again
+	ExpiredBefore time.Time `json:"expired_before"`
+}
+
+type IdempotentPendingTransferTxResult struct {
+	PendingTransfer PendingTransfer `json:"pending_transfer"`
+	// Replayed is set when the pending transfer was created by an earlier request with the same key
+	Replayed bool `json:"-"`
+}
+
+// Holds a transfer for approval at most once per idempotency key of the initiator. The key is stored with the pending
+// transfer in the same transaction, so a repeated request returns the pending transfer instead of holding another
+func (store *SqlStore) IdempotentPendingTransferTx(ctx context.Context, arg IdempotentPendingTransferTxParams) (IdempotentPendingTransferTxResult, error) {
+	var result IdempotentPendingTransferTxResult
+	err := store.execTx(ctx, func(queries *Queries) error {
+		stored, replay, err := useIdempotencyKey(ctx, queries, arg.InitiatedBy, arg.Key, arg.RequestHash, arg.ExpiredBefore)
+		if err != nil {
+			return err
+		}
+		if replay {
+			// the same request ran straight away the first time, there is no pending transfer to return
+			if !stored.PendingTransferID.Valid {
+				return ErrIdempotencyKeyReused
+			}
+			result.Replayed = true
+			result.PendingTransfer, err = queries.GetPendingTransfer(ctx, stored.PendingTransferID.Int64)
+			return err
+		}
+
+		result.PendingTransfer, err = queries.CreatePendingTransfer(ctx, arg.CreatePendingTransferParams)
+		if err != nil {
+			return err
+		}
+		return queries.SetIdempotencyKeyPendingTransfer(ctx, SetIdempotencyKeyPendingTransferParams{
+			Username:          arg.InitiatedBy,
+			Key:               arg.Key,
+			PendingTransferID: sql.NullInt64{Int64: result.PendingTransfer.ID, Valid: true},
+		})
+	})
+	return result, err
+}
+
+// useIdempotencyKey stores a new key, replacing it if it expired. A key that is still stored is returned with replay
+// set when it was used for the same request, and ErrIdempotencyKeyReused when it was not
+func useIdempotencyKey(ctx context.Context, q *Queries, username string, key string, requestHash string, expiredBefore time.Time) (IdempotencyKey, bool, error) {
+	err := q.DeleteExpiredIdempotencyKey(ctx, DeleteExpiredIdempotencyKeyParams{
+		Username:  username,
+		Key:       key,
+		CreatedAt: expiredBefore,
+	})
+	if err != nil {
+		return IdempotencyKey{}, false, err
+	}
+	// a concurrent request with the same key blocks here until the first one commits or rolls back
+	stored, err := q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+		Username:    username,
+		Key:         key,
+		RequestHash: requestHash,
+	})
+	if err != sql.ErrNoRows {
+		return stored, false, err
+	}
+	stored, err = q.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+		Username: username,
+		Key:      key,
+	})
+	if err != nil {
+		return stored, false, err
+	}
+	if stored.RequestHash != requestHash {
+		return stored, false, ErrIdempotencyKeyReused
+	}
+	return stored, true, nil
+}
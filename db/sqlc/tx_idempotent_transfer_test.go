@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotentTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+	amount := int64(10)
+
+	arg := IdempotentTransferTxParams{
+		FxTransferTxParams: FxTransferTxParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountId: fromAccount.ID,
+				ToAccountId:   toAccount.ID,
+				Amount:        amount,
+			},
+		},
+		Username:      fromAccount.Owner,
+		Key:           utils.GenerateRandomString(16),
+		RequestHash:   utils.GenerateRandomString(64),
+		ExpiredBefore: time.Now().Add(-time.Hour),
+	}
+
+	// concurrent retries with the same key only move money once
+	n := 5
+	errs := make(chan error)
+	results := make(chan IdempotentTransferTxResult)
+	for i := 0; i < n; i++ {
+		go func() {
+			result, err := store.IdempotentTransferTx(context.Background(), arg)
+			errs <- err
+			results <- result
+		}()
+	}
+
+	transferIds := make(map[int64]bool)
+	replayed := 0
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+		result := <-results
+		require.NotZero(t, result.Transfer.ID)
+		transferIds[result.Transfer.ID] = true
+		if result.Replayed {
+			replayed++
+		}
+	}
+	require.Len(t, transferIds, 1)
+	require.Equal(t, n-1, replayed)
+
+	updatedFromAccount, err := store.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, fromAccount.Balance-amount, updatedFromAccount.Balance)
+
+	// the same key with a different body is rejected
+	reused := arg
+	reused.Amount = amount + 1
+	reused.RequestHash = utils.GenerateRandomString(64)
+	_, err = store.IdempotentTransferTx(context.Background(), reused)
+	require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+
+	// once the key has expired it starts a new transfer
+	reused.ExpiredBefore = time.Now().Add(time.Second)
+	result, err := store.IdempotentTransferTx(context.Background(), reused)
+	require.NoError(t, err)
+	require.False(t, result.Replayed)
+	require.NotContains(t, transferIds, result.Transfer.ID)
+}
+
+func TestIdempotentPendingTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+
+	arg := IdempotentPendingTransferTxParams{
+		CreatePendingTransferParams: CreatePendingTransferParams{
+			FromAccountID:     fromAccount.ID,
+			ToAccountID:       toAccount.ID,
+			Amount:            500,
+			Metadata:          json.RawMessage("{}"),
+			InitiatedBy:       fromAccount.Owner,
+			RequiredApprovals: 1,
+		},
+		Key:           utils.GenerateRandomString(16),
+		RequestHash:   utils.GenerateRandomString(64),
+		ExpiredBefore: time.Now().Add(-time.Hour),
+	}
+
+	first, err := store.IdempotentPendingTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, first.Replayed)
+	require.Equal(t, PendingTransferStatusPending, first.PendingTransfer.Status)
+
+	key, err := store.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{Username: fromAccount.Owner, Key: arg.Key})
+	require.NoError(t, err)
+	require.Equal(t, first.PendingTransfer.ID, key.PendingTransferID.Int64)
+
+	// a retry gets the same pending transfer instead of holding a second one
+	retry, err := store.IdempotentPendingTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, retry.Replayed)
+	require.Equal(t, first.PendingTransfer.ID, retry.PendingTransfer.ID)
+
+	// a key held for approval has no transfer result to replay
+	_, err = store.IdempotentTransferTx(context.Background(), IdempotentTransferTxParams{
+		FxTransferTxParams: FxTransferTxParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountId: fromAccount.ID,
+				ToAccountId:   toAccount.ID,
+				Amount:        500,
+			},
+		},
+		Username:      fromAccount.Owner,
+		Key:           arg.Key,
+		RequestHash:   arg.RequestHash,
+		ExpiredBefore: arg.ExpiredBefore,
+	})
+	require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+}
+
+func TestDeleteExpiredIdempotencyKeys(t *testing.T) {
+	user := createRandomUser(t)
+	create := func() IdempotencyKey {
+		key, err := testQueries.CreateIdempotencyKey(context.Background(), CreateIdempotencyKeyParams{
+			Username:    user.Username,
+			Key:         utils.GenerateRandomString(16),
+			RequestHash: utils.GenerateRandomString(64),
+		})
+		require.NoError(t, err)
+		return key
+	}
+	expired := create()
+	kept := create()
+	_, err := testDB.ExecContext(context.Background(), "UPDATE idempotency_keys SET created_at = now() - interval '2 days' WHERE username = $1 AND key = $2", user.Username, expired.Key)
+	require.NoError(t, err)
+
+	// other tests may have left expired keys behind, sweep until none are left
+	for {
+		deleted, err := testQueries.DeleteExpiredIdempotencyKeys(context.Background(), DeleteExpiredIdempotencyKeysParams{
+			Before:    time.Now().Add(-24 * time.Hour),
+			BatchSize: 10,
+		})
+		require.NoError(t, err)
+		if deleted < 10 {
+			break
+		}
+	}
+
+	_, err = testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{Username: user.Username, Key: expired.Key})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = testQueries.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{Username: user.Username, Key: kept.Key})
+	require.NoError(t, err)
+}
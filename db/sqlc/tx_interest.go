@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type AccrueInterestTxParams struct {
+	// days before Before are due, it must be the start of a UTC day
+	Before time.Time `json:"before"`
+}
+
+// Accrues a day of interest for one account, the day after the last one accrued for it or the day it was opened, but
+// never a day before it moved to its current product.
+// The account is locked with FOR NO KEY UPDATE SKIP LOCKED, so concurrent workers each pick a different account and a
+// day is never accrued twice. Returns sql.ErrNoRows when no account is due.
+func (store *SqlStore) AccrueInterestTx(ctx context.Context, arg AccrueInterestTxParams) (InterestAccrual, error) {
+	var result InterestAccrual
+	err := store.execTx(ctx, func(queries *Queries) error {
+		due, err := queries.GetAccountDueForAccrual(ctx, arg.Before)
+		if err != nil {
+			return err
+		}
+		year, month, day := due.AccrualDate.Date()
+		dayStart := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+		// interest is earned on the balance at the end of the day
+		principal, err := BalanceAt(ctx, queries, due.AccountID, dayStart.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+		if due.Compounding == CompoundingDaily {
+			unposted, err := queries.SumUnpostedInterest(ctx, SumUnpostedInterestParams{
+				AccountID: due.AccountID,
+				Before:    dayStart,
+			})
+			if err != nil {
+				return err
+			}
+			principal += unposted
+		}
+
+		amount, remainder := AccrueInterest(principal, due.AnnualInterestRate, due.Remainder)
+		result, err = queries.CreateInterestAccrual(ctx, CreateInterestAccrualParams{
+			AccountID:          due.AccountID,
+			AccrualDate:        dayStart,
+			Principal:          principal,
+			AnnualInterestRate: due.AnnualInterestRate,
+			Amount:             amount,
+			Remainder:          remainder,
+		})
+		return err
+	})
+	return result, err
+}
+
+type PostInterestTxParams struct {
+	// interest accrued for days before Before is posted, it must be the start of a UTC month
+	Before time.Time `json:"before"`
+}
+
+type PostInterestTxResult struct {
+	AccountID int64 `json:"account_id"`
+	// Amount is the interest credited, zero when the accruals added up to nothing and no transfer was made
+	Amount   int64            `json:"amount"`
+	Transfer TransferTxResult `json:"transfer"`
+}
+
+// Posts the unposted interest of one account accrued before Before, crediting it with a transfer from the interest
+// expense account of its currency. The account is locked before the accruals are read, so a concurrent worker that
+// picked the same account finds nothing left to post. Returns sql.ErrNoRows when no account has interest to post.
+func (store *SqlStore) PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+	err := store.execTx(ctx, func(queries *Queries) error {
+		accountID, err := queries.GetAccountDueForInterestPosting(ctx, arg.Before)
+		if err != nil {
+			return err
+		}
+		account, err := queries.GetAccountForUpdate(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		result, err = postInterest(ctx, queries, account, arg.Before)
+		return err
+	})
+	return result, err
+}
+
+// postInterest credits the account, which must be locked, with its unposted interest accrued before the given day
+func postInterest(ctx context.Context, queries *Queries, account Account, before time.Time) (PostInterestTxResult, error) {
+	result := PostInterestTxResult{AccountID: account.ID}
+	var err error
+	result.Amount, err = queries.SumUnpostedInterest(ctx, SumUnpostedInterestParams{
+		AccountID: account.ID,
+		Before:    before,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	posted := MarkInterestAccrualsPostedParams{
+		AccountID: account.ID,
+		Before:    before,
+	}
+	if result.Amount > 0 {
+		expense, err := queries.GetSystemAccount(ctx, GetSystemAccountParams{
+			Purpose:  InterestExpensePurpose,
+			Currency: account.Currency,
+		})
+		if err != nil {
+			return result, err
+		}
+		lastDay := before.AddDate(0, 0, -1)
+		result.Transfer, err = transfer(ctx, queries, FxTransferTxParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountId: expense.AccountID,
+				ToAccountId:   account.ID,
+				Amount:        result.Amount,
+				Description:   fmt.Sprintf("Interest to %s", lastDay.Format("2006-01-02")),
+			},
+			waiveFee: true,
+		})
+		if err != nil {
+			return result, err
+		}
+		posted.TransferID = sql.NullInt64{Int64: result.Transfer.Transfer.ID, Valid: true}
+	}
+	_, err = queries.MarkInterestAccrualsPosted(ctx, posted)
+	return result, err
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func createSavingsAccount(t *testing.T, store Store, balance int64) Account {
+	owner := createRandomUser(t)
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:       owner.Username,
+		Currency:    utils.USD,
+		ProductCode: DefaultProductCode,
+	})
+	require.NoError(t, err)
+	account, err = testQueries.SetAccountProduct(context.Background(), SetAccountProductParams{
+		ID:          account.ID,
+		ProductCode: "savings",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "savings", account.ProductCode)
+
+	// interest is earned on the entries, so the balance is paid in rather than written
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: createFundedAccount(t, balance).ID,
+		ToAccountId:   account.ID,
+		Amount:        balance,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestAccrueAndPostInterestTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createSavingsAccount(t, store, 1_000_000)
+
+	today, _ := LimitPeriods(time.Now())
+	tomorrow := today.AddDate(0, 0, 1)
+
+	// other savings accounts may be due as well
+	for {
+		_, err := store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{Before: tomorrow})
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+	}
+	accrued, err := store.SumUnpostedInterest(context.Background(), SumUnpostedInterestParams{
+		AccountID: account.ID,
+		Before:    tomorrow,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(54), accrued)
+
+	var posted PostInterestTxResult
+	for {
+		result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{Before: tomorrow})
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+		if result.AccountID == account.ID {
+			posted = result
+		}
+	}
+	require.Equal(t, int64(54), posted.Amount)
+	require.Equal(t, account.ID, posted.Transfer.ToAccount.ID)
+	require.Equal(t, int64(1_000_054), posted.Transfer.ToAccount.Balance)
+	require.Equal(t, "Interest to "+today.Format("2006-01-02"), posted.Transfer.Transfer.Description)
+
+	expense, err := store.GetSystemAccount(context.Background(), GetSystemAccountParams{
+		Purpose:  InterestExpensePurpose,
+		Currency: utils.USD,
+	})
+	require.NoError(t, err)
+	require.Equal(t, expense.AccountID, posted.Transfer.FromAccount.ID)
+
+	accrued, err = store.SumUnpostedInterest(context.Background(), SumUnpostedInterestParams{
+		AccountID: account.ID,
+		Before:    tomorrow,
+	})
+	require.NoError(t, err)
+	require.Zero(t, accrued)
+}
+
+func TestAccrueInterestTxAfterProductChange(t *testing.T) {
+	store := NewStore(testDB)
+	owner := createRandomUser(t)
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:       owner.Username,
+		Currency:    utils.USD,
+		ProductCode: DefaultProductCode,
+	})
+	require.NoError(t, err)
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: createFundedAccount(t, 1_000_000).ID,
+		ToAccountId:   account.ID,
+		Amount:        1_000_000,
+	})
+	require.NoError(t, err)
+
+	// a checking account that has held its balance for a month never accrued anything at its 0% rate
+	_, err = testDB.ExecContext(context.Background(), "UPDATE accounts SET created_at = now() - interval '30 days' WHERE id = $1", account.ID)
+	require.NoError(t, err)
+	_, err = testDB.ExecContext(context.Background(), "UPDATE entries SET created_at = now() - interval '30 days' WHERE account_id = $1", account.ID)
+	require.NoError(t, err)
+
+	account, err = testQueries.SetAccountProduct(context.Background(), SetAccountProductParams{
+		ID:          account.ID,
+		ProductCode: "savings",
+	})
+	require.NoError(t, err)
+	require.True(t, account.ProductChangedAt.Valid)
+
+	today, _ := LimitPeriods(time.Now())
+	tomorrow := today.AddDate(0, 0, 1)
+	for {
+		_, err := store.AccrueInterestTx(context.Background(), AccrueInterestTxParams{Before: tomorrow})
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	// only the day of the move is accrued at the savings rate, not the month before it
+	accrued, err := store.SumUnpostedInterest(context.Background(), SumUnpostedInterestParams{
+		AccountID: account.ID,
+		Before:    tomorrow,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(54), accrued)
+
+	// setting the same product again does not move the date
+	again, err := testQueries.SetAccountProduct(context.Background(), SetAccountProductParams{
+		ID:          account.ID,
+		ProductCode: "savings",
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ProductChangedAt, again.ProductChangedAt)
+}
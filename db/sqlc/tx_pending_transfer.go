@@ -0,0 +1,276 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/DingBao-sys/simple_bank/utils"
+)
+
+var (
+	ErrPendingTransferDecided = errors.New("pending transfer has already been decided")
+	ErrAlreadyApproved        = errors.New("pending transfer has already been approved by this user")
+	ErrNoReviewPending        = errors.New("pending transfer is not waiting for a risk review")
+	ErrApprovalRequired       = errors.New("transfer is above the approval threshold of the account")
+	ErrInitiatorCannotDecide  = errors.New("the initiator of a transfer cannot approve or reject it")
+	ErrNotApprover            = errors.New("user is not authorised to approve transfers from this account")
+	ErrNotReviewer            = errors.New("only an admin can review a transfer held by the risk engine")
+)
+
+type ApprovePendingTransferTxParams struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+// Records the approval of one user, who must be an approver of the source account other than the one who started the
+// transfer. The approval that brings the count up to the required number also executes the
+// transfer and records the outcome in the same transaction, a transfer refused for lack of funds for example ends up
+// failed with the reason. The pending transfer is locked so concurrent approvals are all counted and only one of them
+// executes it. A transfer held by the risk engine is not executed until it has been reviewed as well.
+func (store *SqlStore) ApprovePendingTransferTx(ctx context.Context, arg ApprovePendingTransferTxParams) (PendingTransfer, error) {
+	var result PendingTransfer
+	err := store.execTx(ctx, func(queries *Queries) error {
+		pending, err := queries.GetPendingTransferForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if pending.Status != PendingTransferStatusPending {
+			return ErrPendingTransferDecided
+		}
+		if err = checkApprover(ctx, queries, pending, arg.Username); err != nil {
+			return err
+		}
+		added, err := queries.AddPendingTransferApproval(ctx, AddPendingTransferApprovalParams{
+			PendingTransferID: pending.ID,
+			Username:          arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+		if added == 0 {
+			return ErrAlreadyApproved
+		}
+		result, err = queries.IncrementPendingTransferApprovals(ctx, pending.ID)
+		if err != nil {
+			return err
+		}
+		if readyToRun(result) {
+			result, err = executePendingTransfer(ctx, queries, result)
+		}
+		return err
+	})
+	return result, err
+}
+
+type ReviewPendingTransferTxParams struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+// Records the review of a transfer held by the risk engine by an admin other than the one who started it, and executes
+// it when it has all the approvals it needs, the same way as ApprovePendingTransferTx.
+func (store *SqlStore) ReviewPendingTransferTx(ctx context.Context, arg ReviewPendingTransferTxParams) (PendingTransfer, error) {
+	var result PendingTransfer
+	err := store.execTx(ctx, func(queries *Queries) error {
+		pending, err := queries.GetPendingTransferForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if pending.Status != PendingTransferStatusPending {
+			return ErrPendingTransferDecided
+		}
+		if err = checkReviewer(ctx, queries, pending, arg.Username); err != nil {
+			return err
+		}
+		result, err = queries.MarkPendingTransferReviewed(ctx, MarkPendingTransferReviewedParams{
+			ID:         pending.ID,
+			ReviewedBy: sql.NullString{String: arg.Username, Valid: true},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoReviewPending
+		}
+		if err != nil {
+			return err
+		}
+		if readyToRun(result) {
+			result, err = executePendingTransfer(ctx, queries, result)
+		}
+		return err
+	})
+	return result, err
+}
+
+type RejectPendingTransferTxParams struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+// Rejects a pending transfer on behalf of an approver of its source account, or an admin while the risk engine holds it
+// for review. The user who started the transfer cannot reject it.
+func (store *SqlStore) RejectPendingTransferTx(ctx context.Context, arg RejectPendingTransferTxParams) (PendingTransfer, error) {
+	var result PendingTransfer
+	err := store.execTx(ctx, func(queries *Queries) error {
+		pending, err := queries.GetPendingTransferForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if pending.Status != PendingTransferStatusPending {
+			return ErrPendingTransferDecided
+		}
+		err = checkReviewer(ctx, queries, pending, arg.Username)
+		if !InReview(pending) || errors.Is(err, ErrNotReviewer) {
+			err = checkApprover(ctx, queries, pending, arg.Username)
+		}
+		if err != nil {
+			return err
+		}
+		result, err = queries.RejectPendingTransfer(ctx, RejectPendingTransferParams{
+			ID:         pending.ID,
+			RejectedBy: sql.NullString{String: arg.Username, Valid: true},
+		})
+		return err
+	})
+	return result, err
+}
+
+// checkApprover refuses a decision from the user who started the transfer or from a user who is not an approver of
+// its source account. A transfer that needs no approvals is only decided by a review
+func checkApprover(ctx context.Context, q *Queries, pending PendingTransfer, username string) error {
+	if pending.InitiatedBy == username {
+		return ErrInitiatorCannotDecide
+	}
+	if pending.RequiredApprovals == 0 {
+		return ErrNotReviewer
+	}
+	approver, err := q.IsAccountApprover(ctx, IsAccountApproverParams{
+		AccountID: pending.FromAccountID,
+		Username:  username,
+	})
+	if err != nil {
+		return err
+	}
+	if !approver {
+		return ErrNotApprover
+	}
+	return nil
+}
+
+// checkReviewer refuses a review from the user who started the transfer or from a user who is not an admin
+func checkReviewer(ctx context.Context, q *Queries, pending PendingTransfer, username string) error {
+	if pending.InitiatedBy == username {
+		return ErrInitiatorCannotDecide
+	}
+	user, err := q.GetUser(ctx, username)
+	if err != nil {
+		return err
+	}
+	if user.Role != utils.AdminRole {
+		return ErrNotReviewer
+	}
+	return nil
+}
+
+// InReview reports whether the risk engine held the transfer and no admin has reviewed it yet
+func InReview(pending PendingTransfer) bool {
+	return pending.RiskAssessmentID.Valid && !pending.ReviewedBy.Valid
+}
+
+// a pending transfer runs once it has its approvals and, when the risk engine held it, a review
+func readyToRun(pending PendingTransfer) bool {
+	reviewed := !pending.RiskAssessmentID.Valid || pending.ReviewedBy.Valid
+	return reviewed && pending.ApprovalCount >= pending.RequiredApprovals
+}
+
+// checkApprovalPolicy refuses an amount above the approval threshold of the account, such a transfer has to go
+// through a pending transfer and its approvals
+func checkApprovalPolicy(ctx context.Context, q *Queries, accountId int64, amount int64) error {
+	policy, err := q.GetApprovalPolicy(ctx, accountId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if amount > policy.Threshold {
+		return ErrApprovalRequired
+	}
+	return nil
+}
+
+// executePendingTransfer claims a locked transfer that is ready to run, executes it and records the outcome
+func executePendingTransfer(ctx context.Context, queries *Queries, pending PendingTransfer) (PendingTransfer, error) {
+	pending, err := queries.ClaimPendingTransfer(ctx, pending.ID)
+	if err != nil {
+		return pending, err
+	}
+	transferResult, err := transfer(ctx, queries, FxTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountId: pending.FromAccountID,
+			ToAccountId:   pending.ToAccountID,
+			Amount:        pending.Amount,
+			Description:   pending.Description,
+			Reference:     pending.Reference,
+			Metadata:      pending.Metadata,
+		},
+		approved: true,
+	})
+	switch {
+	case err == nil:
+		return queries.CompletePendingTransfer(ctx, CompletePendingTransferParams{
+			ID:         pending.ID,
+			TransferID: sql.NullInt64{Int64: transferResult.Transfer.ID, Valid: true},
+		})
+	case transferRefused(err):
+		return queries.FailPendingTransfer(ctx, FailPendingTransferParams{
+			ID:            pending.ID,
+			FailureReason: sql.NullString{String: err.Error(), Valid: true},
+		})
+	}
+	return pending, err
+}
+
+type SetApprovalPolicyTxParams struct {
+	AccountID         int64    `json:"account_id"`
+	Threshold         int64    `json:"threshold"`
+	RequiredApprovals int32    `json:"required_approvals"`
+	Approvers         []string `json:"approvers"`
+}
+
+type SetApprovalPolicyTxResult struct {
+	Policy    ApprovalPolicy    `json:"policy"`
+	Approvers []AccountApprover `json:"approvers"`
+}
+
+// Sets the approval policy of an account and replaces its approvers. Transfers already pending keep the number of
+// approvals they were started with.
+func (store *SqlStore) SetApprovalPolicyTx(ctx context.Context, arg SetApprovalPolicyTxParams) (SetApprovalPolicyTxResult, error) {
+	var result SetApprovalPolicyTxResult
+	err := store.execTx(ctx, func(queries *Queries) error {
+		var err error
+		result.Policy, err = queries.UpsertApprovalPolicy(ctx, UpsertApprovalPolicyParams{
+			AccountID:         arg.AccountID,
+			Threshold:         arg.Threshold,
+			RequiredApprovals: arg.RequiredApprovals,
+		})
+		if err != nil {
+			return err
+		}
+		if err := queries.DeleteAccountApprovers(ctx, arg.AccountID); err != nil {
+			return err
+		}
+		result.Approvers = make([]AccountApprover, 0, len(arg.Approvers))
+		for _, username := range arg.Approvers {
+			approver, err := queries.AddAccountApprover(ctx, AddAccountApproverParams{
+				AccountID: arg.AccountID,
+				Username:  username,
+			})
+			if err != nil {
+				return err
+			}
+			result.Approvers = append(result.Approvers, approver)
+		}
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,235 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprovePendingTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+	approvers := []string{createRandomUser(t).Username, createRandomUser(t).Username, createRandomUser(t).Username}
+
+	policy, err := store.SetApprovalPolicyTx(context.Background(), SetApprovalPolicyTxParams{
+		AccountID:         fromAccount.ID,
+		Threshold:         100,
+		RequiredApprovals: 2,
+		Approvers:         approvers,
+	})
+	require.NoError(t, err)
+	require.Len(t, policy.Approvers, 3)
+
+	pending, err := store.CreatePendingTransfer(context.Background(), CreatePendingTransferParams{
+		FromAccountID:     fromAccount.ID,
+		ToAccountID:       toAccount.ID,
+		Amount:            500,
+		Metadata:          json.RawMessage("{}"),
+		InitiatedBy:       fromAccount.Owner,
+		RequiredApprovals: policy.Policy.RequiredApprovals,
+	})
+	require.NoError(t, err)
+	require.Equal(t, PendingTransferStatusPending, pending.Status)
+
+	// the first approval is only counted
+	approved, err := store.ApprovePendingTransferTx(context.Background(), ApprovePendingTransferTxParams{ID: pending.ID, Username: approvers[0]})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), approved.ApprovalCount)
+	require.Equal(t, PendingTransferStatusPending, approved.Status)
+
+	// approving twice does not count twice
+	_, err = store.ApprovePendingTransferTx(context.Background(), ApprovePendingTransferTxParams{ID: pending.ID, Username: approvers[0]})
+	require.ErrorIs(t, err, ErrAlreadyApproved)
+
+	// the second executes it
+	approved, err = store.ApprovePendingTransferTx(context.Background(), ApprovePendingTransferTxParams{ID: pending.ID, Username: approvers[1]})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), approved.ApprovalCount)
+	require.Equal(t, PendingTransferStatusExecuted, approved.Status)
+	require.True(t, approved.DecidedAt.Valid)
+	require.True(t, approved.TransferID.Valid)
+	account, err := store.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(500), account.Balance)
+
+	_, err = store.ApprovePendingTransferTx(context.Background(), ApprovePendingTransferTxParams{ID: pending.ID, Username: approvers[2]})
+	require.ErrorIs(t, err, ErrPendingTransferDecided)
+}
+
+func TestConcurrentApprovePendingTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+	n := 5
+	approvers := make([]string, n)
+	for i := range approvers {
+		approvers[i] = createRandomUser(t).Username
+	}
+	_, err := store.SetApprovalPolicyTx(context.Background(), SetApprovalPolicyTxParams{
+		AccountID:         fromAccount.ID,
+		RequiredApprovals: 2,
+		Approvers:         approvers,
+	})
+	require.NoError(t, err)
+	pending, err := store.CreatePendingTransfer(context.Background(), CreatePendingTransferParams{
+		FromAccountID:     fromAccount.ID,
+		ToAccountID:       toAccount.ID,
+		Amount:            10,
+		Metadata:          json.RawMessage("{}"),
+		InitiatedBy:       fromAccount.Owner,
+		RequiredApprovals: 2,
+	})
+	require.NoError(t, err)
+
+	// everyone approves at once, exactly one approval executes the transfer
+	type outcome struct {
+		result PendingTransfer
+		err    error
+	}
+	outcomes := make(chan outcome)
+	for _, approver := range approvers {
+		go func(username string) {
+			result, err := store.ApprovePendingTransferTx(context.Background(), ApprovePendingTransferTxParams{ID: pending.ID, Username: username})
+			outcomes <- outcome{result, err}
+		}(approver)
+	}
+	claimed, decided := 0, 0
+	for i := 0; i < n; i++ {
+		outcome := <-outcomes
+		if outcome.err != nil {
+			require.ErrorIs(t, outcome.err, ErrPendingTransferDecided)
+			decided++
+			continue
+		}
+		if outcome.result.Status == PendingTransferStatusExecuted {
+			claimed++
+		}
+	}
+	require.Equal(t, 1, claimed)
+	require.Equal(t, n-2, decided)
+}
+
+func TestApprovePendingTransferTxRefused(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 100)
+	toAccount := createRandomAccount(t)
+	approver := createRandomUser(t).Username
+	_, err := store.SetApprovalPolicyTx(context.Background(), SetApprovalPolicyTxParams{
+		AccountID:         fromAccount.ID,
+		RequiredApprovals: 1,
+		Approvers:         []string{approver},
+	})
+	require.NoError(t, err)
+	pending, err := store.CreatePendingTransfer(context.Background(), CreatePendingTransferParams{
+		FromAccountID:     fromAccount.ID,
+		ToAccountID:       toAccount.ID,
+		Amount:            500,
+		Metadata:          json.RawMessage("{}"),
+		InitiatedBy:       fromAccount.Owner,
+		RequiredApprovals: 1,
+	})
+	require.NoError(t, err)
+
+	// the refusal is recorded along with the approval
+	failed, err := store.ApprovePendingTransferTx(context.Background(), ApprovePendingTransferTxParams{ID: pending.ID, Username: approver})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failed.ApprovalCount)
+	require.Equal(t, PendingTransferStatusFailed, failed.Status)
+	require.Equal(t, ErrInsufficientFunds.Error(), failed.FailureReason.String)
+	require.False(t, failed.TransferID.Valid)
+}
+
+func TestPendingTransferTxDeciders(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+	initiator := fromAccount.Owner
+	approver := createRandomUser(t).Username
+	// the initiator being an approver of the account does not let them decide their own transfer
+	_, err := store.SetApprovalPolicyTx(context.Background(), SetApprovalPolicyTxParams{
+		AccountID:         fromAccount.ID,
+		RequiredApprovals: 1,
+		Approvers:         []string{initiator, approver},
+	})
+	require.NoError(t, err)
+	assessment := createRandomRiskAssessment(t, fromAccount, toAccount, RiskOutcomeReview)
+	pending, err := store.CreatePendingTransfer(context.Background(), CreatePendingTransferParams{
+		FromAccountID:     fromAccount.ID,
+		ToAccountID:       toAccount.ID,
+		Amount:            500,
+		Metadata:          json.RawMessage("{}"),
+		InitiatedBy:       initiator,
+		RequiredApprovals: 1,
+		RiskAssessmentID:  sql.NullInt64{Int64: assessment.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = store.ApprovePendingTransferTx(context.Background(), ApprovePendingTransferTxParams{ID: pending.ID, Username: initiator})
+	require.ErrorIs(t, err, ErrInitiatorCannotDecide)
+	_, err = store.RejectPendingTransferTx(context.Background(), RejectPendingTransferTxParams{ID: pending.ID, Username: initiator})
+	require.ErrorIs(t, err, ErrInitiatorCannotDecide)
+	_, err = store.ApprovePendingTransferTx(context.Background(), ApprovePendingTransferTxParams{ID: pending.ID, Username: createRandomUser(t).Username})
+	require.ErrorIs(t, err, ErrNotApprover)
+	_, err = store.ReviewPendingTransferTx(context.Background(), ReviewPendingTransferTxParams{ID: pending.ID, Username: approver})
+	require.ErrorIs(t, err, ErrNotReviewer)
+
+	// the database refuses the initiator too, whoever the caller is
+	added, err := testQueries.AddPendingTransferApproval(context.Background(), AddPendingTransferApprovalParams{
+		PendingTransferID: pending.ID,
+		Username:          initiator,
+	})
+	require.NoError(t, err)
+	require.Zero(t, added)
+	_, err = testQueries.RejectPendingTransfer(context.Background(), RejectPendingTransferParams{
+		ID:         pending.ID,
+		RejectedBy: sql.NullString{String: initiator, Valid: true},
+	})
+	require.Error(t, err)
+
+	// an admin may reject a transfer in review without being an approver
+	rejected, err := store.RejectPendingTransferTx(context.Background(), RejectPendingTransferTxParams{ID: pending.ID, Username: createAdminUser(t).Username})
+	require.NoError(t, err)
+	require.Equal(t, PendingTransferStatusRejected, rejected.Status)
+	_, err = store.RejectPendingTransferTx(context.Background(), RejectPendingTransferTxParams{ID: pending.ID, Username: approver})
+	require.ErrorIs(t, err, ErrPendingTransferDecided)
+}
+
+// setTestApprovalPolicy makes transfers above threshold from the account wait for one approval
+func setTestApprovalPolicy(t *testing.T, store Store, account Account, threshold int64) {
+	_, err := store.SetApprovalPolicyTx(context.Background(), SetApprovalPolicyTxParams{
+		AccountID:         account.ID,
+		Threshold:         threshold,
+		RequiredApprovals: 1,
+		Approvers:         []string{createRandomUser(t).Username},
+	})
+	require.NoError(t, err)
+}
+
+func TestTransferTxApprovalPolicy(t *testing.T) {
+	store := NewStore(testDB)
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+	setTestApprovalPolicy(t, store, fromAccount, 100)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        101,
+	})
+	require.ErrorIs(t, err, ErrApprovalRequired)
+
+	// the threshold itself runs straight away
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+}
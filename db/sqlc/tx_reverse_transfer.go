@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+)
+
+// rates on transfers are scaled by 1e8, the same as fx.RateScale
+const rateScale = 100_000_000
+
+var (
+	ErrReversalExceedsTransfer = errors.New("refund exceeds the amount left on the transfer")
+	ErrReversalOfReversal      = errors.New("a reversal cannot be reversed")
+)
+
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	// Amount is refunded to the sender in the currency of the original debit, zero refunds whatever is left
+	Amount int64 `json:"amount"`
+}
+
+// Refunds all or part of a transfer. The refund is a new transfer in the opposite direction linked to the original
+// through reversal_of. The original transfer is locked so concurrent refunds cannot exceed its amount in total.
+// A cross currency transfer is debited from the receiver in proportion to what they were credited
+func (store *SqlStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	err := store.execTx(ctx, func(queries *Queries) error {
+		original, err := queries.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+		if original.ReversalOf.Valid {
+			return ErrReversalOfReversal
+		}
+		reversed, err := queries.SumTransferReversals(ctx, sql.NullInt64{Int64: original.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+
+		remaining := original.Amount - reversed.Refunded
+		refund := arg.Amount
+		if refund == 0 {
+			refund = remaining
+		}
+		if refund <= 0 || refund > remaining {
+			return ErrReversalExceedsTransfer
+		}
+
+		reversal := FxTransferTxParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountId: original.ToAccountID,
+				ToAccountId:   original.FromAccountID,
+				Amount:        refund,
+			},
+			reversalOf: original.ID,
+		}
+		if original.ExchangeRate.Valid {
+			// the last refund takes whatever is left so rounding never strands part of the credited amount
+			debit := original.ToAmount.Int64 - reversed.Debited
+			if refund < remaining {
+				debit = mulDiv(refund, original.ToAmount.Int64, original.Amount)
+			}
+			if debit <= 0 {
+				return ErrInvalidExchangeRate
+			}
+			reversal.Amount = debit
+			reversal.ToAmount = refund
+			reversal.ExchangeRate = mulDiv(original.Amount, rateScale, original.ToAmount.Int64)
+			if reversal.ExchangeRate <= 0 {
+				return ErrInvalidExchangeRate
+			}
+		}
+		result, err = transfer(ctx, queries, reversal)
+		return err
+	})
+	return result, err
+}
+
+// mulDiv returns a*b/c rounded down without overflowing in between
+func mulDiv(a, b, c int64) int64 {
+	product := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	return product.Quo(product, big.NewInt(c)).Int64()
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+
+	original, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountId: fromAccount.ID,
+		ToAccountId:   toAccount.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+
+	// a partial refund moves money back and links to the original
+	result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     30,
+	})
+	require.NoError(t, err)
+	require.Equal(t, original.Transfer.ID, result.Transfer.ReversalOf.Int64)
+	require.Equal(t, toAccount.ID, result.Transfer.FromAccountID)
+	require.Equal(t, fromAccount.ID, result.Transfer.ToAccountID)
+	require.Equal(t, int64(30), result.Transfer.Amount)
+	require.Equal(t, original.FromAccount.Balance+30, result.ToAccount.Balance)
+
+	// no more than the original can be refunded in total
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     71,
+	})
+	require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+
+	// a zero amount refunds what is left
+	result, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{TransferID: original.Transfer.ID})
+	require.NoError(t, err)
+	require.Equal(t, int64(70), result.Transfer.Amount)
+	require.Equal(t, fromAccount.Balance, result.ToAccount.Balance)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{TransferID: original.Transfer.ID})
+	require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{TransferID: result.Transfer.ID})
+	require.ErrorIs(t, err, ErrReversalOfReversal)
+}
+
+func TestReverseFxTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 1000)
+	toAccount := createRandomAccount(t)
+
+	original, err := store.FxTransferTx(context.Background(), FxTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountId: fromAccount.ID,
+			ToAccountId:   toAccount.ID,
+			Amount:        100,
+		},
+		ToAmount:     135,
+		ExchangeRate: 135_000_000,
+	})
+	require.NoError(t, err)
+
+	// the receiver gives back in proportion to what they got
+	result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     33,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(44), result.Transfer.Amount)
+	require.Equal(t, int64(33), result.Transfer.ToAmount.Int64)
+
+	// and the last refund clears the rest of the credit
+	result, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{TransferID: original.Transfer.ID})
+	require.NoError(t, err)
+	require.Equal(t, int64(91), result.Transfer.Amount)
+	require.Equal(t, int64(67), result.Transfer.ToAmount.Int64)
+	require.Equal(t, toAccount.Balance, result.FromAccount.Balance)
+	require.Equal(t, fromAccount.Balance, result.ToAccount.Balance)
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Executes one scheduled transfer due at now and records its outcome. The row is locked with FOR UPDATE SKIP LOCKED
+// for the whole transaction, so concurrent workers each pick a different transfer, and a crash rolls everything back
+// leaving the transfer pending for the next run. Returns sql.ErrNoRows when no transfer is due.
+func (store *SqlStore) ExecuteScheduledTransferTx(ctx context.Context, now time.Time) (ScheduledTransfer, error) {
+	var result ScheduledTransfer
+	err := store.execTx(ctx, func(queries *Queries) error {
+		scheduled, err := queries.GetDueScheduledTransferForUpdate(ctx, now)
+		if err != nil {
+			return err
+		}
+
+		transferResult, err := transfer(ctx, queries, FxTransferTxParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountId: scheduled.FromAccountID,
+				ToAccountId:   scheduled.ToAccountID,
+				Amount:        scheduled.Amount,
+			},
+		})
+		switch {
+		case err == nil:
+			result, err = queries.CompleteScheduledTransfer(ctx, CompleteScheduledTransferParams{
+				ID:         scheduled.ID,
+				TransferID: sql.NullInt64{Int64: transferResult.Transfer.ID, Valid: true},
+			})
+		case transferRefused(err):
+			result, err = queries.FailScheduledTransfer(ctx, FailScheduledTransferParams{
+				ID:            scheduled.ID,
+				FailureReason: sql.NullString{String: err.Error(), Valid: true},
+			})
+		}
+		return err
+	})
+	return result, err
+}
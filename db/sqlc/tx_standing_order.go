@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ExecuteStandingOrderTxParams struct {
+	// orders whose next run is at or before Now are due
+	Now time.Time `json:"now"`
+	// how long a retrying order waits before the next attempt
+	RetryDelay time.Duration `json:"retry_delay"`
+}
+
+type ExecuteStandingOrderTxResult struct {
+	StandingOrder StandingOrder          `json:"standing_order"`
+	Execution     StandingOrderExecution `json:"execution"`
+}
+
+// Executes the next due occurrence of one standing order. The order is locked with FOR UPDATE SKIP LOCKED for the
+// whole transaction, so concurrent workers each pick a different order and an occurrence is never executed twice.
+// Returns sql.ErrNoRows when no order is due.
+func (store *SqlStore) ExecuteStandingOrderTx(ctx context.Context, arg ExecuteStandingOrderTxParams) (ExecuteStandingOrderTxResult, error) {
+	var result ExecuteStandingOrderTxResult
+	err := store.execTx(ctx, func(queries *Queries) error {
+		order, err := queries.GetDueStandingOrderForUpdate(ctx, arg.Now)
+		if err != nil {
+			return err
+		}
+
+		execution := CreateStandingOrderExecutionParams{
+			StandingOrderID: order.ID,
+			OccurrenceAt:    order.OccurrenceAt,
+			Attempt:         order.RetryCount + 1,
+		}
+		schedule := UpdateStandingOrderScheduleParams{
+			ID:     order.ID,
+			Status: order.Status,
+		}
+		// insufficient funds, exceeded limits and broken product rules are detected before transfer writes anything, so
+		// the transaction can carry on and record them
+		transferResult, err := transfer(ctx, queries, FxTransferTxParams{
+			TransferTxParams: TransferTxParams{
+				FromAccountId: order.FromAccountID,
+				ToAccountId:   order.ToAccountID,
+				Amount:        order.Amount,
+			},
+		})
+		switch {
+		case err == nil:
+			execution.Status = StandingOrderExecutionStatusSucceeded
+			execution.TransferID = sql.NullInt64{Int64: transferResult.Transfer.ID, Valid: true}
+		case transferRefused(err):
+			execution.FailureReason = sql.NullString{String: err.Error(), Valid: true}
+			execution.Status = StandingOrderExecutionStatusSkipped
+			if order.InsufficientFundsPolicy == InsufficientFundsPolicyRetry && order.RetryCount < order.MaxRetries {
+				execution.Status = StandingOrderExecutionStatusRetrying
+			}
+		default:
+			return err
+		}
+
+		if execution.Status == StandingOrderExecutionStatusRetrying {
+			schedule.OccurrenceAt = order.OccurrenceAt
+			schedule.NextRunAt = arg.Now.Add(arg.RetryDelay)
+			schedule.RetryCount = order.RetryCount + 1
+		} else {
+			schedule.OccurrenceAt = NextStandingOrderRun(order.Frequency, order.DayOfMonth, order.OccurrenceAt)
+			schedule.NextRunAt = schedule.OccurrenceAt
+			if order.EndAt.Valid && schedule.OccurrenceAt.After(order.EndAt.Time) {
+				schedule.Status = StandingOrderStatusCompleted
+			}
+		}
+
+		result.Execution, err = queries.CreateStandingOrderExecution(ctx, execution)
+		if err != nil {
+			return err
+		}
+		result.StandingOrder, err = queries.UpdateStandingOrderSchedule(ctx, schedule)
+		return err
+	})
+	return result, err
+}
+
+// FirstStandingOrderRun returns the first occurrence at or after start. Occurrences keep the time of day of start.
+func FirstStandingOrderRun(frequency StandingOrderFrequency, dayOfMonth int32, start time.Time) time.Time {
+	return standingOrderRun(frequency, dayOfMonth, start, true)
+}
+
+// NextStandingOrderRun returns the occurrence following previous
+func NextStandingOrderRun(frequency StandingOrderFrequency, dayOfMonth int32, previous time.Time) time.Time {
+	return standingOrderRun(frequency, dayOfMonth, previous, false)
+}
+
+func standingOrderRun(frequency StandingOrderFrequency, dayOfMonth int32, from time.Time, inclusive bool) time.Time {
+	if frequency == StandingOrderFrequencyWeekly {
+		if inclusive {
+			return from
+		}
+		return from.AddDate(0, 0, 7)
+	}
+	for offset := 0; ; offset++ {
+		run := monthlyRun(frequency, dayOfMonth, from, offset)
+		if run.After(from) || (inclusive && run.Equal(from)) {
+			return run
+		}
+	}
+}
+
+// monthlyRun is the occurrence in the month offset months after the month of from
+func monthlyRun(frequency StandingOrderFrequency, dayOfMonth int32, from time.Time, offset int) time.Time {
+	year, month, _ := from.Date()
+	first := time.Date(year, month+time.Month(offset), 1, from.Hour(), from.Minute(), from.Second(), from.Nanosecond(), from.Location())
+	last := first.AddDate(0, 1, -1)
+	if frequency == StandingOrderFrequencyLastBusinessDay {
+		for last.Weekday() == time.Saturday || last.Weekday() == time.Sunday {
+			last = last.AddDate(0, 0, -1)
+		}
+		return last
+	}
+	if int(dayOfMonth) < last.Day() {
+		return first.AddDate(0, 0, int(dayOfMonth)-1)
+	}
+	return last
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandingOrderRuns(t *testing.T) {
+	start := time.Date(2026, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	require.Equal(t, start, FirstStandingOrderRun(StandingOrderFrequencyWeekly, 0, start))
+	require.Equal(t, start.AddDate(0, 0, 7), NextStandingOrderRun(StandingOrderFrequencyWeekly, 0, start))
+
+	// the 31st falls on the last day of shorter months
+	run := FirstStandingOrderRun(StandingOrderFrequencyMonthly, 31, start)
+	require.Equal(t, start, run)
+	run = NextStandingOrderRun(StandingOrderFrequencyMonthly, 31, run)
+	require.Equal(t, time.Date(2026, time.February, 28, 9, 0, 0, 0, time.UTC), run)
+	run = NextStandingOrderRun(StandingOrderFrequencyMonthly, 31, run)
+	require.Equal(t, time.Date(2026, time.March, 31, 9, 0, 0, 0, time.UTC), run)
+
+	// a day already passed in the start month moves to the next month
+	run = FirstStandingOrderRun(StandingOrderFrequencyMonthly, 15, start)
+	require.Equal(t, time.Date(2026, time.February, 15, 9, 0, 0, 0, time.UTC), run)
+
+	// 31 January 2026 is a Saturday, 27 February a Friday and 31 March a Tuesday
+	run = FirstStandingOrderRun(StandingOrderFrequencyLastBusinessDay, 0, start)
+	require.Equal(t, time.Date(2026, time.February, 27, 9, 0, 0, 0, time.UTC), run)
+	run = NextStandingOrderRun(StandingOrderFrequencyLastBusinessDay, 0, run)
+	require.Equal(t, time.Date(2026, time.March, 31, 9, 0, 0, 0, time.UTC), run)
+}
+
+// executeStandingOrder runs due orders until the given one was executed, orders left behind by other tests may be due too
+func executeStandingOrder(t *testing.T, store Store, arg ExecuteStandingOrderTxParams, orderID int64) ExecuteStandingOrderTxResult {
+	for {
+		result, err := store.ExecuteStandingOrderTx(context.Background(), arg)
+		require.NoError(t, err)
+		if result.StandingOrder.ID == orderID {
+			return result
+		}
+	}
+}
+
+func TestExecuteStandingOrderTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 100)
+	toAccount := createRandomAccount(t)
+	occurrence := time.Date(1950, time.January, 2, 9, 0, 0, 0, time.UTC)
+	retryDelay := time.Hour
+
+	order, err := testQueries.CreateStandingOrder(context.Background(), CreateStandingOrderParams{
+		FromAccountID:           fromAccount.ID,
+		ToAccountID:             toAccount.ID,
+		Amount:                  60,
+		Frequency:               StandingOrderFrequencyWeekly,
+		InsufficientFundsPolicy: InsufficientFundsPolicyRetry,
+		MaxRetries:              1,
+		OccurrenceAt:            occurrence,
+		NextRunAt:               occurrence,
+		EndAt:                   sql.NullTime{Time: occurrence.AddDate(0, 0, 10), Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, StandingOrderStatusActive, order.Status)
+
+	// the first occurrence is paid
+	result := executeStandingOrder(t, store, ExecuteStandingOrderTxParams{Now: occurrence, RetryDelay: retryDelay}, order.ID)
+	require.Equal(t, StandingOrderExecutionStatusSucceeded, result.Execution.Status)
+	require.True(t, result.Execution.TransferID.Valid)
+	require.Equal(t, int32(1), result.Execution.Attempt)
+	require.WithinDuration(t, occurrence.AddDate(0, 0, 7), result.StandingOrder.NextRunAt, time.Second)
+
+	// the second one is short of funds and retried once
+	now := occurrence.AddDate(0, 0, 7)
+	result = executeStandingOrder(t, store, ExecuteStandingOrderTxParams{Now: now, RetryDelay: retryDelay}, order.ID)
+	require.Equal(t, StandingOrderExecutionStatusRetrying, result.Execution.Status)
+	require.Equal(t, ErrInsufficientFunds.Error(), result.Execution.FailureReason.String)
+	require.Equal(t, int32(1), result.StandingOrder.RetryCount)
+	require.WithinDuration(t, now, result.StandingOrder.OccurrenceAt, time.Second)
+	require.WithinDuration(t, now.Add(retryDelay), result.StandingOrder.NextRunAt, time.Second)
+
+	// then skipped, which moves past the end date
+	now = now.Add(retryDelay)
+	result = executeStandingOrder(t, store, ExecuteStandingOrderTxParams{Now: now, RetryDelay: retryDelay}, order.ID)
+	require.Equal(t, StandingOrderExecutionStatusSkipped, result.Execution.Status)
+	require.Equal(t, int32(2), result.Execution.Attempt)
+	require.Equal(t, int32(0), result.StandingOrder.RetryCount)
+	require.Equal(t, StandingOrderStatusCompleted, result.StandingOrder.Status)
+
+	executions, err := testQueries.ListStandingOrderExecutions(context.Background(), ListStandingOrderExecutionsParams{
+		StandingOrderID: order.ID,
+		Limit:           10,
+		Offset:          0,
+	})
+	require.NoError(t, err)
+	require.Len(t, executions, 3)
+
+	account, err := testQueries.GetAccount(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Equal(t, fromAccount.Balance-60, account.Balance)
+}
+
+func TestExecuteStandingOrderTxApprovalPolicy(t *testing.T) {
+	store := NewStore(testDB)
+
+	fromAccount := createFundedAccount(t, 100)
+	toAccount := createRandomAccount(t)
+	setTestApprovalPolicy(t, store, fromAccount, 50)
+	occurrence := time.Date(1950, time.January, 2, 9, 0, 0, 0, time.UTC)
+
+	order, err := testQueries.CreateStandingOrder(context.Background(), CreateStandingOrderParams{
+		FromAccountID:           fromAccount.ID,
+		ToAccountID:             toAccount.ID,
+		Amount:                  60,
+		Frequency:               StandingOrderFrequencyWeekly,
+		InsufficientFundsPolicy: InsufficientFundsPolicySkip,
+		OccurrenceAt:            occurrence,
+		NextRunAt:               occurrence,
+	})
+	require.NoError(t, err)
+
+	// the occurrence is skipped rather than paid without approval
+	result := executeStandingOrder(t, store, ExecuteStandingOrderTxParams{Now: occurrence, RetryDelay: time.Hour}, order.ID)
+	require.Equal(t, StandingOrderExecutionStatusSkipped, result.Execution.Status)
+	require.Equal(t, ErrApprovalRequired.Error(), result.Execution.FailureReason.String)
+	require.False(t, result.Execution.TransferID.Valid)
+}
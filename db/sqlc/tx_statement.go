@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type StatementTxParams struct {
+	AccountID int64     `json:"account_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type StatementLine struct {
+	ListStatementEntriesRow
+	RunningBalance int64 `json:"running_balance"`
+}
+
+type StatementTxResult struct {
+	Account        Account         `json:"account"`
+	StartTime      time.Time       `json:"start_time"`
+	EndTime        time.Time       `json:"end_time"`
+	OpeningBalance int64           `json:"opening_balance"`
+	ClosingBalance int64           `json:"closing_balance"`
+	Lines          []StatementLine `json:"lines"`
+	GeneratedAt    time.Time       `json:"generated_at"`
+}
+
+// Builds an account statement for [StartTime, EndTime) from the entries ledger. Everything is read in a single
+// repeatable read snapshot so a transfer committing halfway through cannot make the balances disagree with the lines
+func (store *SqlStore) StatementTx(ctx context.Context, arg StatementTxParams) (StatementTxResult, error) {
+	result := StatementTxResult{
+		StartTime: arg.StartTime,
+		EndTime:   arg.EndTime,
+	}
+	opts := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	err := store.execTxOptions(ctx, opts, func(queries *Queries) error {
+		var err error
+		result.Account, err = queries.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		result.OpeningBalance, err = queries.SumEntriesBefore(ctx, SumEntriesBeforeParams{
+			AccountID: arg.AccountID,
+			CreatedAt: arg.StartTime,
+		})
+		if err != nil {
+			return err
+		}
+		entries, err := queries.ListStatementEntries(ctx, ListStatementEntriesParams{
+			AccountID: arg.AccountID,
+			StartTime: arg.StartTime,
+			EndTime:   arg.EndTime,
+		})
+		if err != nil {
+			return err
+		}
+
+		balance := result.OpeningBalance
+		result.Lines = make([]StatementLine, 0, len(entries))
+		for _, entry := range entries {
+			balance += entry.Amount
+			result.Lines = append(result.Lines, StatementLine{
+				ListStatementEntriesRow: entry,
+				RunningBalance:          balance,
+			})
+		}
+		result.ClosingBalance = balance
+		result.GeneratedAt = time.Now()
+		return nil
+	})
+	return result, err
+}
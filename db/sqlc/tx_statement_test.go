@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createFundedAccount(t, 1000)
+	other := createFundedAccount(t, 1000)
+
+	// seed the ledger so the opening balance matches the funded balance
+	_, err := appendEntry(context.Background(), testQueries, account.ID, account.Balance, 0, time.Now())
+	require.NoError(t, err)
+	start := time.Now()
+
+	amounts := []int64{-10, 25, -5}
+	for _, amount := range amounts {
+		arg := TransferTxParams{FromAccountId: account.ID, ToAccountId: other.ID, Amount: -amount}
+		if amount > 0 {
+			arg = TransferTxParams{FromAccountId: other.ID, ToAccountId: account.ID, Amount: amount}
+		}
+		_, err := store.TransferTx(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	statement, err := store.StatementTx(context.Background(), StatementTxParams{
+		AccountID: account.ID,
+		StartTime: start,
+		EndTime:   time.Now().Add(time.Second),
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, statement.Account.ID)
+	require.Equal(t, account.Balance, statement.OpeningBalance)
+	require.Len(t, statement.Lines, len(amounts))
+
+	balance := statement.OpeningBalance
+	for i, line := range statement.Lines {
+		balance += amounts[i]
+		require.Equal(t, amounts[i], line.Amount)
+		require.Equal(t, balance, line.RunningBalance)
+		require.True(t, line.TransferID.Valid)
+		require.Equal(t, other.ID, line.CounterpartyAccountID.Int64)
+		require.Equal(t, other.Owner, line.CounterpartyOwner.String)
+	}
+	require.Equal(t, balance, statement.ClosingBalance)
+
+	updatedAccount, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, updatedAccount.Balance, statement.ClosingBalance)
+}
@@ -33,6 +33,15 @@ func createRandomUser(t *testing.T) User {
 	return user
 }
 
+// createAdminUser creates a user with the admin role, which only an operator grants
+func createAdminUser(t *testing.T) User {
+	user := createRandomUser(t)
+	_, err := testDB.ExecContext(context.Background(), "UPDATE users SET role = $1 WHERE username = $2", utils.AdminRole, user.Username)
+	require.NoError(t, err)
+	user.Role = utils.AdminRole
+	return user
+}
+
 func TestCreateUser(t *testing.T) {
 	createRandomUser(t)
 }
@@ -50,3 +59,17 @@ func TestGetUser(t *testing.T) {
 	require.WithinDuration(t, user.CreatedAt, testUser.CreatedAt, time.Second)
 	require.WithinDuration(t, user.PasswordChangedAt, testUser.PasswordChangedAt, time.Second)
 }
+
+func TestUpdateUserPassword(t *testing.T) {
+	user := createRandomUser(t)
+	hashedPassword, err := utils.HashPassword(utils.GenerateRandomString(8))
+	require.NoError(t, err)
+
+	updated, err := testQueries.UpdateUserPassword(context.Background(), UpdateUserPasswordParams{
+		Username:       user.Username,
+		HashedPassword: hashedPassword,
+	})
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, updated.HashedPassword)
+	require.WithinDuration(t, time.Now(), updated.PasswordChangedAt, time.Second)
+}
@@ -0,0 +1,178 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+type camt053Renderer struct{}
+
+type camtDocument struct {
+	XMLName   xml.Name `xml:"Document"`
+	Namespace string   `xml:"xmlns,attr"`
+	Statement struct {
+		GroupHeader struct {
+			MessageID string `xml:"MsgId"`
+			CreatedAt string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		Statement camtStatement `xml:"Stmt"`
+	} `xml:"BkToCstmrStmt"`
+}
+
+type camtStatement struct {
+	ID        string `xml:"Id"`
+	CreatedAt string `xml:"CreDtTm"`
+	Period    struct {
+		From string `xml:"FrDtTm"`
+		To   string `xml:"ToDtTm"`
+	} `xml:"FrToDt"`
+	Account struct {
+		ID       string `xml:"Id>Othr>Id"`
+		Currency string `xml:"Ccy"`
+		Owner    string `xml:"Ownr>Nm"`
+	} `xml:"Acct"`
+	Balances []camtBalance `xml:"Bal"`
+	Entries  []camtEntry   `xml:"Ntry"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Type        string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount      camtAmount `xml:"Amt"`
+	CreditDebit string     `xml:"CdtDbtInd"`
+	Date        string     `xml:"Dt>DtTm"`
+}
+
+type camtEntry struct {
+	Reference   string                  `xml:"NtryRef"`
+	Amount      camtAmount              `xml:"Amt"`
+	CreditDebit string                  `xml:"CdtDbtInd"`
+	Status      string                  `xml:"Sts"`
+	BookingDate string                  `xml:"BookgDt>DtTm"`
+	ValueDate   string                  `xml:"ValDt>DtTm"`
+	BankCode    string                  `xml:"BkTxCd>Prtry>Cd"`
+	Details     *camtTransactionDetails `xml:"NtryDtls>TxDtls,omitempty"`
+}
+
+type camtTransactionDetails struct {
+	TransactionID  string              `xml:"Refs>TxId"`
+	EndToEndID     string              `xml:"Refs>EndToEndId,omitempty"`
+	RelatedParties *camtRelatedParties `xml:"RltdPties,omitempty"`
+	Remittance     *camtRemittance     `xml:"RmtInf,omitempty"`
+}
+
+type camtRemittance struct {
+	Unstructured string `xml:"Ustrd"`
+}
+
+type camtRelatedParties struct {
+	Creditor *camtParty `xml:"Cdtr,omitempty"`
+	Debtor   *camtParty `xml:"Dbtr,omitempty"`
+}
+
+type camtParty struct {
+	Name string `xml:"Nm"`
+}
+
+func (camt053Renderer) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+func (camt053Renderer) FileExtension() string {
+	return "xml"
+}
+
+func (camt053Renderer) Render(w io.Writer, statement db.StatementTxResult) error {
+	currency := statement.Account.Currency
+	statementID := fmt.Sprintf("%d-%s", statement.Account.ID, statement.EndTime.UTC().Format("20060102"))
+
+	doc := camtDocument{Namespace: camt053Namespace}
+	doc.Statement.GroupHeader.MessageID = "STMT-" + statementID
+	doc.Statement.GroupHeader.CreatedAt = isoTime(statement.GeneratedAt)
+
+	stmt := &doc.Statement.Statement
+	stmt.ID = statementID
+	stmt.CreatedAt = isoTime(statement.GeneratedAt)
+	stmt.Period.From = isoTime(statement.StartTime)
+	stmt.Period.To = isoTime(statement.EndTime)
+	stmt.Account.ID = strconv.FormatInt(statement.Account.ID, 10)
+	stmt.Account.Currency = currency
+	stmt.Account.Owner = statement.Account.Owner
+	stmt.Balances = []camtBalance{
+		newCamtBalance("OPBD", statement.OpeningBalance, currency, statement.StartTime),
+		newCamtBalance("CLBD", statement.ClosingBalance, currency, statement.EndTime),
+	}
+	for _, line := range statement.Lines {
+		entry := camtEntry{
+			Reference:   strconv.FormatInt(line.ID, 10),
+			Amount:      camtAmount{Currency: currency, Value: formatAmount(abs(line.Amount))},
+			CreditDebit: creditDebit(line.Amount),
+			Status:      "BOOK",
+			BookingDate: isoTime(line.CreatedAt),
+			ValueDate:   isoTime(line.CreatedAt),
+			BankCode:    "TRANSFER",
+		}
+		if line.TransferID.Valid {
+			details := &camtTransactionDetails{
+				TransactionID: strconv.FormatInt(line.TransferID.Int64, 10),
+				EndToEndID:    line.Reference.String,
+			}
+			if len(line.Description.String) > 0 {
+				details.Remittance = &camtRemittance{Unstructured: line.Description.String}
+			}
+			// the related party is the creditor on our debits and the debtor on our credits
+			if line.CounterpartyOwner.Valid {
+				party := &camtParty{Name: line.CounterpartyOwner.String}
+				if line.Amount < 0 {
+					details.RelatedParties = &camtRelatedParties{Creditor: party}
+				} else {
+					details.RelatedParties = &camtRelatedParties{Debtor: party}
+				}
+			}
+			entry.Details = details
+		}
+		stmt.Entries = append(stmt.Entries, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func newCamtBalance(balanceType string, amount int64, currency string, at time.Time) camtBalance {
+	return camtBalance{
+		Type:        balanceType,
+		Amount:      camtAmount{Currency: currency, Value: formatAmount(abs(amount))},
+		CreditDebit: creditDebit(amount),
+		Date:        isoTime(at),
+	}
+}
+
+func creditDebit(amount int64) string {
+	if amount < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+func isoTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
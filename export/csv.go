@@ -0,0 +1,57 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+)
+
+type csvRenderer struct{}
+
+func (csvRenderer) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (csvRenderer) FileExtension() string {
+	return "csv"
+}
+
+func (csvRenderer) Render(w io.Writer, statement db.StatementTxResult) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{
+		"date", "entry_id", "transfer_id", "counterparty_account_id", "counterparty_owner", "currency", "amount", "balance",
+		"reference", "description",
+	})
+	if err != nil {
+		return err
+	}
+	for _, line := range statement.Lines {
+		record := []string{
+			line.CreatedAt.UTC().Format(time.RFC3339),
+			strconv.FormatInt(line.ID, 10),
+			"",
+			"",
+			"",
+			statement.Account.Currency,
+			formatAmount(line.Amount),
+			formatAmount(line.RunningBalance),
+			line.Reference.String,
+			line.Description.String,
+		}
+		if line.TransferID.Valid {
+			record[2] = strconv.FormatInt(line.TransferID.Int64, 10)
+		}
+		if line.CounterpartyAccountID.Valid {
+			record[3] = strconv.FormatInt(line.CounterpartyAccountID.Int64, 10)
+			record[4] = line.CounterpartyOwner.String
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,134 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+)
+
+const (
+	ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+	ofxBankID = "SIMPLEBANK"
+)
+
+type ofxRenderer struct{}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	SignOn  struct {
+		Response struct {
+			Status   ofxStatus `xml:"STATUS"`
+			DTServer string    `xml:"DTSERVER"`
+			Language string    `xml:"LANGUAGE"`
+		} `xml:"SONRS"`
+	} `xml:"SIGNONMSGSRSV1"`
+	Bank struct {
+		Transaction struct {
+			TrnUID    string         `xml:"TRNUID"`
+			Status    ofxStatus      `xml:"STATUS"`
+			Statement ofxStatementRs `xml:"STMTRS"`
+		} `xml:"STMTTRNRS"`
+	} `xml:"BANKMSGSRSV1"`
+}
+
+type ofxStatementRs struct {
+	Currency string `xml:"CURDEF"`
+	Account  struct {
+		BankID      string `xml:"BANKID"`
+		AccountID   string `xml:"ACCTID"`
+		AccountType string `xml:"ACCTTYPE"`
+	} `xml:"BANKACCTFROM"`
+	TransactionList struct {
+		Start        string           `xml:"DTSTART"`
+		End          string           `xml:"DTEND"`
+		Transactions []ofxTransaction `xml:"STMTTRN"`
+	} `xml:"BANKTRANLIST"`
+	LedgerBalance struct {
+		Amount string `xml:"BALAMT"`
+		AsOf   string `xml:"DTASOF"`
+	} `xml:"LEDGERBAL"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	FitID  string `xml:"FITID"`
+	Name   string `xml:"NAME,omitempty"`
+	Memo   string `xml:"MEMO,omitempty"`
+}
+
+func (ofxRenderer) ContentType() string {
+	return "application/x-ofx"
+}
+
+func (ofxRenderer) FileExtension() string {
+	return "ofx"
+}
+
+func (ofxRenderer) Render(w io.Writer, statement db.StatementTxResult) error {
+	var doc ofxDocument
+	doc.SignOn.Response.Status = ofxStatus{Code: 0, Severity: "INFO"}
+	doc.SignOn.Response.DTServer = ofxTime(statement.GeneratedAt)
+	doc.SignOn.Response.Language = "ENG"
+
+	doc.Bank.Transaction.TrnUID = "0"
+	doc.Bank.Transaction.Status = ofxStatus{Code: 0, Severity: "INFO"}
+
+	rs := &doc.Bank.Transaction.Statement
+	rs.Currency = statement.Account.Currency
+	rs.Account.BankID = ofxBankID
+	rs.Account.AccountID = strconv.FormatInt(statement.Account.ID, 10)
+	rs.Account.AccountType = "CHECKING"
+	rs.TransactionList.Start = ofxTime(statement.StartTime)
+	rs.TransactionList.End = ofxTime(statement.EndTime)
+	for _, line := range statement.Lines {
+		transaction := ofxTransaction{
+			Type:   "CREDIT",
+			Posted: ofxTime(line.CreatedAt),
+			Amount: formatAmount(line.Amount),
+			FitID:  strconv.FormatInt(line.ID, 10),
+		}
+		if line.Amount < 0 {
+			transaction.Type = "DEBIT"
+		}
+		if line.CounterpartyOwner.Valid {
+			transaction.Name = line.CounterpartyOwner.String
+		}
+		if len(line.Description.String) > 0 {
+			transaction.Memo = line.Description.String
+		} else if line.TransferID.Valid {
+			transaction.Memo = fmt.Sprintf("Transfer %d", line.TransferID.Int64)
+		}
+		rs.TransactionList.Transactions = append(rs.TransactionList.Transactions, transaction)
+	}
+	rs.LedgerBalance.Amount = formatAmount(statement.ClosingBalance)
+	rs.LedgerBalance.AsOf = ofxTime(statement.EndTime)
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ofxTime formats a timestamp as an OFX datetime in UTC
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
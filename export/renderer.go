@@ -0,0 +1,94 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatOFX     = "ofx"
+	FormatCamt053 = "camt053"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported statement export format")
+	ErrNotAcceptable     = errors.New("no acceptable statement export format")
+)
+
+// Renderer writes an account statement in one file format
+type Renderer interface {
+	ContentType() string
+	FileExtension() string
+	Render(w io.Writer, statement db.StatementTxResult) error
+}
+
+var renderers = map[string]Renderer{
+	FormatCSV:     csvRenderer{},
+	FormatOFX:     ofxRenderer{},
+	FormatCamt053: camt053Renderer{},
+}
+
+// media types accepted in the Accept header, in order of preference when the client accepts anything
+var mediaTypes = []struct {
+	mediaType string
+	format    string
+}{
+	{"text/csv", FormatCSV},
+	{"application/x-ofx", FormatOFX},
+	{"application/vnd.iso20022.camt.053+xml", FormatCamt053},
+	{"application/xml", FormatCamt053},
+}
+
+// NewRenderer returns the renderer for an explicit format name
+func NewRenderer(format string) (Renderer, error) {
+	renderer, ok := renderers[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+	}
+	return renderer, nil
+}
+
+// NegotiateRenderer picks a renderer from an Accept header, an empty header or a wildcard selects CSV
+func NegotiateRenderer(accept string) (Renderer, error) {
+	if strings.TrimSpace(accept) == "" {
+		return renderers[FormatCSV], nil
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if mediaType == "*/*" || mediaType == "text/*" {
+			return renderers[FormatCSV], nil
+		}
+		for _, candidate := range mediaTypes {
+			if candidate.mediaType == mediaType {
+				return renderers[candidate.format], nil
+			}
+		}
+	}
+	return nil, ErrNotAcceptable
+}
+
+// formatAmount renders minor units as a decimal with two fraction digits, all supported currencies use cents
+func formatAmount(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
+
+func abs(amount int64) int64 {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
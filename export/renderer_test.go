@@ -0,0 +1,44 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "0.00", formatAmount(0))
+	require.Equal(t, "0.05", formatAmount(5))
+	require.Equal(t, "12.34", formatAmount(1234))
+	require.Equal(t, "-0.99", formatAmount(-99))
+	require.Equal(t, "-100.00", formatAmount(-10000))
+}
+
+func TestNegotiateRenderer(t *testing.T) {
+	testCases := []struct {
+		accept    string
+		extension string
+	}{
+		{"", "csv"},
+		{"*/*", "csv"},
+		{"text/csv; charset=utf-8", "csv"},
+		{"application/x-ofx", "ofx"},
+		{"application/json, application/vnd.iso20022.camt.053+xml", "xml"},
+		{"application/xml;q=0.9", "xml"},
+	}
+	for _, tc := range testCases {
+		renderer, err := NegotiateRenderer(tc.accept)
+		require.NoError(t, err, tc.accept)
+		require.Equal(t, tc.extension, renderer.FileExtension(), tc.accept)
+	}
+
+	_, err := NegotiateRenderer("application/pdf")
+	require.ErrorIs(t, err, ErrNotAcceptable)
+
+	_, err = NewRenderer("pdf")
+	require.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	renderer, err := NewRenderer("OFX")
+	require.NoError(t, err)
+	require.Equal(t, "ofx", renderer.FileExtension())
+}
@@ -0,0 +1,38 @@
+package fx
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+)
+
+// DBRateProvider reads rates from the rates table
+type DBRateProvider struct {
+	querier db.Querier
+}
+
+func NewDBRateProvider(querier db.Querier) *DBRateProvider {
+	return &DBRateProvider{querier: querier}
+}
+
+func (provider *DBRateProvider) GetRate(ctx context.Context, baseCurrency string, quoteCurrency string, at time.Time) (Rate, error) {
+	rate, err := provider.querier.GetEffectiveRate(ctx, db.GetEffectiveRateParams{
+		BaseCurrency:  baseCurrency,
+		QuoteCurrency: quoteCurrency,
+		EffectiveAt:   at,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return Rate{}, ErrRateNotFound
+		}
+		return Rate{}, err
+	}
+	return Rate{
+		BaseCurrency:  rate.BaseCurrency,
+		QuoteCurrency: rate.QuoteCurrency,
+		Rate:          rate.Rate,
+		EffectiveAt:   rate.EffectiveAt,
+	}, nil
+}
@@ -0,0 +1,72 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+type fileRate struct {
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          string    `json:"rate"`
+	EffectiveAt   time.Time `json:"effective_at"`
+}
+
+// FileRateProvider serves rates loaded once from a JSON file, which is handy for local development and tests
+type FileRateProvider struct {
+	// rates for each currency pair, sorted by effective time
+	rates map[string][]Rate
+}
+
+// NewFileRateProvider loads a JSON array of {base_currency, quote_currency, rate, effective_at} objects
+func NewFileRateProvider(path string) (*FileRateProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read rates file: %w", err)
+	}
+	var fileRates []fileRate
+	if err := json.Unmarshal(data, &fileRates); err != nil {
+		return nil, fmt.Errorf("cannot parse rates file: %w", err)
+	}
+
+	provider := &FileRateProvider{rates: make(map[string][]Rate)}
+	for _, fileRate := range fileRates {
+		rate, err := ParseRate(fileRate.Rate)
+		if err != nil {
+			return nil, err
+		}
+		key := pairKey(fileRate.BaseCurrency, fileRate.QuoteCurrency)
+		provider.rates[key] = append(provider.rates[key], Rate{
+			BaseCurrency:  fileRate.BaseCurrency,
+			QuoteCurrency: fileRate.QuoteCurrency,
+			Rate:          rate,
+			EffectiveAt:   fileRate.EffectiveAt,
+		})
+	}
+	for _, rates := range provider.rates {
+		sort.SliceStable(rates, func(i, j int) bool {
+			return rates[i].EffectiveAt.Before(rates[j].EffectiveAt)
+		})
+	}
+	return provider, nil
+}
+
+func (provider *FileRateProvider) GetRate(ctx context.Context, baseCurrency string, quoteCurrency string, at time.Time) (Rate, error) {
+	rates := provider.rates[pairKey(baseCurrency, quoteCurrency)]
+	// index of the first rate that is not yet in effect
+	i := sort.Search(len(rates), func(i int) bool {
+		return rates[i].EffectiveAt.After(at)
+	})
+	if i == 0 {
+		return Rate{}, ErrRateNotFound
+	}
+	return rates[i-1], nil
+}
+
+func pairKey(baseCurrency string, quoteCurrency string) string {
+	return baseCurrency + "/" + quoteCurrency
+}
@@ -0,0 +1,38 @@
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRateProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	data := `[
+		{"base_currency": "USD", "quote_currency": "SGD", "rate": "1.36", "effective_at": "2024-01-02T00:00:00Z"},
+		{"base_currency": "USD", "quote_currency": "SGD", "rate": "1.35", "effective_at": "2024-01-01T00:00:00Z"}
+	]`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+
+	provider, err := NewFileRateProvider(path)
+	require.NoError(t, err)
+
+	rate, err := provider.GetRate(context.Background(), utils.USD, utils.SGD, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, int64(135_000_000), rate.Rate)
+
+	rate, err = provider.GetRate(context.Background(), utils.USD, utils.SGD, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, int64(136_000_000), rate.Rate)
+
+	_, err = provider.GetRate(context.Background(), utils.USD, utils.SGD, time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC))
+	require.ErrorIs(t, err, ErrRateNotFound)
+
+	_, err = provider.GetRate(context.Background(), utils.SGD, utils.USD, time.Now())
+	require.ErrorIs(t, err, ErrRateNotFound)
+}
@@ -0,0 +1,67 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// RateScale is the fixed point scale of a rate, a rate of 1.35 is stored as 135000000
+const RateScale = 100_000_000
+
+const rateDecimals = 8
+
+var (
+	ErrRateNotFound = errors.New("no exchange rate available")
+	ErrInvalidRate  = errors.New("invalid exchange rate")
+)
+
+// Rate is the number of quote currency units bought by one base currency unit, scaled by RateScale
+type Rate struct {
+	BaseCurrency  string    `json:"base_currency"`
+	QuoteCurrency string    `json:"quote_currency"`
+	Rate          int64     `json:"rate"`
+	EffectiveAt   time.Time `json:"effective_at"`
+}
+
+// RateProvider looks up the rate in effect for a currency pair at a given instant
+type RateProvider interface {
+	GetRate(ctx context.Context, baseCurrency string, quoteCurrency string, at time.Time) (Rate, error)
+}
+
+// Convert applies the rate to an amount in minor units. The result is rounded down to a whole minor unit so the
+// bank never credits more than the converted value
+func Convert(amount int64, rate int64) (int64, error) {
+	if rate <= 0 {
+		return 0, ErrInvalidRate
+	}
+	converted := new(big.Int).Mul(big.NewInt(amount), big.NewInt(rate))
+	converted.Quo(converted, big.NewInt(RateScale))
+	if !converted.IsInt64() {
+		return 0, fmt.Errorf("converted amount overflows: %d at rate %d", amount, rate)
+	}
+	return converted.Int64(), nil
+}
+
+// ParseRate turns a decimal string such as "1.3456" into a scaled rate
+func ParseRate(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	whole, fraction, _ := strings.Cut(value, ".")
+	if len(whole) == 0 || len(fraction) > rateDecimals {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidRate, value)
+	}
+	digits := whole + fraction + strings.Repeat("0", rateDecimals-len(fraction))
+	rate, ok := new(big.Int).SetString(digits, 10)
+	if !ok || rate.Sign() <= 0 || !rate.IsInt64() {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidRate, value)
+	}
+	return rate.Int64(), nil
+}
+
+// FormatRate is the inverse of ParseRate
+func FormatRate(rate int64) string {
+	return fmt.Sprintf("%d.%08d", rate/RateScale, rate%RateScale)
+}
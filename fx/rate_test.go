@@ -0,0 +1,54 @@
+package fx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	testCases := []struct {
+		value string
+		rate  int64
+	}{
+		{"1", RateScale},
+		{"1.35", 135_000_000},
+		{"0.00000001", 1},
+		{"148.12345678", 14_812_345_678},
+	}
+	for _, tc := range testCases {
+		rate, err := ParseRate(tc.value)
+		require.NoError(t, err, tc.value)
+		require.Equal(t, tc.rate, rate, tc.value)
+		require.Equal(t, tc.value, trimRate(FormatRate(rate)), tc.value)
+	}
+
+	for _, value := range []string{"", "0", "-1.2", "abc", ".5", "1.123456789"} {
+		_, err := ParseRate(value)
+		require.ErrorIs(t, err, ErrInvalidRate, value)
+	}
+}
+
+func trimRate(value string) string {
+	for len(value) > 0 && value[len(value)-1] == '0' {
+		value = value[:len(value)-1]
+	}
+	if value[len(value)-1] == '.' {
+		value = value[:len(value)-1]
+	}
+	return value
+}
+
+func TestConvert(t *testing.T) {
+	amount, err := Convert(1000, 135_000_000)
+	require.NoError(t, err)
+	require.Equal(t, int64(1350), amount)
+
+	// fractions of a minor unit are rounded down
+	amount, err = Convert(999, 92_000_000)
+	require.NoError(t, err)
+	require.Equal(t, int64(919), amount)
+
+	_, err = Convert(1000, 0)
+	require.ErrorIs(t, err, ErrInvalidRate)
+}
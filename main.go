@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
+	"io"
 	"log"
+	"os"
 
 	"github.com/DingBao-sys/simple_bank/api"
 	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/reconcile"
 	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/DingBao-sys/simple_bank/worker"
 	_ "github.com/lib/pq"
 )
 
@@ -20,7 +26,29 @@ func main() {
 	if err != nil {
 		log.Fatal("cannot connect to database: ", err)
 	}
-	store := db.NewStore(conn)
+	isolationLevel, err := db.ParseIsolationLevel(config.TxIsolationLevel)
+	if err != nil {
+		log.Fatal("cannot load config: ", err)
+	}
+	store := db.NewStoreWithConfig(conn, db.TxConfig{
+		IsolationLevel:  isolationLevel,
+		MaxRetries:      config.TxMaxRetries,
+		RetryBackoff:    config.TxRetryBackoff,
+		MaxRetryBackoff: config.TxMaxRetryBackoff,
+	})
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		runReconcile(store, os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify-chain" {
+		runVerifyChain(store, os.Args[2:])
+		return
+	}
+	go worker.NewScheduledTransferWorker(store, config.SchedulerInterval).Start(context.Background())
+	go worker.NewStandingOrderWorker(store, config.SchedulerInterval, config.StandingOrderRetryDelay).Start(context.Background())
+	go worker.NewHoldSweeper(store, config.SchedulerInterval, config.IdempotencyKeyRetention).Start(context.Background())
+	go worker.NewBalanceSnapshotter(store, config.SchedulerInterval).Start(context.Background())
+	go worker.NewInterestWorker(store, config.SchedulerInterval).Start(context.Background())
 	server, err := api.NewServer(config, store)
 	if err != nil {
 		log.Fatal("cannot create server: ", err)
@@ -30,3 +58,95 @@ func main() {
 		log.Fatal("cannot start server: ", err)
 	}
 }
+
+// runReconcile checks the ledger and prints every discrepancy, exiting with status 1 if there was any.
+// With -json the report is also written as JSON to the given file, or to stdout for -
+func runReconcile(store db.Store, args []string) {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	chunkSize := flags.Int("chunk-size", reconcile.DefaultChunkSize, "accounts and transfers read per query")
+	jsonPath := flags.String("json", "", "write the report as JSON to this file, - for stdout")
+	flags.Parse(args)
+	if *chunkSize < 1 {
+		log.Fatal("chunk-size must be positive")
+	}
+
+	report, err := reconcile.NewReconciler(store, int32(*chunkSize)).Run(context.Background())
+	if err != nil {
+		log.Fatal("cannot reconcile ledger: ", err)
+	}
+	if *jsonPath != "-" {
+		if err := report.WriteText(os.Stdout); err != nil {
+			log.Fatal("cannot write report: ", err)
+		}
+	}
+	if *jsonPath != "" {
+		if err := writeJSONReport(report, *jsonPath); err != nil {
+			log.Fatal("cannot write report: ", err)
+		}
+	}
+	if !report.Balanced() {
+		os.Exit(1)
+	}
+}
+
+// runVerifyChain walks the entry hash chain of an account and prints where it first breaks, exiting with status 1 if
+// it does. With -checkpoint the chain must also still contain a head recorded earlier
+func runVerifyChain(store db.Store, args []string) {
+	flags := flag.NewFlagSet("verify-chain", flag.ExitOnError)
+	accountID := flags.Int64("account", 0, "id of the account whose chain is verified")
+	checkpointValue := flags.String("checkpoint", "", "chain head recorded earlier as seq:hash")
+	chunkSize := flags.Int("chunk-size", reconcile.DefaultChunkSize, "entries read per query")
+	jsonPath := flags.String("json", "", "write the report as JSON to this file, - for stdout")
+	flags.Parse(args)
+	if *accountID < 1 {
+		log.Fatal("account must be positive")
+	}
+	if *chunkSize < 1 {
+		log.Fatal("chunk-size must be positive")
+	}
+	var checkpoint *reconcile.Checkpoint
+	if *checkpointValue != "" {
+		parsed, err := reconcile.ParseCheckpoint(*checkpointValue)
+		if err != nil {
+			log.Fatal(err)
+		}
+		checkpoint = &parsed
+	}
+
+	report, err := reconcile.NewChainVerifier(store, int32(*chunkSize)).Verify(context.Background(), *accountID, checkpoint)
+	if err != nil {
+		log.Fatal("cannot verify entry chain: ", err)
+	}
+	if *jsonPath != "-" {
+		if err := report.WriteText(os.Stdout); err != nil {
+			log.Fatal("cannot write report: ", err)
+		}
+	}
+	if *jsonPath != "" {
+		if err := writeJSONReport(report, *jsonPath); err != nil {
+			log.Fatal("cannot write report: ", err)
+		}
+	}
+	if !report.Intact() {
+		os.Exit(1)
+	}
+}
+
+type jsonReport interface {
+	WriteJSON(w io.Writer) error
+}
+
+func writeJSONReport(report jsonReport, path string) error {
+	if path == "-" {
+		return report.WriteJSON(os.Stdout)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteJSON(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
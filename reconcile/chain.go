@@ -0,0 +1,166 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+)
+
+// kinds of chain break
+const (
+	// an entry is missing from the chain, its sequence number was skipped or the chain ends before a checkpoint
+	MissingEntry = "missing_entry"
+	// the previous hash of the entry is not the hash of the entry before it
+	BrokenLink = "broken_link"
+	// the entry does not hash to its stored hash, one of its fields was changed
+	TamperedEntry = "tampered_entry"
+	// the entry at the checkpoint no longer has the hash that was checkpointed
+	CheckpointMismatch = "checkpoint_mismatch"
+)
+
+// Checkpoint is a chain head recorded earlier, the chain must still contain it
+type Checkpoint struct {
+	Seq  int64
+	Hash []byte
+}
+
+// ParseCheckpoint reads a checkpoint written as seq:hash with the hash in hex, the form the chain head is reported in
+func ParseCheckpoint(value string) (Checkpoint, error) {
+	var checkpoint Checkpoint
+	seq, hash, found := strings.Cut(value, ":")
+	if !found {
+		return checkpoint, fmt.Errorf("checkpoint must be seq:hash, got %q", value)
+	}
+	var err error
+	checkpoint.Seq, err = strconv.ParseInt(seq, 10, 64)
+	if err != nil || checkpoint.Seq < 1 {
+		return checkpoint, fmt.Errorf("checkpoint seq must be a positive integer, got %q", seq)
+	}
+	checkpoint.Hash, err = hex.DecodeString(hash)
+	if err != nil || len(checkpoint.Hash) != sha256.Size {
+		return checkpoint, fmt.Errorf("checkpoint hash must be %d hex encoded bytes, got %q", sha256.Size, hash)
+	}
+	return checkpoint, nil
+}
+
+type ChainBreak struct {
+	Kind string `json:"kind"`
+	// Seq is the position in the chain where it breaks, for a missing entry the first sequence number missing
+	Seq int64 `json:"seq"`
+	// EntryID is the entry found at Seq, zero when it is missing
+	EntryID int64 `json:"entry_id,omitempty"`
+}
+
+func (chainBreak ChainBreak) String() string {
+	if chainBreak.EntryID == 0 {
+		return fmt.Sprintf("%s: seq %d", chainBreak.Kind, chainBreak.Seq)
+	}
+	return fmt.Sprintf("%s: seq %d entry %d", chainBreak.Kind, chainBreak.Seq, chainBreak.EntryID)
+}
+
+type ChainReport struct {
+	AccountID      int64 `json:"account_id"`
+	EntriesChecked int64 `json:"entries_checked"`
+	// HeadSeq and HeadHash are the last entry checked, the head of an intact chain
+	HeadSeq  int64  `json:"head_seq"`
+	HeadHash string `json:"head_hash"`
+	// FirstBreak is where the chain first fails to verify, nil when it is intact
+	FirstBreak *ChainBreak `json:"first_break,omitempty"`
+}
+
+// Intact reports whether the whole chain verified
+func (report ChainReport) Intact() bool {
+	return report.FirstBreak == nil
+}
+
+func (report ChainReport) WriteText(w io.Writer) error {
+	if report.FirstBreak != nil {
+		if _, err := fmt.Fprintln(w, report.FirstBreak); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "checked %d entries of account %d, head %d:%s\n",
+		report.EntriesChecked, report.AccountID, report.HeadSeq, report.HeadHash)
+	return err
+}
+
+func (report ChainReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// ChainVerifier walks the hash chain of an account from its first entry, recomputing every hash. It stops at the
+// first break, every entry after it would fail to verify too. Entries are read in chunks of ascending sequence number
+type ChainVerifier struct {
+	querier   db.Querier
+	chunkSize int32
+}
+
+func NewChainVerifier(querier db.Querier, chunkSize int32) *ChainVerifier {
+	return &ChainVerifier{
+		querier:   querier,
+		chunkSize: chunkSize,
+	}
+}
+
+// Verify checks the chain of the account and, when checkpoint is not nil, that the chain still contains it. The chain
+// only proves the entries before its head, a checkpoint kept outside the database is what shows no entry was removed
+// from the end
+func (verifier *ChainVerifier) Verify(ctx context.Context, accountID int64, checkpoint *Checkpoint) (ChainReport, error) {
+	report := ChainReport{AccountID: accountID}
+	previous := db.GenesisHash()
+	for {
+		entries, err := verifier.querier.ListEntryChain(ctx, db.ListEntryChainParams{
+			AccountID: accountID,
+			AfterSeq:  report.HeadSeq,
+			ChunkSize: verifier.chunkSize,
+		})
+		if err != nil {
+			return report, err
+		}
+		for _, entry := range entries {
+			if chainBreak := checkEntry(entry, report.HeadSeq+1, previous, checkpoint); chainBreak != nil {
+				report.FirstBreak = chainBreak
+				return report, nil
+			}
+			report.EntriesChecked++
+			report.HeadSeq = entry.Seq
+			report.HeadHash = hex.EncodeToString(entry.Hash)
+			previous = entry.Hash
+		}
+		if len(entries) < int(verifier.chunkSize) {
+			break
+		}
+	}
+	if checkpoint != nil && checkpoint.Seq > report.HeadSeq {
+		report.FirstBreak = &ChainBreak{Kind: MissingEntry, Seq: report.HeadSeq + 1}
+	}
+	return report, nil
+}
+
+func checkEntry(entry db.Entry, seq int64, previous []byte, checkpoint *Checkpoint) *ChainBreak {
+	if entry.Seq != seq {
+		return &ChainBreak{Kind: MissingEntry, Seq: seq}
+	}
+	chainBreak := &ChainBreak{Seq: entry.Seq, EntryID: entry.ID}
+	switch {
+	case !bytes.Equal(entry.PrevHash, previous):
+		chainBreak.Kind = BrokenLink
+	case !bytes.Equal(db.EntryHash(entry), entry.Hash):
+		chainBreak.Kind = TamperedEntry
+	case checkpoint != nil && checkpoint.Seq == entry.Seq && !bytes.Equal(checkpoint.Hash, entry.Hash):
+		chainBreak.Kind = CheckpointMismatch
+	default:
+		return nil
+	}
+	return chainBreak
+}
@@ -0,0 +1,156 @@
+package reconcile
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// buildChain returns n correctly chained entries of one account
+func buildChain(accountID int64, n int) []db.Entry {
+	entries := make([]db.Entry, 0, n)
+	previous := db.GenesisHash()
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 123456000, time.UTC)
+	for seq := int64(1); seq <= int64(n); seq++ {
+		entry := db.Entry{
+			ID:         seq * 10,
+			AccountID:  accountID,
+			Amount:     seq * 100,
+			CreatedAt:  createdAt.Add(time.Duration(seq) * time.Minute),
+			TransferID: sql.NullInt64{Int64: seq, Valid: true},
+			Seq:        seq,
+			PrevHash:   previous,
+		}
+		entry.Hash = db.EntryHash(entry)
+		previous = entry.Hash
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestChainVerifier(t *testing.T) {
+	chain := buildChain(1, 5)
+	head := fmt.Sprintf("5:%s", hex.EncodeToString(chain[4].Hash))
+
+	tampered := append([]db.Entry{}, chain...)
+	tampered[2].Amount = 1
+
+	missing := append(append([]db.Entry{}, chain[:2]...), chain[3:]...)
+
+	relinked := append([]db.Entry{}, chain...)
+	relinked[3].PrevHash = db.GenesisHash()
+	relinked[3].Hash = db.EntryHash(relinked[3])
+
+	testCases := []struct {
+		name       string
+		entries    []db.Entry
+		checkpoint string
+		firstBreak *ChainBreak
+		headSeq    int64
+	}{
+		{
+			name:       "Intact",
+			entries:    chain,
+			checkpoint: head,
+			headSeq:    5,
+		},
+		{
+			name:       "TamperedEntry",
+			entries:    tampered,
+			firstBreak: &ChainBreak{Kind: TamperedEntry, Seq: 3, EntryID: 30},
+			headSeq:    2,
+		},
+		{
+			name:       "MissingEntry",
+			entries:    missing,
+			firstBreak: &ChainBreak{Kind: MissingEntry, Seq: 3},
+			headSeq:    2,
+		},
+		{
+			name:       "BrokenLink",
+			entries:    relinked,
+			firstBreak: &ChainBreak{Kind: BrokenLink, Seq: 4, EntryID: 40},
+			headSeq:    3,
+		},
+		{
+			// the last two entries were removed, only the checkpoint shows it
+			name:       "TruncatedBeforeCheckpoint",
+			entries:    chain[:3],
+			checkpoint: head,
+			firstBreak: &ChainBreak{Kind: MissingEntry, Seq: 4},
+			headSeq:    3,
+		},
+		{
+			name:       "CheckpointMismatch",
+			entries:    chain,
+			checkpoint: fmt.Sprintf("2:%s", hex.EncodeToString(chain[0].Hash)),
+			firstBreak: &ChainBreak{Kind: CheckpointMismatch, Seq: 2, EntryID: 20},
+			headSeq:    1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			// chunks of two entries, each query starts after the last seq read
+			store.EXPECT().
+				ListEntryChain(gomock.Any(), gomock.Any()).
+				AnyTimes().
+				DoAndReturn(func(_ any, arg db.ListEntryChainParams) ([]db.Entry, error) {
+					require.Equal(t, int64(1), arg.AccountID)
+					chunk := []db.Entry{}
+					for _, entry := range tc.entries {
+						if entry.Seq > arg.AfterSeq && len(chunk) < int(arg.ChunkSize) {
+							chunk = append(chunk, entry)
+						}
+					}
+					return chunk, nil
+				})
+
+			var checkpoint *Checkpoint
+			if len(tc.checkpoint) > 0 {
+				parsed, err := ParseCheckpoint(tc.checkpoint)
+				require.NoError(t, err)
+				checkpoint = &parsed
+			}
+			report, err := NewChainVerifier(store, 2).Verify(context.Background(), 1, checkpoint)
+			require.NoError(t, err)
+			require.Equal(t, tc.firstBreak, report.FirstBreak)
+			require.Equal(t, tc.firstBreak == nil, report.Intact())
+			require.Equal(t, tc.headSeq, report.HeadSeq)
+		})
+	}
+}
+
+func TestChainVerifierQueryError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListEntryChain(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+
+	_, err := NewChainVerifier(store, DefaultChunkSize).Verify(context.Background(), 1, nil)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
+
+func TestParseCheckpoint(t *testing.T) {
+	hash := hex.EncodeToString(db.GenesisHash())
+	checkpoint, err := ParseCheckpoint("42:" + hash)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), checkpoint.Seq)
+	require.Equal(t, db.GenesisHash(), checkpoint.Hash)
+
+	for _, value := range []string{"", "42", "0:" + hash, "x:" + hash, "42:zz", "42:00"} {
+		_, err := ParseCheckpoint(value)
+		require.Error(t, err, value)
+	}
+}
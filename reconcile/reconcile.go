@@ -0,0 +1,193 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+)
+
+const DefaultChunkSize = 1000
+
+// kinds of discrepancy
+const (
+	// the balance of the account differs from the sum of its entries
+	BalanceMismatch = "balance_mismatch"
+	// the transfer does not have exactly one entry on each of its accounts
+	EntryCountMismatch = "entry_count_mismatch"
+	// the entry on the source account does not debit the transfer amount
+	DebitMismatch = "debit_mismatch"
+	// the entry on the destination account does not credit the amount the transfer credits
+	CreditMismatch = "credit_mismatch"
+)
+
+type Discrepancy struct {
+	Kind       string `json:"kind"`
+	AccountID  int64  `json:"account_id,omitempty"`
+	TransferID int64  `json:"transfer_id,omitempty"`
+	Expected   int64  `json:"expected"`
+	Actual     int64  `json:"actual"`
+}
+
+func (discrepancy Discrepancy) String() string {
+	switch {
+	case discrepancy.TransferID != 0 && discrepancy.AccountID != 0:
+		return fmt.Sprintf("%s: transfer %d account %d expected %d, got %d", discrepancy.Kind, discrepancy.TransferID,
+			discrepancy.AccountID, discrepancy.Expected, discrepancy.Actual)
+	case discrepancy.TransferID != 0:
+		return fmt.Sprintf("%s: transfer %d expected %d, got %d", discrepancy.Kind, discrepancy.TransferID,
+			discrepancy.Expected, discrepancy.Actual)
+	}
+	return fmt.Sprintf("%s: account %d expected %d, got %d", discrepancy.Kind, discrepancy.AccountID,
+		discrepancy.Expected, discrepancy.Actual)
+}
+
+type Report struct {
+	StartedAt        time.Time     `json:"started_at"`
+	FinishedAt       time.Time     `json:"finished_at"`
+	AccountsChecked  int64         `json:"accounts_checked"`
+	TransfersChecked int64         `json:"transfers_checked"`
+	Discrepancies    []Discrepancy `json:"discrepancies"`
+}
+
+// Balanced reports whether the ledger had no discrepancy
+func (report Report) Balanced() bool {
+	return len(report.Discrepancies) == 0
+}
+
+func (report Report) WriteText(w io.Writer) error {
+	for _, discrepancy := range report.Discrepancies {
+		if _, err := fmt.Fprintln(w, discrepancy); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "checked %d accounts and %d transfers, found %d discrepancies\n",
+		report.AccountsChecked, report.TransfersChecked, len(report.Discrepancies))
+	return err
+}
+
+func (report Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// Reconciler checks the ledger is consistent: every account balance equals the sum of its entries and every transfer
+// has exactly one debit and one credit entry for its amounts. Accounts and transfers are read in chunks of ascending
+// id, each chunk is a consistent snapshot but the ledger may move on between chunks, so a discrepancy found while
+// transfers are running should be checked again
+type Reconciler struct {
+	querier   db.Querier
+	chunkSize int32
+}
+
+func NewReconciler(querier db.Querier, chunkSize int32) *Reconciler {
+	return &Reconciler{
+		querier:   querier,
+		chunkSize: chunkSize,
+	}
+}
+
+func (reconciler *Reconciler) Run(ctx context.Context) (Report, error) {
+	report := Report{
+		StartedAt:     time.Now(),
+		Discrepancies: []Discrepancy{},
+	}
+	if err := reconciler.reconcileAccounts(ctx, &report); err != nil {
+		return report, err
+	}
+	if err := reconciler.reconcileTransfers(ctx, &report); err != nil {
+		return report, err
+	}
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+func (reconciler *Reconciler) reconcileAccounts(ctx context.Context, report *Report) error {
+	var afterID int64
+	for {
+		accounts, err := reconciler.querier.ReconcileAccounts(ctx, db.ReconcileAccountsParams{
+			AfterID:   afterID,
+			ChunkSize: reconciler.chunkSize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, account := range accounts {
+			if account.Balance != account.EntriesTotal {
+				report.Discrepancies = append(report.Discrepancies, Discrepancy{
+					Kind:      BalanceMismatch,
+					AccountID: account.ID,
+					Expected:  account.EntriesTotal,
+					Actual:    account.Balance,
+				})
+			}
+			afterID = account.ID
+		}
+		report.AccountsChecked += int64(len(accounts))
+		if len(accounts) < int(reconciler.chunkSize) {
+			return nil
+		}
+	}
+}
+
+func (reconciler *Reconciler) reconcileTransfers(ctx context.Context, report *Report) error {
+	var afterID int64
+	for {
+		transfers, err := reconciler.querier.ReconcileTransfers(ctx, db.ReconcileTransfersParams{
+			AfterID:   afterID,
+			ChunkSize: reconciler.chunkSize,
+		})
+		if err != nil {
+			return err
+		}
+		for _, transfer := range transfers {
+			report.Discrepancies = append(report.Discrepancies, checkTransfer(transfer)...)
+			afterID = transfer.ID
+		}
+		report.TransfersChecked += int64(len(transfers))
+		if len(transfers) < int(reconciler.chunkSize) {
+			return nil
+		}
+	}
+}
+
+// checkTransfer compares the entries of a transfer with its amounts. Without an exchange rate the two entries sum to
+// zero, with one the credit is the converted amount
+func checkTransfer(transfer db.ReconcileTransfersRow) []Discrepancy {
+	var discrepancies []Discrepancy
+	if transfer.EntryCount != 2 {
+		discrepancies = append(discrepancies, Discrepancy{
+			Kind:       EntryCountMismatch,
+			TransferID: transfer.ID,
+			Expected:   2,
+			Actual:     transfer.EntryCount,
+		})
+	}
+	if transfer.Debited != -transfer.Amount {
+		discrepancies = append(discrepancies, Discrepancy{
+			Kind:       DebitMismatch,
+			TransferID: transfer.ID,
+			AccountID:  transfer.FromAccountID,
+			Expected:   -transfer.Amount,
+			Actual:     transfer.Debited,
+		})
+	}
+	credit := transfer.Amount
+	if transfer.ToAmount.Valid {
+		credit = transfer.ToAmount.Int64
+	}
+	if transfer.Credited != credit {
+		discrepancies = append(discrepancies, Discrepancy{
+			Kind:       CreditMismatch,
+			TransferID: transfer.ID,
+			AccountID:  transfer.ToAccountID,
+			Expected:   credit,
+			Actual:     transfer.Credited,
+		})
+	}
+	return discrepancies
+}
@@ -0,0 +1,108 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcilerRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	// a full chunk is followed by another query starting after its last id
+	gomock.InOrder(
+		store.EXPECT().
+			ReconcileAccounts(gomock.Any(), gomock.Eq(db.ReconcileAccountsParams{AfterID: 0, ChunkSize: 2})).
+			Times(1).
+			Return([]db.ReconcileAccountsRow{
+				{ID: 1, Balance: 100, EntriesTotal: 100},
+				{ID: 2, Balance: 90, EntriesTotal: 80},
+			}, nil),
+		store.EXPECT().
+			ReconcileAccounts(gomock.Any(), gomock.Eq(db.ReconcileAccountsParams{AfterID: 2, ChunkSize: 2})).
+			Times(1).
+			Return([]db.ReconcileAccountsRow{{ID: 5, Balance: -10, EntriesTotal: -10}}, nil),
+	)
+	gomock.InOrder(
+		store.EXPECT().
+			ReconcileTransfers(gomock.Any(), gomock.Eq(db.ReconcileTransfersParams{AfterID: 0, ChunkSize: 2})).
+			Times(1).
+			Return([]db.ReconcileTransfersRow{
+				{ID: 3, FromAccountID: 1, ToAccountID: 2, Amount: 10, EntryCount: 2, Debited: -10, Credited: 10},
+				// converted at 1.35
+				{ID: 4, FromAccountID: 2, ToAccountID: 5, Amount: 10, ToAmount: sql.NullInt64{Int64: 13, Valid: true}, EntryCount: 2, Debited: -10, Credited: 13},
+			}, nil),
+		store.EXPECT().
+			ReconcileTransfers(gomock.Any(), gomock.Eq(db.ReconcileTransfersParams{AfterID: 4, ChunkSize: 2})).
+			Times(1).
+			Return([]db.ReconcileTransfersRow{
+				{ID: 6, FromAccountID: 1, ToAccountID: 5, Amount: 10, EntryCount: 1, Debited: -10},
+			}, nil),
+		store.EXPECT().
+			ReconcileTransfers(gomock.Any(), gomock.Eq(db.ReconcileTransfersParams{AfterID: 6, ChunkSize: 2})).
+			Times(0),
+	)
+
+	report, err := NewReconciler(store, 2).Run(context.Background())
+	require.NoError(t, err)
+	require.False(t, report.Balanced())
+	require.Equal(t, int64(3), report.AccountsChecked)
+	require.Equal(t, int64(3), report.TransfersChecked)
+	require.Equal(t, []Discrepancy{
+		{Kind: BalanceMismatch, AccountID: 2, Expected: 80, Actual: 90},
+		{Kind: EntryCountMismatch, TransferID: 6, Expected: 2, Actual: 1},
+		{Kind: CreditMismatch, TransferID: 6, AccountID: 5, Expected: 10, Actual: 0},
+	}, report.Discrepancies)
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text))
+	require.Equal(t, "balance_mismatch: account 2 expected 80, got 90\n"+
+		"entry_count_mismatch: transfer 6 expected 2, got 1\n"+
+		"credit_mismatch: transfer 6 account 5 expected 10, got 0\n"+
+		"checked 3 accounts and 3 transfers, found 3 discrepancies\n", text.String())
+
+	var buffer bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buffer))
+	var decoded Report
+	require.NoError(t, json.Unmarshal(buffer.Bytes(), &decoded))
+	require.Equal(t, report.Discrepancies, decoded.Discrepancies)
+}
+
+func TestReconcilerRunBalanced(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().ReconcileAccounts(gomock.Any(), gomock.Any()).Times(1).Return([]db.ReconcileAccountsRow{}, nil)
+	store.EXPECT().ReconcileTransfers(gomock.Any(), gomock.Any()).Times(1).Return([]db.ReconcileTransfersRow{}, nil)
+
+	report, err := NewReconciler(store, DefaultChunkSize).Run(context.Background())
+	require.NoError(t, err)
+	require.True(t, report.Balanced())
+
+	// an empty report still lists no discrepancies rather than null
+	var buffer bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buffer))
+	require.Contains(t, buffer.String(), `"discrepancies": []`)
+}
+
+func TestReconcilerRunError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().ReconcileAccounts(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+	store.EXPECT().ReconcileTransfers(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := NewReconciler(store, DefaultChunkSize).Run(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
@@ -0,0 +1,90 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+)
+
+// Transfer is what the rules see of a transfer before it is executed
+type Transfer struct {
+	Username string
+	From     db.Account
+	To       db.Account
+	Amount   int64
+	Now      time.Time
+}
+
+// Decision is the verdict of one rule, Code is a stable identifier clients can act on and Reason is for people
+type Decision struct {
+	Rule    string         `json:"rule"`
+	Outcome db.RiskOutcome `json:"outcome"`
+	Code    string         `json:"reason_code,omitempty"`
+	Reason  string         `json:"reason,omitempty"`
+}
+
+// Rule is a single check, it reads whatever history it needs through the querier
+type Rule interface {
+	Name() string
+	Evaluate(ctx context.Context, querier db.Querier, transfer Transfer) (Decision, error)
+}
+
+// Assessment combines the decisions of every rule, the strictest outcome wins
+type Assessment struct {
+	Outcome db.RiskOutcome
+	// the decision that set the outcome, zero when every rule allowed the transfer
+	Decisive  Decision
+	Decisions []Decision
+}
+
+type Engine struct {
+	querier db.Querier
+	rules   []Rule
+}
+
+func NewEngine(querier db.Querier, rules ...Rule) *Engine {
+	return &Engine{querier: querier, rules: rules}
+}
+
+// Enabled reports whether any rule is configured, an engine without rules allows everything
+func (engine *Engine) Enabled() bool {
+	return len(engine.rules) > 0
+}
+
+// Evaluate runs every rule so all decisions are kept for analysis, even once one of them has denied the transfer
+func (engine *Engine) Evaluate(ctx context.Context, transfer Transfer) (Assessment, error) {
+	assessment := Assessment{
+		Outcome:   db.RiskOutcomeAllow,
+		Decisions: make([]Decision, 0, len(engine.rules)),
+	}
+	for _, rule := range engine.rules {
+		decision, err := rule.Evaluate(ctx, engine.querier, transfer)
+		if err != nil {
+			return Assessment{}, fmt.Errorf("risk rule %s: %w", rule.Name(), err)
+		}
+		decision.Rule = rule.Name()
+		assessment.Decisions = append(assessment.Decisions, decision)
+		if severity(decision.Outcome) > severity(assessment.Outcome) {
+			assessment.Outcome = decision.Outcome
+			assessment.Decisive = decision
+		}
+	}
+	return assessment, nil
+}
+
+func severity(outcome db.RiskOutcome) int {
+	switch outcome {
+	case db.RiskOutcomeDeny:
+		return 2
+	case db.RiskOutcomeReview:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func allow() Decision {
+	return Decision{Outcome: db.RiskOutcomeAllow}
+}
@@ -0,0 +1,177 @@
+package risk
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/utils"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func randomTransfer(amount int64) Transfer {
+	owner := utils.GenerateRandomOwner()
+	return Transfer{
+		Username: owner,
+		From:     db.Account{ID: 1, Owner: owner, Currency: utils.USD},
+		To:       db.Account{ID: 2, Owner: utils.GenerateRandomOwner(), Currency: utils.USD},
+		Amount:   amount,
+		Now:      time.Now(),
+	}
+}
+
+func TestVelocityRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	transfer := randomTransfer(10)
+	rule := VelocityRule{MaxTransfers: 3, Window: 10 * time.Minute}
+	arg := db.CountOwnerTransfersSinceParams{Owner: transfer.From.Owner, Since: transfer.Now.Add(-rule.Window)}
+
+	store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(2), nil)
+	decision, err := rule.Evaluate(context.Background(), store, transfer)
+	require.NoError(t, err)
+	require.Equal(t, db.RiskOutcomeAllow, decision.Outcome)
+
+	store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(3), nil)
+	decision, err = rule.Evaluate(context.Background(), store, transfer)
+	require.NoError(t, err)
+	require.Equal(t, db.RiskOutcomeDeny, decision.Outcome)
+	require.Equal(t, CodeVelocity, decision.Code)
+}
+
+func TestNewPayeeRule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	rule := NewPayeeRule{Amount: 500}
+
+	// small amounts are not checked at all, the mock fails on any call
+	decision, err := rule.Evaluate(context.Background(), store, randomTransfer(500))
+	require.NoError(t, err)
+	require.Equal(t, db.RiskOutcomeAllow, decision.Outcome)
+
+	transfer := randomTransfer(501)
+	arg := db.HasPaidAccountParams{Owner: transfer.From.Owner, ToAccountID: transfer.To.ID}
+	store.EXPECT().HasPaidAccount(gomock.Any(), gomock.Eq(arg)).Times(1).Return(false, nil)
+	decision, err = rule.Evaluate(context.Background(), store, transfer)
+	require.NoError(t, err)
+	require.Equal(t, db.RiskOutcomeReview, decision.Outcome)
+	require.Equal(t, CodeNewPayee, decision.Code)
+
+	store.EXPECT().HasPaidAccount(gomock.Any(), gomock.Eq(arg)).Times(1).Return(true, nil)
+	decision, err = rule.Evaluate(context.Background(), store, transfer)
+	require.NoError(t, err)
+	require.Equal(t, db.RiskOutcomeAllow, decision.Outcome)
+}
+
+func TestPasswordChangeRule(t *testing.T) {
+	transfer := randomTransfer(10)
+	rule := PasswordChangeRule{Window: 24 * time.Hour}
+	changedAt := transfer.Now.Add(-time.Hour)
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		outcome    db.RiskOutcome
+	}{
+		{
+			name: "FirstTransferAfterChange",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(transfer.From.Owner)).Times(1).Return(db.User{PasswordChangedAt: changedAt}, nil)
+				arg := db.CountOwnerTransfersSinceParams{Owner: transfer.From.Owner, Since: changedAt}
+				store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(0), nil)
+			},
+			outcome: db.RiskOutcomeReview,
+		},
+		{
+			name: "AlreadyTransferredSinceChange",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{PasswordChangedAt: changedAt}, nil)
+				store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+			},
+			outcome: db.RiskOutcomeAllow,
+		},
+		{
+			name: "ChangedLongAgo",
+			buildStubs: func(store *mockdb.MockStore) {
+				user := db.User{PasswordChangedAt: transfer.Now.Add(-48 * time.Hour)}
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Any()).Times(0)
+			},
+			outcome: db.RiskOutcomeAllow,
+		},
+		{
+			name: "NeverChanged",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, nil)
+				store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Any()).Times(0)
+			},
+			outcome: db.RiskOutcomeAllow,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			decision, err := rule.Evaluate(context.Background(), store, transfer)
+			require.NoError(t, err)
+			require.Equal(t, tc.outcome, decision.Outcome)
+		})
+	}
+}
+
+func TestEngine(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	transfer := randomTransfer(1000)
+
+	engine := NewEngine(store, NewPayeeRule{Amount: 100}, VelocityRule{MaxTransfers: 1, Window: time.Minute})
+	require.True(t, engine.Enabled())
+
+	// every rule runs and the strictest outcome wins
+	store.EXPECT().HasPaidAccount(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+	store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Any()).Times(1).Return(int64(5), nil)
+	assessment, err := engine.Evaluate(context.Background(), transfer)
+	require.NoError(t, err)
+	require.Equal(t, db.RiskOutcomeDeny, assessment.Outcome)
+	require.Equal(t, "velocity", assessment.Decisive.Rule)
+	require.Len(t, assessment.Decisions, 2)
+	require.Equal(t, db.RiskOutcomeReview, assessment.Decisions[0].Outcome)
+
+	store.EXPECT().HasPaidAccount(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+	store.EXPECT().CountOwnerTransfersSince(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+	assessment, err = engine.Evaluate(context.Background(), transfer)
+	require.NoError(t, err)
+	require.Equal(t, db.RiskOutcomeAllow, assessment.Outcome)
+	require.Zero(t, assessment.Decisive)
+
+	store.EXPECT().HasPaidAccount(gomock.Any(), gomock.Any()).Times(1).Return(false, sql.ErrConnDone)
+	_, err = engine.Evaluate(context.Background(), transfer)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
+
+func TestRulesFromConfig(t *testing.T) {
+	require.Empty(t, RulesFromConfig(utils.Config{}))
+
+	rules := RulesFromConfig(utils.Config{
+		RiskVelocityMaxTransfers: 20,
+		RiskVelocityWindow:       10 * time.Minute,
+		RiskNewPayeeAmount:       100000,
+		RiskPasswordChangeWindow: 24 * time.Hour,
+	})
+	require.Len(t, rules, 3)
+
+	// a velocity limit without a window is disabled
+	rules = RulesFromConfig(utils.Config{RiskVelocityMaxTransfers: 20})
+	require.Empty(t, rules)
+}
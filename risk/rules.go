@@ -0,0 +1,125 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/DingBao-sys/simple_bank/utils"
+)
+
+const (
+	CodeVelocity        = "velocity"
+	CodeNewPayee        = "new_payee"
+	CodePasswordChanged = "password_changed"
+)
+
+// VelocityRule denies a transfer once the owner has sent MaxTransfers transfers within Window
+type VelocityRule struct {
+	MaxTransfers int64
+	Window       time.Duration
+}
+
+func (rule VelocityRule) Name() string {
+	return "velocity"
+}
+
+func (rule VelocityRule) Evaluate(ctx context.Context, querier db.Querier, transfer Transfer) (Decision, error) {
+	count, err := querier.CountOwnerTransfersSince(ctx, db.CountOwnerTransfersSinceParams{
+		Owner: transfer.From.Owner,
+		Since: transfer.Now.Add(-rule.Window),
+	})
+	if err != nil {
+		return Decision{}, err
+	}
+	if count < rule.MaxTransfers {
+		return allow(), nil
+	}
+	return Decision{
+		Outcome: db.RiskOutcomeDeny,
+		Code:    CodeVelocity,
+		Reason:  fmt.Sprintf("more than %d transfers in %s", rule.MaxTransfers, rule.Window),
+	}, nil
+}
+
+// NewPayeeRule holds a transfer over Amount to an account the owner has never paid before
+type NewPayeeRule struct {
+	Amount int64
+}
+
+func (rule NewPayeeRule) Name() string {
+	return "new_payee"
+}
+
+func (rule NewPayeeRule) Evaluate(ctx context.Context, querier db.Querier, transfer Transfer) (Decision, error) {
+	if transfer.Amount <= rule.Amount {
+		return allow(), nil
+	}
+	paid, err := querier.HasPaidAccount(ctx, db.HasPaidAccountParams{
+		Owner:       transfer.From.Owner,
+		ToAccountID: transfer.To.ID,
+	})
+	if err != nil {
+		return Decision{}, err
+	}
+	if paid {
+		return allow(), nil
+	}
+	return Decision{
+		Outcome: db.RiskOutcomeReview,
+		Code:    CodeNewPayee,
+		Reason:  fmt.Sprintf("first payment over %d to this account", rule.Amount),
+	}, nil
+}
+
+// PasswordChangeRule holds the first transfer after a password change made within Window
+type PasswordChangeRule struct {
+	Window time.Duration
+}
+
+func (rule PasswordChangeRule) Name() string {
+	return "password_change"
+}
+
+func (rule PasswordChangeRule) Evaluate(ctx context.Context, querier db.Querier, transfer Transfer) (Decision, error) {
+	user, err := querier.GetUser(ctx, transfer.From.Owner)
+	if err != nil {
+		return Decision{}, err
+	}
+	// users who never changed their password keep the zero time
+	changedAt := user.PasswordChangedAt
+	if changedAt.IsZero() || transfer.Now.Sub(changedAt) > rule.Window {
+		return allow(), nil
+	}
+	count, err := querier.CountOwnerTransfersSince(ctx, db.CountOwnerTransfersSinceParams{
+		Owner: transfer.From.Owner,
+		Since: changedAt,
+	})
+	if err != nil {
+		return Decision{}, err
+	}
+	if count > 0 {
+		return allow(), nil
+	}
+	return Decision{
+		Outcome: db.RiskOutcomeReview,
+		Code:    CodePasswordChanged,
+		Reason:  "first transfer after a password change",
+	}, nil
+}
+
+// RulesFromConfig builds the configured rules, a rule whose settings are left at zero is disabled
+func RulesFromConfig(config utils.Config) []Rule {
+	var rules []Rule
+	if config.RiskVelocityMaxTransfers > 0 && config.RiskVelocityWindow > 0 {
+		rules = append(rules, VelocityRule{MaxTransfers: config.RiskVelocityMaxTransfers, Window: config.RiskVelocityWindow})
+	}
+	if config.RiskNewPayeeAmount > 0 {
+		rules = append(rules, NewPayeeRule{Amount: config.RiskNewPayeeAmount})
+	}
+	if config.RiskPasswordChangeWindow > 0 {
+		rules = append(rules, PasswordChangeRule{Window: config.RiskPasswordChangeWindow})
+	}
+	return rules
+}
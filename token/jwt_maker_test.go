@@ -20,7 +20,7 @@ func TestCreateToken(t *testing.T) {
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, err := maker.CreateToken(username, duration)
+	token, err := maker.CreateToken(username, utils.DepositorRole, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
@@ -30,6 +30,7 @@ func TestCreateToken(t *testing.T) {
 
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, utils.DepositorRole, payload.Role)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
@@ -40,7 +41,7 @@ func TestExpiredToken(t *testing.T) {
 	require.NoError(t, err)
 	require.NotEmpty(t, maker)
 	// create token
-	token, err := maker.CreateToken(utils.GenerateRandomOwner(), -time.Minute)
+	token, err := maker.CreateToken(utils.GenerateRandomOwner(), utils.DepositorRole, -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	// verify token to get payload
@@ -52,7 +53,7 @@ func TestExpiredToken(t *testing.T) {
 
 func TestInvalidJwtTokenAlgNone(t *testing.T) {
 	// create a payload
-	payload, err := NewPayload(utils.GenerateRandomOwner(), time.Minute)
+	payload, err := NewPayload(utils.GenerateRandomOwner(), utils.DepositorRole, time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 	// create token
@@ -3,6 +3,6 @@ package token
 import "time"
 
 type Maker interface {
-	CreateToken(username string, duration time.Duration) (string, error)
+	CreateToken(username string, role string, duration time.Duration) (string, error)
 	VerifyToken(token string) (*Payload, error)
 }
@@ -20,7 +20,7 @@ func TestPasetoCreateToken(t *testing.T) {
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, err := maker.CreateToken(username, duration)
+	token, err := maker.CreateToken(username, utils.DepositorRole, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
@@ -30,6 +30,7 @@ func TestPasetoCreateToken(t *testing.T) {
 
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, utils.DepositorRole, payload.Role)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
@@ -43,7 +44,7 @@ func TestPasetoExpiredToken(t *testing.T) {
 	username := utils.GenerateRandomOwner()
 	duration := -time.Minute
 
-	token, err := maker.CreateToken(username, duration)
+	token, err := maker.CreateToken(username, utils.DepositorRole, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
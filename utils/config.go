@@ -7,11 +7,26 @@ import (
 )
 
 type Config struct {
-	DBDriver            string        `mapstructure:"DB_DRIVER"`
-	DBSource            string        `mapstructure:"DB_SOURCE"`
-	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymetricKey    string        `mapstructure:"TOKEN_SYMETRIC_KEY"`
-	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	DBDriver                string        `mapstructure:"DB_DRIVER"`
+	DBSource                string        `mapstructure:"DB_SOURCE"`
+	ServerAddress           string        `mapstructure:"SERVER_ADDRESS"`
+	TokenSymetricKey        string        `mapstructure:"TOKEN_SYMETRIC_KEY"`
+	AccessTokenDuration     time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	IdempotencyKeyRetention time.Duration `mapstructure:"IDEMPOTENCY_KEY_RETENTION"`
+	FXRatesFile             string        `mapstructure:"FX_RATES_FILE"`
+	FXQuoteDuration         time.Duration `mapstructure:"FX_QUOTE_DURATION"`
+	SchedulerInterval       time.Duration `mapstructure:"SCHEDULER_INTERVAL"`
+	StandingOrderRetryDelay time.Duration `mapstructure:"STANDING_ORDER_RETRY_DELAY"`
+	HoldDuration            time.Duration `mapstructure:"HOLD_DURATION"`
+	TxIsolationLevel        string        `mapstructure:"TX_ISOLATION_LEVEL"`
+	TxMaxRetries            int           `mapstructure:"TX_MAX_RETRIES"`
+	TxRetryBackoff          time.Duration `mapstructure:"TX_RETRY_BACKOFF"`
+	TxMaxRetryBackoff       time.Duration `mapstructure:"TX_MAX_RETRY_BACKOFF"`
+	// risk rules, a rule left at zero is disabled
+	RiskVelocityMaxTransfers int64         `mapstructure:"RISK_VELOCITY_MAX_TRANSFERS"`
+	RiskVelocityWindow       time.Duration `mapstructure:"RISK_VELOCITY_WINDOW"`
+	RiskNewPayeeAmount       int64         `mapstructure:"RISK_NEW_PAYEE_AMOUNT"`
+	RiskPasswordChangeWindow time.Duration `mapstructure:"RISK_PASSWORD_CHANGE_WINDOW"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
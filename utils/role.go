@@ -0,0 +1,7 @@
+package utils
+
+// roles a user can have
+const (
+	DepositorRole = "depositor"
+	AdminRole     = "admin"
+)
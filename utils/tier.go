@@ -0,0 +1,7 @@
+package utils
+
+// tiers select the transfer limits of a user
+const (
+	StandardTier = "standard"
+	PremiumTier  = "premium"
+)
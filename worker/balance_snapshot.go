@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+)
+
+// SnapshotSettleDelay is how long after the end of a UTC day its snapshot is taken. Entries are stamped by the clock of
+// the app instance that writes them, before their transaction commits, so an entry stamped just before midnight may
+// commit after it, and an instance whose clock runs behind stamps entries into a day that has already ended elsewhere.
+// A transfer holds its transaction for seconds and the instances keep their clocks within a second of each other, an
+// hour leaves room for both.
+const SnapshotSettleDelay = time.Hour
+
+// BalanceSnapshotter writes the daily balance snapshots used by db.BalanceAt, one per account for the start of every
+// UTC day. A snapshot builds on the previous one of the account, so taking it only reads the entries of one day.
+// Snapshots are never overwritten, running several replicas side by side is harmless.
+type BalanceSnapshotter struct {
+	store    db.Store
+	interval time.Duration
+	// the last day snapshotted by this process
+	last time.Time
+}
+
+func NewBalanceSnapshotter(store db.Store, interval time.Duration) *BalanceSnapshotter {
+	return &BalanceSnapshotter{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Start checks every interval whether a new day is due for a snapshot until ctx is canceled
+func (snapshotter *BalanceSnapshotter) Start(ctx context.Context) {
+	ticker := time.NewTicker(snapshotter.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := snapshotter.RunOnce(ctx, time.Now()); err != nil {
+			log.Println("cannot take balance snapshots:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce snapshots the latest day that has settled at now unless it was already done, returning how many account
+// snapshots were written
+func (snapshotter *BalanceSnapshotter) RunOnce(ctx context.Context, now time.Time) (int64, error) {
+	day, _ := db.LimitPeriods(now.Add(-SnapshotSettleDelay))
+	if !day.After(snapshotter.last) {
+		return 0, nil
+	}
+	count, err := snapshotter.store.CreateBalanceSnapshots(ctx, day)
+	if err != nil {
+		return 0, err
+	}
+	snapshotter.last = day
+	return count, nil
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceSnapshotterRunOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	march5 := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	march6 := march5.AddDate(0, 0, 1)
+	gomock.InOrder(
+		store.EXPECT().CreateBalanceSnapshots(gomock.Any(), gomock.Eq(march5)).Times(1).Return(int64(3), nil),
+		store.EXPECT().CreateBalanceSnapshots(gomock.Any(), gomock.Eq(march6)).Times(1).Return(int64(4), nil),
+	)
+
+	snapshotter := NewBalanceSnapshotter(store, time.Minute)
+
+	// shortly after midnight the day is not settled yet, so the previous one is snapshotted
+	count, err := snapshotter.RunOnce(context.Background(), march6.Add(SnapshotSettleDelay/2))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+
+	// a day is only snapshotted once
+	count, err = snapshotter.RunOnce(context.Background(), march6.Add(SnapshotSettleDelay*3/4))
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	count, err = snapshotter.RunOnce(context.Background(), march6.Add(2*SnapshotSettleDelay))
+	require.NoError(t, err)
+	require.Equal(t, int64(4), count)
+}
+
+func TestBalanceSnapshotterRunOnceError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	// a failed day is tried again on the next run
+	gomock.InOrder(
+		store.EXPECT().CreateBalanceSnapshots(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), sql.ErrConnDone),
+		store.EXPECT().CreateBalanceSnapshots(gomock.Any(), gomock.Any()).Times(1).Return(int64(2), nil),
+	)
+
+	snapshotter := NewBalanceSnapshotter(store, time.Minute)
+	_, err := snapshotter.RunOnce(context.Background(), now)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+
+	count, err := snapshotter.RunOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+}
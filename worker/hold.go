@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+)
+
+// HoldSweeper releases holds that expired without being captured or voided, and deletes the idempotency keys older
+// than their retention along with the responses stored for them. Each hold is released in its own transaction, see
+// Store.ExpireHoldTx, and keys are deleted a batch at a time.
+type HoldSweeper struct {
+	store     db.Store
+	interval  time.Duration
+	batchSize int
+	// keyRetention is how long an idempotency key is kept, retries after it are treated as new requests anyway
+	keyRetention time.Duration
+}
+
+func NewHoldSweeper(store db.Store, interval time.Duration, keyRetention time.Duration) *HoldSweeper {
+	return &HoldSweeper{
+		store:        store,
+		interval:     interval,
+		batchSize:    DefaultBatchSize,
+		keyRetention: keyRetention,
+	}
+}
+
+// Start polls for expired holds and idempotency keys every interval until ctx is canceled
+func (sweeper *HoldSweeper) Start(ctx context.Context) {
+	ticker := time.NewTicker(sweeper.interval)
+	defer ticker.Stop()
+	for {
+		for {
+			count, err := sweeper.RunOnce(ctx, time.Now())
+			if err != nil {
+				log.Println("cannot expire holds:", err)
+				break
+			}
+			if count < sweeper.batchSize {
+				break
+			}
+		}
+		for {
+			count, err := sweeper.DeleteExpiredKeys(ctx, time.Now())
+			if err != nil {
+				log.Println("cannot delete expired idempotency keys:", err)
+				break
+			}
+			if count < sweeper.batchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce releases up to a batch of holds expired at now, returning how many were released
+func (sweeper *HoldSweeper) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	for count := 0; count < sweeper.batchSize; count++ {
+		_, err := sweeper.store.ExpireHoldTx(ctx, now)
+		if errors.Is(err, sql.ErrNoRows) {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+	}
+	return sweeper.batchSize, nil
+}
+
+// DeleteExpiredKeys deletes up to a batch of idempotency keys that were past their retention at now, returning how
+// many were deleted
+func (sweeper *HoldSweeper) DeleteExpiredKeys(ctx context.Context, now time.Time) (int, error) {
+	deleted, err := sweeper.store.DeleteExpiredIdempotencyKeys(ctx, db.DeleteExpiredIdempotencyKeysParams{
+		Before:    now.Add(-sweeper.keyRetention),
+		BatchSize: int32(sweeper.batchSize),
+	})
+	return int(deleted), err
+}
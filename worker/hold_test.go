@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoldSweeperRunOnce(t *testing.T) {
+	now := time.Now()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	gomock.InOrder(
+		store.EXPECT().ExpireHoldTx(gomock.Any(), gomock.Eq(now)).Times(3).Return(db.HoldTxResult{}, nil),
+		store.EXPECT().ExpireHoldTx(gomock.Any(), gomock.Eq(now)).Times(1).Return(db.HoldTxResult{}, sql.ErrNoRows),
+	)
+
+	sweeper := NewHoldSweeper(store, time.Minute, time.Hour)
+	count, err := sweeper.RunOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+}
+
+func TestHoldSweeperRunOnceError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().ExpireHoldTx(gomock.Any(), gomock.Any()).Times(1).Return(db.HoldTxResult{}, sql.ErrConnDone)
+
+	sweeper := NewHoldSweeper(store, time.Minute, time.Hour)
+	_, err := sweeper.RunOnce(context.Background(), time.Now())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
+
+func TestHoldSweeperDeleteExpiredKeys(t *testing.T) {
+	now := time.Now()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	arg := db.DeleteExpiredIdempotencyKeysParams{Before: now.Add(-time.Hour), BatchSize: DefaultBatchSize}
+	store.EXPECT().DeleteExpiredIdempotencyKeys(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(7), nil)
+
+	sweeper := NewHoldSweeper(store, time.Minute, time.Hour)
+	count, err := sweeper.DeleteExpiredKeys(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 7, count)
+}
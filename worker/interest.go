@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+)
+
+// InterestWorker accrues interest daily and posts it monthly. A UTC day is accrued SnapshotSettleDelay after it ends,
+// once the entries the app instances stamped with that day have committed, and the interest accrued in a month is
+// posted once its last day has been accrued. Accruals and postings each run in
+// their own transaction, see Store.AccrueInterestTx and Store.PostInterestTx.
+type InterestWorker struct {
+	store     db.Store
+	interval  time.Duration
+	batchSize int
+}
+
+func NewInterestWorker(store db.Store, interval time.Duration) *InterestWorker {
+	return &InterestWorker{
+		store:     store,
+		interval:  interval,
+		batchSize: DefaultBatchSize,
+	}
+}
+
+// Start accrues and posts due interest every interval until ctx is canceled
+func (worker *InterestWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(worker.interval)
+	defer ticker.Stop()
+	for {
+		for {
+			count, err := worker.RunOnce(ctx, time.Now())
+			if err != nil {
+				log.Println("cannot accrue interest:", err)
+				break
+			}
+			if count < worker.batchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce accrues up to a batch of account days settled at now and, once no accrual is left, posts interest with the
+// rest of the batch. It returns how many accruals and postings were made
+func (worker *InterestWorker) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	dayStart, monthStart := db.LimitPeriods(now.Add(-SnapshotSettleDelay))
+	count := 0
+	for ; count < worker.batchSize; count++ {
+		_, err := worker.store.AccrueInterestTx(ctx, db.AccrueInterestTxParams{Before: dayStart})
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+	}
+	// posting waits for the accruals, so the last day of the month is not left for a posting of its own
+	for ; count < worker.batchSize; count++ {
+		_, err := worker.store.PostInterestTx(ctx, db.PostInterestTxParams{Before: monthStart})
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterestWorkerRunOnce(t *testing.T) {
+	// early on the first of the month the last day of the previous month has not settled yet
+	now := time.Date(2024, 4, 1, 0, 30, 0, 0, time.UTC)
+	dayStart := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	gomock.InOrder(
+		store.EXPECT().
+			AccrueInterestTx(gomock.Any(), gomock.Eq(db.AccrueInterestTxParams{Before: dayStart})).
+			Times(2).
+			Return(db.InterestAccrual{}, nil),
+		store.EXPECT().
+			AccrueInterestTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.InterestAccrual{}, sql.ErrNoRows),
+		store.EXPECT().
+			PostInterestTx(gomock.Any(), gomock.Eq(db.PostInterestTxParams{Before: monthStart})).
+			Times(1).
+			Return(db.PostInterestTxResult{}, nil),
+		store.EXPECT().
+			PostInterestTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.PostInterestTxResult{}, sql.ErrNoRows),
+	)
+
+	interestWorker := NewInterestWorker(store, time.Minute)
+	count, err := interestWorker.RunOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+}
+
+func TestInterestWorkerRunOnceError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	// nothing is posted while accruals are failing
+	store.EXPECT().AccrueInterestTx(gomock.Any(), gomock.Any()).Times(1).Return(db.InterestAccrual{}, sql.ErrConnDone)
+	store.EXPECT().PostInterestTx(gomock.Any(), gomock.Any()).Times(0)
+
+	interestWorker := NewInterestWorker(store, time.Minute)
+	_, err := interestWorker.RunOnce(context.Background(), time.Now())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
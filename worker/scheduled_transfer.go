@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+)
+
+// DefaultBatchSize is how many due rows a worker handles per run
+const DefaultBatchSize = 50
+
+// ScheduledTransferWorker executes scheduled transfers once they are due. Each transfer runs in its own transaction
+// that holds the row locked, see Store.ExecuteScheduledTransferTx, so several replicas can run the worker side by side
+// without executing a transfer twice.
+type ScheduledTransferWorker struct {
+	store     db.Store
+	interval  time.Duration
+	batchSize int
+}
+
+func NewScheduledTransferWorker(store db.Store, interval time.Duration) *ScheduledTransferWorker {
+	return &ScheduledTransferWorker{
+		store:     store,
+		interval:  interval,
+		batchSize: DefaultBatchSize,
+	}
+}
+
+// Start polls for due transfers every interval until ctx is canceled
+func (worker *ScheduledTransferWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(worker.interval)
+	defer ticker.Stop()
+	for {
+		// keep going until a run comes back short, so a backlog is drained without waiting for the next tick
+		for {
+			count, err := worker.RunOnce(ctx, time.Now())
+			if err != nil {
+				log.Println("cannot run scheduled transfers:", err)
+				break
+			}
+			if count < worker.batchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce executes up to a batch of transfers due at now, returning how many were executed
+func (worker *ScheduledTransferWorker) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	for count := 0; count < worker.batchSize; count++ {
+		_, err := worker.store.ExecuteScheduledTransferTx(ctx, now)
+		if errors.Is(err, sql.ErrNoRows) {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+	}
+	return worker.batchSize, nil
+}
@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunOnce(t *testing.T) {
+	now := time.Now()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	gomock.InOrder(
+		store.EXPECT().ExecuteScheduledTransferTx(gomock.Any(), gomock.Eq(now)).Times(1).
+			Return(db.ScheduledTransfer{ID: 1, Status: db.ScheduledTransferStatusSucceeded}, nil),
+		store.EXPECT().ExecuteScheduledTransferTx(gomock.Any(), gomock.Eq(now)).Times(1).
+			Return(db.ScheduledTransfer{ID: 2, Status: db.ScheduledTransferStatusFailed}, nil),
+		store.EXPECT().ExecuteScheduledTransferTx(gomock.Any(), gomock.Eq(now)).Times(1).
+			Return(db.ScheduledTransfer{}, sql.ErrNoRows),
+	)
+
+	worker := NewScheduledTransferWorker(store, time.Minute)
+	count, err := worker.RunOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
+
+func TestRunOnceError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ScheduledTransfer{}, sql.ErrConnDone)
+
+	worker := NewScheduledTransferWorker(store, time.Minute)
+	_, err := worker.RunOnce(context.Background(), time.Now())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+)
+
+// StandingOrderWorker executes the due occurrences of standing orders. Each occurrence runs in its own transaction
+// that holds the order locked, see Store.ExecuteStandingOrderTx.
+type StandingOrderWorker struct {
+	store      db.Store
+	interval   time.Duration
+	retryDelay time.Duration
+	batchSize  int
+}
+
+func NewStandingOrderWorker(store db.Store, interval time.Duration, retryDelay time.Duration) *StandingOrderWorker {
+	return &StandingOrderWorker{
+		store:      store,
+		interval:   interval,
+		retryDelay: retryDelay,
+		batchSize:  DefaultBatchSize,
+	}
+}
+
+// Start polls for due standing orders every interval until ctx is canceled
+func (worker *StandingOrderWorker) Start(ctx context.Context) {
+	ticker := time.NewTicker(worker.interval)
+	defer ticker.Stop()
+	for {
+		for {
+			count, err := worker.RunOnce(ctx, time.Now())
+			if err != nil {
+				log.Println("cannot run standing orders:", err)
+				break
+			}
+			if count < worker.batchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce executes up to a batch of occurrences due at now, returning how many were executed
+func (worker *StandingOrderWorker) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	for count := 0; count < worker.batchSize; count++ {
+		_, err := worker.store.ExecuteStandingOrderTx(ctx, db.ExecuteStandingOrderTxParams{
+			Now:        now,
+			RetryDelay: worker.retryDelay,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+	}
+	return worker.batchSize, nil
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	mockdb "github.com/DingBao-sys/simple_bank/db/mock"
+	db "github.com/DingBao-sys/simple_bank/db/sqlc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandingOrderRunOnce(t *testing.T) {
+	now := time.Now()
+	arg := db.ExecuteStandingOrderTxParams{Now: now, RetryDelay: time.Hour}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	gomock.InOrder(
+		store.EXPECT().ExecuteStandingOrderTx(gomock.Any(), gomock.Eq(arg)).Times(2).Return(db.ExecuteStandingOrderTxResult{}, nil),
+		store.EXPECT().ExecuteStandingOrderTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.ExecuteStandingOrderTxResult{}, sql.ErrNoRows),
+	)
+
+	worker := NewStandingOrderWorker(store, time.Minute, time.Hour)
+	count, err := worker.RunOnce(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
+
+func TestStandingOrderRunOnceError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().ExecuteStandingOrderTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ExecuteStandingOrderTxResult{}, sql.ErrConnDone)
+
+	worker := NewStandingOrderWorker(store, time.Minute, time.Hour)
+	_, err := worker.RunOnce(context.Background(), time.Now())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}